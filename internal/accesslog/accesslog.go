@@ -0,0 +1,77 @@
+package accesslog
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/auth"
+	"github.com/elysiandb/elysian-gate/internal/logger"
+	"github.com/elysiandb/elysian-gate/internal/requestid"
+	"github.com/elysiandb/elysian-gate/internal/sharding"
+	"github.com/elysiandb/elysian-gate/internal/tracing"
+	"github.com/valyala/fasthttp"
+)
+
+const requestKey = "request"
+
+func Middleware(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		start := time.Now()
+
+		id := string(ctx.Request.Header.Peek(requestid.Header))
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		r := &requestid.Request{ID: id}
+		ctx.SetUserValue(requestKey, r)
+		ctx.Response.Header.Set(requestid.Header, id)
+
+		next(ctx)
+
+		path := string(ctx.Path())
+		entity, _ := ctx.UserValue("entity").(string)
+		if entity == "" {
+			entity, _ = sharding.ParseAPIPath(path)
+		}
+
+		fields := []logger.Field{
+			logger.F("request_id", id),
+			logger.F("method", string(ctx.Method())),
+			logger.F("path", path),
+			logger.F("entity", entity),
+			logger.F("status", ctx.Response.StatusCode()),
+			logger.F("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			logger.F("node", strings.Join(r.Nodes(), ",")),
+			logger.F("bytes", bodySize(ctx)),
+			logger.F("client", client(ctx)),
+			logger.F("cache", string(ctx.Response.Header.Peek("X-Cache"))),
+		}
+		if span := tracing.FromRequest(ctx); span != nil {
+			fields = append(fields, logger.F("trace_id", span.Context().TraceID))
+		}
+		logger.Info("access", fields...)
+	}
+}
+
+func Context(ctx *fasthttp.RequestCtx) context.Context {
+	c := context.Background()
+	if r, _ := ctx.UserValue(requestKey).(*requestid.Request); r != nil {
+		c = requestid.NewContext(c, r)
+	}
+	return tracing.NewContext(c, tracing.FromRequest(ctx))
+}
+
+func bodySize(ctx *fasthttp.RequestCtx) int {
+	if ctx.Response.IsBodyStream() {
+		return -1
+	}
+	return len(ctx.Response.Body())
+}
+
+func client(ctx *fasthttp.RequestCtx) string {
+	if p := auth.FromContext(ctx); p != nil {
+		return p.Name
+	}
+	return ctx.RemoteIP().String()
+}
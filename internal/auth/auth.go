@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/logger"
+	"github.com/valyala/fasthttp"
+)
+
+const principalKey = "principal"
+
+type Principal struct {
+	Name   string
+	Roles  []string
+	Method string
+}
+
+var (
+	ErrMissingCredentials = errors.New("missing credentials")
+	ErrInvalidAPIKey      = errors.New("invalid api key")
+)
+
+var verifier = struct {
+	sync.RWMutex
+	keys *keySet
+}{}
+
+func Init() error {
+	keys, err := loadKeySet(configuration.Config.Gateway.Auth.JWT)
+	if err != nil {
+		return err
+	}
+	verifier.Lock()
+	verifier.keys = keys
+	verifier.Unlock()
+	return nil
+}
+
+func Enabled() bool {
+	return configuration.Config.Gateway.Auth.Enabled
+}
+
+func Middleware(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		if !Enabled() {
+			next(ctx)
+			return
+		}
+
+		p, err := Authenticate(&ctx.Request.Header)
+		if err != nil {
+			logger.Warn("rejected unauthenticated request", logger.F("method", string(ctx.Method())), logger.F("path", string(ctx.Path())), logger.F("error", err))
+			ctx.Response.Header.Set("WWW-Authenticate", `Bearer realm="elysiangate"`)
+			writeError(ctx, fasthttp.StatusUnauthorized, "unauthorized", err.Error())
+			return
+		}
+
+		ctx.SetUserValue(principalKey, p)
+		next(ctx)
+	}
+}
+
+func Authenticate(h *fasthttp.RequestHeader) (*Principal, error) {
+	if key := string(h.Peek("X-API-Key")); key != "" {
+		return authenticateAPIKey(key)
+	}
+
+	authz := strings.TrimSpace(string(h.Peek("Authorization")))
+	scheme, credentials, _ := strings.Cut(authz, " ")
+	credentials = strings.TrimSpace(credentials)
+	switch {
+	case strings.EqualFold(scheme, "ApiKey") && credentials != "":
+		return authenticateAPIKey(credentials)
+	case strings.EqualFold(scheme, "Bearer") && credentials != "":
+		verifier.RLock()
+		keys := verifier.keys
+		verifier.RUnlock()
+		return verifyJWT(credentials, keys, configuration.Config.Gateway.Auth.JWT)
+	}
+	return nil, ErrMissingCredentials
+}
+
+func FromContext(ctx *fasthttp.RequestCtx) *Principal {
+	p, _ := ctx.UserValue(principalKey).(*Principal)
+	return p
+}
+
+func authenticateAPIKey(key string) (*Principal, error) {
+	for _, k := range configuration.Config.Gateway.Auth.APIKeys {
+		if k.Key != "" && subtle.ConstantTimeCompare([]byte(k.Key), []byte(key)) == 1 {
+			return &Principal{Name: k.Name, Roles: k.Roles, Method: "api-key"}, nil
+		}
+	}
+	return nil, ErrInvalidAPIKey
+}
+
+func writeError(ctx *fasthttp.RequestCtx, status int, code string, detail string) {
+	data, _ := json.Marshal(map[string]string{"error": code, "detail": detail})
+	ctx.SetContentType("application/json")
+	ctx.SetStatusCode(status)
+	ctx.SetBody(data)
+}
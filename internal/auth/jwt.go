@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+)
+
+const defaultRolesClaim = "roles"
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type keySet struct {
+	hmacSecret []byte
+	rsaDefault *rsa.PublicKey
+	rsaByKid   map[string]*rsa.PublicKey
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+func loadKeySet(cfg configuration.JWT) (*keySet, error) {
+	ks := &keySet{rsaByKid: map[string]*rsa.PublicKey{}}
+	if cfg.HMACSecret != "" {
+		ks.hmacSecret = []byte(cfg.HMACSecret)
+	}
+
+	if cfg.RSAPublicKeyFile != "" {
+		data, err := os.ReadFile(cfg.RSAPublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read rsa public key: %w", err)
+		}
+		key, err := parseRSAPublicKey(data)
+		if err != nil {
+			return nil, err
+		}
+		ks.rsaDefault = key
+	}
+
+	if cfg.JWKSFile != "" {
+		data, err := os.ReadFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("read jwks: %w", err)
+		}
+		var set jwks
+		if err := json.Unmarshal(data, &set); err != nil {
+			return nil, fmt.Errorf("parse jwks: %w", err)
+		}
+		for _, k := range set.Keys {
+			if k.Kty != "RSA" {
+				continue
+			}
+			key, err := jwkToRSA(k.N, k.E)
+			if err != nil {
+				return nil, fmt.Errorf("jwks key %s: %w", k.Kid, err)
+			}
+			ks.rsaByKid[k.Kid] = key
+		}
+	}
+	return ks, nil
+}
+
+func parseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("rsa public key: no PEM block found")
+	}
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		if rsaKey, ok := key.(*rsa.PublicKey); ok {
+			return rsaKey, nil
+		}
+		return nil, errors.New("rsa public key: not an RSA key")
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+		if rsaKey, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+			return rsaKey, nil
+		}
+	}
+	return nil, errors.New("rsa public key: unsupported format")
+}
+
+func jwkToRSA(n string, e string) (*rsa.PublicKey, error) {
+	nb, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, err
+	}
+	eb, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil {
+		return nil, err
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(nb), E: int(new(big.Int).SetBytes(eb).Int64())}, nil
+}
+
+func verifyJWT(token string, keys *keySet, cfg configuration.JWT) (*Principal, error) {
+	if keys == nil {
+		return nil, fmt.Errorf("%w: no verification keys configured", ErrInvalidToken)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature encoding", ErrInvalidToken)
+	}
+	if err := verifySignature(header, parts[0]+"."+parts[1], signature, keys); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	if err := validateClaims(claims, cfg); err != nil {
+		return nil, err
+	}
+
+	rolesClaim := cfg.RolesClaim
+	if rolesClaim == "" {
+		rolesClaim = defaultRolesClaim
+	}
+	name, _ := claims["sub"].(string)
+	return &Principal{Name: name, Roles: stringList(claims[rolesClaim]), Method: "jwt"}, nil
+}
+
+type algorithm struct {
+	hmac    bool
+	newHash func() hash.Hash
+	hash    crypto.Hash
+}
+
+var algorithms = map[string]algorithm{
+	"HS256": {hmac: true, newHash: sha256.New, hash: crypto.SHA256},
+	"HS384": {hmac: true, newHash: sha512.New384, hash: crypto.SHA384},
+	"HS512": {hmac: true, newHash: sha512.New, hash: crypto.SHA512},
+	"RS256": {newHash: sha256.New, hash: crypto.SHA256},
+	"RS384": {newHash: sha512.New384, hash: crypto.SHA384},
+	"RS512": {newHash: sha512.New, hash: crypto.SHA512},
+}
+
+func verifySignature(header jwtHeader, signed string, signature []byte, keys *keySet) error {
+	alg, ok := algorithms[header.Alg]
+	if !ok {
+		return fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, header.Alg)
+	}
+
+	if alg.hmac {
+		if keys.hmacSecret == nil {
+			return fmt.Errorf("%w: no hmac secret configured", ErrInvalidToken)
+		}
+		mac := hmac.New(alg.newHash, keys.hmacSecret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		return nil
+	}
+
+	key := keys.rsaDefault
+	if k, ok := keys.rsaByKid[header.Kid]; ok {
+		key = k
+	}
+	if key == nil {
+		return fmt.Errorf("%w: no rsa key for kid %q", ErrInvalidToken, header.Kid)
+	}
+	h := alg.newHash()
+	h.Write([]byte(signed))
+	if err := rsa.VerifyPKCS1v15(key, alg.hash, h.Sum(nil), signature); err != nil {
+		return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+	return nil
+}
+
+func validateClaims(claims map[string]any, cfg configuration.JWT) error {
+	leeway := time.Duration(cfg.LeewaySeconds) * time.Second
+	now := time.Now()
+
+	exp, ok, err := timeClaim(claims, "exp")
+	if err != nil {
+		return err
+	}
+	if !ok && !cfg.AllowMissingExp {
+		return fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	}
+	if ok && now.After(exp.Add(leeway)) {
+		return fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+	nbf, ok, err := timeClaim(claims, "nbf")
+	if err != nil {
+		return err
+	}
+	if ok && now.Add(leeway).Before(nbf) {
+		return fmt.Errorf("%w: not yet valid", ErrInvalidToken)
+	}
+	if cfg.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != cfg.Issuer {
+			return fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+		}
+	}
+	if cfg.Audience != "" {
+		found := false
+		for _, aud := range stringList(claims["aud"]) {
+			if aud == cfg.Audience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+		}
+	}
+	return nil
+}
+
+func timeClaim(claims map[string]any, name string) (time.Time, bool, error) {
+	v, ok := claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	n, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("%w: %s is not a number", ErrInvalidToken, name)
+	}
+	return time.Unix(int64(n), 0), true, nil
+}
+
+func decodeSegment(seg string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func stringList(v any) []string {
+	switch val := v.(type) {
+	case string:
+		return strings.Fields(val)
+	case []any:
+		out := []string{}
+		for _, item := range val {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
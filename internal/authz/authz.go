@@ -0,0 +1,100 @@
+package authz
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/elysiandb/elysian-gate/internal/auth"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/logger"
+	"github.com/valyala/fasthttp"
+)
+
+type Action string
+
+const (
+	ActionRead    Action = "read"
+	ActionCreate  Action = "create"
+	ActionUpdate  Action = "update"
+	ActionDelete  Action = "delete"
+	ActionDestroy Action = "destroy"
+	ActionAdmin   Action = "admin"
+)
+
+const (
+	wildcard      = "*"
+	anonymousRole = "anonymous"
+)
+
+func Allowed(p *auth.Principal, entity string, action Action) bool {
+	cfg := configuration.Config.Gateway.Authorization
+	if !cfg.Enabled && action != ActionAdmin {
+		return true
+	}
+
+	roles := []string{anonymousRole}
+	if p != nil {
+		roles = p.Roles
+		if grants, ok := cfg.Principals[principalKey(p)]; ok && allows(grants, entity, action) {
+			return true
+		}
+	}
+	for _, role := range roles {
+		if grants, ok := cfg.Roles[role]; ok && allows(grants, entity, action) {
+			return true
+		}
+	}
+	return false
+}
+
+func Authorize(ctx *fasthttp.RequestCtx, entity string, action Action) bool {
+	p := auth.FromContext(ctx)
+	if Allowed(p, entity, action) {
+		return true
+	}
+
+	name := anonymousRole
+	if p != nil {
+		name = p.Name
+	}
+	target := entity
+	if target == "" {
+		target = "the gateway"
+	}
+	detail := fmt.Sprintf("%s may not %s %s", name, action, target)
+	logger.Warn("forbidden", logger.F("detail", detail))
+
+	data, _ := json.Marshal(map[string]string{"error": "forbidden", "detail": detail})
+	ctx.SetContentType("application/json")
+	ctx.SetStatusCode(fasthttp.StatusForbidden)
+	ctx.SetBody(data)
+	return false
+}
+
+// principalKey namespaces grants by how the caller authenticated, so a JWT
+// subject that matches an API key name does not inherit the key's grants.
+func principalKey(p *auth.Principal) string {
+	switch p.Method {
+	case "api-key":
+		return "apikey:" + p.Name
+	case "jwt":
+		return "jwt:" + p.Name
+	}
+	return ""
+}
+
+func allows(g configuration.Grants, entity string, action Action) bool {
+	actions, ok := g[entity]
+	if !ok {
+		actions, ok = g[wildcard]
+	}
+	if !ok {
+		return false
+	}
+	for _, a := range actions {
+		if a == wildcard || Action(a) == action {
+			return true
+		}
+	}
+	return false
+}
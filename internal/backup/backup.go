@@ -0,0 +1,429 @@
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/cache"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/events"
+	"github.com/elysiandb/elysian-gate/internal/global"
+	"github.com/elysiandb/elysian-gate/internal/logger"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+	"github.com/elysiandb/elysian-gate/internal/replication"
+	"github.com/elysiandb/elysian-gate/internal/tracing"
+)
+
+const (
+	FormatVersion = 1
+	manifestName  = "manifest.json"
+	maxLineBytes  = 64 << 20
+)
+
+var ErrInvalidArchive = errors.New("invalid backup archive")
+
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Seq       int64     `json:"seq"`
+	Groups    []Group   `json:"groups"`
+}
+
+type Group struct {
+	Name     string   `json:"name"`
+	Master   string   `json:"master"`
+	Entities []Entity `json:"entities"`
+}
+
+type Entity struct {
+	Type    string `json:"type"`
+	Records int    `json:"records"`
+	File    string `json:"file"`
+}
+
+type RestoreReport struct {
+	Seq       int64          `json:"seq"`
+	ChangeSeq int64          `json:"change_seq"`
+	Records   map[string]int `json:"records"`
+	Removed   []string       `json:"removed"`
+	Discarded int            `json:"discarded_ops"`
+	Resynced  []string       `json:"resynced"`
+	Failed    map[string]any `json:"failed,omitempty"`
+}
+
+func Create(ctx context.Context, w io.Writer) (Manifest, error) {
+	a, err := Export(ctx)
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer a.Close()
+	return a.Manifest, a.Write(w)
+}
+
+// Archive is an export spooled to a temporary file. Writes are paused only
+// while the masters are read, and the tar.gz is produced afterwards.
+type Archive struct {
+	Manifest Manifest
+	spool    *os.File
+	sections map[string]section
+}
+
+type section struct {
+	offset int64
+	size   int64
+}
+
+func Export(ctx context.Context) (*Archive, error) {
+	ctx, span := tracing.Start(ctx, "backup.Export")
+	defer span.End()
+
+	f, err := os.CreateTemp(configuration.Config.Gateway.Backup.SpoolDir, "elysian-backup-*.jsonl")
+	if err != nil {
+		return nil, err
+	}
+	a := &Archive{spool: f, sections: map[string]section{}}
+
+	seq, resume := balancer.PauseWrites()
+	err = a.export(ctx, seq)
+	resume()
+	if err != nil {
+		a.Close()
+		span.SetError(err)
+		return nil, err
+	}
+
+	span.SetAttributes(tracing.Attr("elysian.seq", seq), tracing.Attr("elysian.groups", len(a.Manifest.Groups)))
+	return a, nil
+}
+
+func (a *Archive) export(ctx context.Context, seq int64) error {
+	m := Manifest{Version: FormatVersion, CreatedAt: time.Now().UTC(), Seq: seq}
+	w := bufio.NewWriter(a.spool)
+	var offset int64
+
+	snap := nodes.ElysianCluster.Snapshot()
+	groups := snap.Groups()
+	sort.Strings(groups)
+	for _, name := range groups {
+		master, ok := snap.Master(name)
+		if !ok {
+			return fmt.Errorf("group %s has no master", name)
+		}
+		types, err := replication.ListEntityTypes(ctx, &master)
+		if err != nil {
+			return fmt.Errorf("listing entity types on %s: %w", master.Name, err)
+		}
+		sort.Strings(types)
+
+		g := Group{Name: name, Master: master.Name, Entities: []Entity{}}
+		for _, t := range types {
+			records, err := replication.ListEntities(ctx, &master, t)
+			if err != nil {
+				return fmt.Errorf("exporting %s from %s: %w", t, master.Name, err)
+			}
+			var size int64
+			for _, r := range records {
+				line, err := json.Marshal(r)
+				if err != nil {
+					return err
+				}
+				w.Write(line)
+				w.WriteByte('\n')
+				size += int64(len(line)) + 1
+			}
+			file := path.Join("groups", name, t+".jsonl")
+			a.sections[file] = section{offset: offset, size: size}
+			offset += size
+			g.Entities = append(g.Entities, Entity{Type: t, Records: len(records), File: file})
+		}
+		m.Groups = append(m.Groups, g)
+	}
+	a.Manifest = m
+	return w.Flush()
+}
+
+// Write streams the archive as tar.gz, manifest first.
+func (a *Archive) Write(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	m := a.Manifest
+	manifest, _ := json.MarshalIndent(m, "", "  ")
+	if err := writeFile(tw, manifestName, bytes.NewReader(manifest), int64(len(manifest)), m.CreatedAt); err != nil {
+		return err
+	}
+	for _, g := range m.Groups {
+		for _, e := range g.Entities {
+			sec := a.sections[e.File]
+			if err := writeFile(tw, e.File, io.NewSectionReader(a.spool, sec.offset, sec.size), sec.size, m.CreatedAt); err != nil {
+				return err
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	logger.Info("backup created", logger.F("seq", m.Seq), logger.F("groups", len(m.Groups)))
+	return nil
+}
+
+func (a *Archive) Close() error {
+	a.spool.Close()
+	return os.Remove(a.spool.Name())
+}
+
+func writeFile(tw *tar.Writer, name string, r io.Reader, size int64, mod time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: size, ModTime: mod}); err != nil {
+		return err
+	}
+	_, err := io.Copy(tw, r)
+	return err
+}
+
+func Read(r io.Reader) (Manifest, map[string][]map[string]interface{}, error) {
+	files := map[string][]map[string]interface{}{}
+	m, err := Walk(r, func(file string, rec map[string]interface{}) error {
+		files[file] = append(files[file], rec)
+		return nil
+	})
+	if err != nil {
+		return m, nil, err
+	}
+	for _, g := range m.Groups {
+		for _, e := range g.Entities {
+			if files[e.File] == nil {
+				files[e.File] = []map[string]interface{}{}
+			}
+		}
+	}
+	return m, files, nil
+}
+
+// Walk streams the archive record by record, so it never holds more than one
+// line in memory. fn may be nil to only verify the archive.
+func Walk(r io.Reader, fn func(file string, rec map[string]interface{}) error) (Manifest, error) {
+	var m Manifest
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return m, fmt.Errorf("not a backup archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != manifestName {
+		return m, errors.New("not a backup archive: manifest.json must come first")
+	}
+	if err := json.NewDecoder(tr).Decode(&m); err != nil {
+		return m, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if m.Version != FormatVersion {
+		return m, fmt.Errorf("unsupported backup format version %d", m.Version)
+	}
+
+	counts := map[string]int{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return m, err
+		}
+		counts[hdr.Name] = 0
+		sc := bufio.NewScanner(tr)
+		sc.Buffer(make([]byte, 64<<10), maxLineBytes)
+		for sc.Scan() {
+			if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+				continue
+			}
+			var rec map[string]interface{}
+			if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+				return m, fmt.Errorf("invalid record in %s: %w", hdr.Name, err)
+			}
+			counts[hdr.Name]++
+			if fn != nil {
+				if err := fn(hdr.Name, rec); err != nil {
+					return m, err
+				}
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return m, err
+		}
+	}
+
+	for _, g := range m.Groups {
+		for _, e := range g.Entities {
+			if n, ok := counts[e.File]; !ok || n != e.Records {
+				return m, fmt.Errorf("archive is incomplete: %s has %d of %d records", e.File, n, e.Records)
+			}
+		}
+	}
+	return m, nil
+}
+
+func Restore(ctx context.Context, r io.ReadSeeker) (RestoreReport, error) {
+	ctx, span := tracing.Start(ctx, "backup.Restore")
+	defer span.End()
+
+	report := RestoreReport{Records: map[string]int{}, Removed: []string{}, Resynced: []string{}, Failed: map[string]any{}}
+	m, err := Walk(r, nil)
+	if err != nil {
+		err = fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		span.SetError(err)
+		return report, err
+	}
+	report.Seq = m.Seq
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return report, err
+	}
+
+	snap := nodes.ElysianCluster.Snapshot()
+	for _, g := range m.Groups {
+		if _, ok := snap.Master(g.Name); !ok {
+			return report, fmt.Errorf("%w: group %s does not exist in this cluster", ErrInvalidArchive, g.Name)
+		}
+	}
+
+	_, resume := balancer.PauseWrites()
+	held := []string{}
+	for _, g := range m.Groups {
+		for _, n := range nodes.ElysianCluster.Snapshot().Nodes {
+			if n.Role != "slave" || n.ShardGroup() != g.Name {
+				continue
+			}
+			if err := nodes.ElysianCluster.Hold(n.Name); err != nil {
+				report.Failed[n.Name] = err.Error()
+				continue
+			}
+			held = append(held, n.Name)
+		}
+	}
+	loadErr := load(ctx, m, r, &report)
+	recordRestore(m, &report, loadErr)
+	resume()
+
+	for _, name := range held {
+		if err := nodes.ElysianCluster.ResyncHeld(name); err != nil {
+			report.Failed[name] = err.Error()
+			continue
+		}
+		report.Resynced = append(report.Resynced, name)
+	}
+	if loadErr != nil {
+		span.SetError(loadErr)
+		return report, loadErr
+	}
+	if len(report.Failed) > 0 {
+		names := make([]string, 0, len(report.Failed))
+		for name := range report.Failed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		err = fmt.Errorf("restore loaded the masters but could not resync %s", strings.Join(names, ", "))
+		span.SetError(err)
+	}
+
+	logger.Info("backup restored", logger.F("seq", m.Seq), logger.F("resynced", strings.Join(report.Resynced, ",")))
+	return report, err
+}
+
+// recordRestore logs a restore change for every type the restore touched and
+// publishes the restore event, also when loading failed half way.
+func recordRestore(m Manifest, report *RestoreReport, loadErr error) {
+	removed := map[string][]string{}
+	for _, r := range report.Removed {
+		group, entity, _ := strings.Cut(r, "/")
+		removed[group] = append(removed[group], entity)
+	}
+	for _, g := range m.Groups {
+		entities := removed[g.Name]
+		for _, e := range g.Entities {
+			entities = append(entities, e.Type)
+		}
+		sort.Strings(entities)
+		report.ChangeSeq = balancer.RecordRestore(g.Name, entities, m.Seq)
+	}
+
+	data := map[string]any{"seq": m.Seq, "change_seq": report.ChangeSeq, "records": report.Records, "removed": report.Removed}
+	if loadErr != nil {
+		data["error"] = loadErr.Error()
+	}
+	events.Publish(events.BackupRestored, "", "", data)
+}
+
+type target struct {
+	master global.Node
+	entity string
+}
+
+func load(ctx context.Context, m Manifest, r io.Reader, report *RestoreReport) error {
+	snap := nodes.ElysianCluster.Snapshot()
+	targets := map[string]target{}
+	for _, g := range m.Groups {
+		master, _ := snap.Master(g.Name)
+		report.Discarded += balancer.DiscardPending(g.Name)
+
+		archived := map[string]bool{}
+		for _, e := range g.Entities {
+			archived[e.Type] = true
+		}
+		types, err := replication.ListEntityTypes(ctx, &master)
+		if err != nil {
+			return fmt.Errorf("listing entity types on %s: %w", master.Name, err)
+		}
+		for _, t := range types {
+			if archived[t] {
+				continue
+			}
+			if err := replication.DeleteEntityType(ctx, &master, t); err != nil {
+				return fmt.Errorf("removing %s from %s: %w", t, master.Name, err)
+			}
+			cache.InvalidateEntity(t)
+			report.Removed = append(report.Removed, g.Name+"/"+t)
+		}
+
+		for _, e := range g.Entities {
+			if err := replication.DeleteEntityType(ctx, &master, e.Type); err != nil {
+				return fmt.Errorf("resetting %s on %s: %w", e.Type, master.Name, err)
+			}
+			targets[e.File] = target{master: master, entity: e.Type}
+		}
+	}
+
+	_, err := Walk(r, func(file string, rec map[string]interface{}) error {
+		t, ok := targets[file]
+		if !ok {
+			return nil
+		}
+		if err := replication.ImportEntity(ctx, &t.master, t.entity, rec); err != nil {
+			return fmt.Errorf("restoring %s on %s: %w", t.entity, t.master.Name, err)
+		}
+		return nil
+	})
+	for _, g := range m.Groups {
+		for _, e := range g.Entities {
+			cache.InvalidateEntity(e.Type)
+			if err == nil {
+				report.Records[g.Name+"/"+e.Type] += e.Records
+			}
+		}
+	}
+	return err
+}
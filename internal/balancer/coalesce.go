@@ -0,0 +1,49 @@
+package balancer
+
+import (
+	"strings"
+
+	"github.com/elysiandb/elysian-gate/internal/coalesce"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/sharding"
+)
+
+const (
+	RouteGet  = "get"
+	RouteList = "list"
+)
+
+var reads coalesce.Group
+
+func coalesced(route string, path string, query string, vary []string, fn func() (int, []byte, error)) (int, []byte, error) {
+	if !coalescingEnabled(route) {
+		return fn()
+	}
+	entity, id := sharding.ParseAPIPath(path)
+	key := entity + "\x00" + id + "\x00" + route + " " + path + "?" + query + "\x00" + strings.Join(vary, "\x00")
+	res := reads.Do(key, fn)
+	return res.Status, res.Body, res.Err
+}
+
+func forgetReads(entity string, match func(id string) bool) {
+	reads.Forget(func(key string) bool {
+		parts := strings.SplitN(key, "\x00", 3)
+		return parts[0] == entity && match(parts[1])
+	})
+}
+
+func coalescingEnabled(route string) bool {
+	cfg := configuration.Config.Gateway.Coalescing
+	if !cfg.Enabled {
+		return false
+	}
+	if len(cfg.Routes) == 0 {
+		return true
+	}
+	for _, r := range cfg.Routes {
+		if r == route {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package balancer
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	latencyWindow     = 128
+	latencyMinSamples = 10
+)
+
+type latencyWindowSamples struct {
+	samples []time.Duration
+	next    int
+}
+
+var latencies = struct {
+	sync.Mutex
+	nodes map[string]*latencyWindowSamples
+}{nodes: map[string]*latencyWindowSamples{}}
+
+func recordLatency(node string, d time.Duration) {
+	latencies.Lock()
+	defer latencies.Unlock()
+	w, ok := latencies.nodes[node]
+	if !ok {
+		w = &latencyWindowSamples{}
+		latencies.nodes[node] = w
+	}
+	if len(w.samples) < latencyWindow {
+		w.samples = append(w.samples, d)
+		return
+	}
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % latencyWindow
+}
+
+func latencyPercentile(node string, p int) (time.Duration, bool) {
+	latencies.Lock()
+	w, ok := latencies.nodes[node]
+	if !ok || len(w.samples) < latencyMinSamples {
+		latencies.Unlock()
+		return 0, false
+	}
+	sorted := append([]time.Duration(nil), w.samples...)
+	latencies.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := (len(sorted)*p + 99) / 100
+	if idx > 0 {
+		idx--
+	}
+	return sorted[idx], true
+}
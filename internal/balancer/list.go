@@ -0,0 +1,383 @@
+package balancer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/forward"
+	"github.com/elysiandb/elysian-gate/internal/global"
+	"github.com/elysiandb/elysian-gate/internal/logger"
+	"github.com/elysiandb/elysian-gate/internal/requestid"
+	"github.com/elysiandb/elysian-gate/internal/sharding"
+)
+
+var errListBudget = fmt.Errorf("list result exceeds gateway memory budget")
+
+type listParams struct {
+	upstream string
+	sorts    []sortKey
+	limit    int
+	offset   int
+}
+
+type sortKey struct {
+	field string
+	desc  bool
+}
+
+type listItem struct {
+	raw json.RawMessage
+	doc map[string]any
+}
+
+func SendListRequest(path string, query string, vary ...string) (int, []byte, error) {
+	return SendListRequestContext(context.Background(), path, query, vary...)
+}
+
+func SendListRequestContext(ctx context.Context, path string, query string, vary ...string) (int, []byte, error) {
+	return coalesced(RouteList, path, query, vary, func() (int, []byte, error) {
+		return sendListRequest(ctx, path, query)
+	})
+}
+
+func sendListRequest(ctx context.Context, path string, query string) (int, []byte, error) {
+	params := parseListQuery(query)
+
+	entity, _ := sharding.ParseAPIPath(path)
+	groups := []string{sharding.GroupFor(entity, "")}
+	if sharding.IsHashed(entity) {
+		groups = sharding.Groups()
+	}
+	if len(groups) == 1 && listFanout(len(GetGroupReadRequestNodes(groups[0]))) == 1 {
+		return sendGroupReadRequest(ctx, groups[0], path, query)
+	}
+
+	merge := &listMerge{seen: map[string]bool{}, budget: newListBudget()}
+	for _, group := range groups {
+		bodies, status, err := fetchGroupLists(forward.WithMaxBody(ctx, merge.budget.remaining()), group, path, params.upstream)
+		if errors.Is(err, forward.ErrBodyTooLarge) {
+			return 507, []byte(`{"error":"list result exceeds gateway memory budget"}`), errListBudget
+		}
+		if err != nil {
+			return status, []byte(fmt.Sprintf(`{"error":"%v"}`, err)), err
+		}
+		if status >= 300 {
+			return status, []byte(bodies[0]), nil
+		}
+		err = merge.addGroup(bodies)
+		if err == errListBudget {
+			return 507, []byte(`{"error":"list result exceeds gateway memory budget"}`), err
+		}
+		if err != nil {
+			return 502, []byte(`{"error":"invalid list response"}`), fmt.Errorf("group %s returned an invalid list: %w", group, err)
+		}
+	}
+	items := merge.items
+
+	sortListItems(items, params.sorts)
+	items = paginate(items, params.offset, params.limit)
+
+	out := make([]json.RawMessage, len(items))
+	for i, it := range items {
+		out[i] = it.raw
+	}
+	data, _ := json.MarshalIndent(out, "", "  ")
+	return 200, data, nil
+}
+
+func fetchGroupLists(ctx context.Context, group string, path string, query string) ([]string, int, error) {
+	candidates := GetGroupReadRequestNodes(group)
+	if len(candidates) == 0 {
+		return nil, 503, fmt.Errorf("no available node")
+	}
+
+	fanout := listFanout(len(candidates))
+
+	ctx, cancel := readBudget(ctx)
+	defer cancel()
+
+	results := make([]attemptResult, fanout)
+	var wg sync.WaitGroup
+	for i := 0; i < fanout; i++ {
+		wg.Add(1)
+		go func(i int, node *global.Node) {
+			defer wg.Done()
+			results[i] = readAttempt(ctx, node, path, query)
+		}(i, candidates[i])
+	}
+	wg.Wait()
+
+	bodies := []string{}
+	for _, r := range results {
+		switch {
+		case r.err != nil && !r.retryable():
+			return nil, 0, r.err
+		case !r.retryable() && r.status < 300:
+			requestid.AddNode(ctx, r.node.Name)
+			bodies = append(bodies, r.body)
+		case !r.retryable():
+			requestid.AddNode(ctx, r.node.Name)
+			return []string{r.body}, r.status, nil
+		default:
+			logger.Warn("list failed", logger.F("node", r.node.Name), logger.F("status", r.status), logger.F("error", r.err))
+		}
+	}
+	if len(bodies) > 0 {
+		return bodies, 200, nil
+	}
+
+	if len(candidates) > fanout {
+		status, body, err := readWithRetries(ctx, candidates[fanout:], path, query)
+		if err != nil {
+			return nil, status, err
+		}
+		return []string{body}, status, nil
+	}
+	if ctx.Err() != nil {
+		return nil, 504, fmt.Errorf("read latency budget exceeded")
+	}
+	return nil, 502, fmt.Errorf("all nodes failed")
+}
+
+func listFanout(candidates int) int {
+	fanout := configuration.Config.Gateway.List.Fanout
+	if fanout <= 0 {
+		fanout = 1
+	}
+	return max(min(fanout, candidates), 1)
+}
+
+type listBudget struct {
+	maxItems int
+	maxBytes int
+	items    int
+	bytes    int
+}
+
+func newListBudget() *listBudget {
+	cfg := configuration.Config.Gateway.List
+	return &listBudget{maxItems: cfg.MaxItems, maxBytes: cfg.MaxBytes}
+}
+
+// remaining is the number of bytes a group may still return, or 0 when no
+// byte budget is configured.
+func (b *listBudget) remaining() int {
+	if b.maxBytes <= 0 {
+		return 0
+	}
+	return max(b.maxBytes-b.bytes, 1)
+}
+
+func (b *listBudget) take(size int) bool {
+	b.items++
+	b.bytes += size
+	return (b.maxItems <= 0 || b.items <= b.maxItems) && (b.maxBytes <= 0 || b.bytes <= b.maxBytes)
+}
+
+type listMerge struct {
+	items  []listItem
+	seen   map[string]bool
+	budget *listBudget
+}
+
+// addGroup merges the bodies one group's replicas returned. Documents with an
+// id are kept once across all groups. Replicas return the same documents
+// without an id too, so those are kept as many times as the replica that
+// returned them most often.
+func (m *listMerge) addGroup(bodies []string) error {
+	kept := map[string]int{}
+	for _, body := range bodies {
+		dec := json.NewDecoder(strings.NewReader(body))
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if d, ok := tok.(json.Delim); !ok || d != '[' {
+			return fmt.Errorf("expected a JSON array")
+		}
+
+		copies := map[string]int{}
+		for dec.More() {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return err
+			}
+			var doc map[string]any
+			json.Unmarshal(raw, &doc)
+			if key, ok := idKey(doc); ok {
+				if m.seen[key] {
+					continue
+				}
+				m.seen[key] = true
+			} else {
+				key := contentKey(doc, raw)
+				copies[key]++
+				if copies[key] <= kept[key] {
+					continue
+				}
+				kept[key]++
+			}
+			if !m.budget.take(len(raw)) {
+				return errListBudget
+			}
+			m.items = append(m.items, listItem{raw: raw, doc: doc})
+		}
+	}
+	return nil
+}
+
+func idKey(doc map[string]any) (string, bool) {
+	id, ok := doc["id"]
+	if !ok || id == nil {
+		return "", false
+	}
+	data, _ := json.Marshal(id)
+	return string(data), true
+}
+
+func contentKey(doc map[string]any, raw json.RawMessage) string {
+	if doc != nil {
+		data, _ := json.Marshal(doc)
+		return string(data)
+	}
+	var buf bytes.Buffer
+	if json.Compact(&buf, raw) != nil {
+		return string(raw)
+	}
+	return buf.String()
+}
+
+func parseListQuery(query string) listParams {
+	p := listParams{limit: -1}
+	upstream := []string{}
+	for _, part := range strings.Split(query, "&") {
+		if part == "" {
+			continue
+		}
+		rawKey, rawVal, _ := strings.Cut(part, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+		val, err := url.QueryUnescape(rawVal)
+		if err != nil {
+			val = rawVal
+		}
+
+		switch {
+		case key == "limit":
+			if n, err := strconv.Atoi(val); err == nil && n >= 0 {
+				p.limit = n
+				continue
+			}
+		case key == "offset":
+			if n, err := strconv.Atoi(val); err == nil && n >= 0 {
+				p.offset = n
+				continue
+			}
+		case strings.HasPrefix(key, "sort[") && strings.HasSuffix(key, "]"):
+			p.sorts = append(p.sorts, sortKey{
+				field: key[len("sort[") : len(key)-1],
+				desc:  strings.EqualFold(val, "desc"),
+			})
+		}
+		upstream = append(upstream, part)
+	}
+
+	if p.limit >= 0 {
+		upstream = append(upstream, "limit="+strconv.Itoa(p.offset+p.limit))
+	}
+	p.upstream = strings.Join(upstream, "&")
+	return p
+}
+
+func sortListItems(items []listItem, sorts []sortKey) {
+	if len(sorts) == 0 {
+		return
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		for _, s := range sorts {
+			c := compareValues(lookupField(items[i].doc, s.field), lookupField(items[j].doc, s.field))
+			if c == 0 {
+				continue
+			}
+			if s.desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+func lookupField(doc map[string]any, field string) any {
+	var cur any = doc
+	for _, part := range strings.Split(field, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[part]
+	}
+	return cur
+}
+
+func compareValues(a any, b any) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+	switch av := a.(type) {
+	case float64:
+		if bv, ok := b.(float64); ok {
+			switch {
+			case av < bv:
+				return -1
+			case av > bv:
+				return 1
+			}
+			return 0
+		}
+	case string:
+		if bv, ok := b.(string); ok {
+			return strings.Compare(av, bv)
+		}
+	case bool:
+		if bv, ok := b.(bool); ok {
+			switch {
+			case av == bv:
+				return 0
+			case !av:
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func paginate(items []listItem, offset int, limit int) []listItem {
+	if offset >= len(items) {
+		return []listItem{}
+	}
+	items = items[offset:]
+	if limit >= 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
@@ -1,77 +1,141 @@
 package balancer
 
 import (
+	"context"
+	"crypto/rand"
 	"encoding/json"
 	"fmt"
-	"math/rand"
+	mrand "math/rand"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/elysiandb/elysian-gate/internal/breaker"
+	"github.com/elysiandb/elysian-gate/internal/cache"
+	"github.com/elysiandb/elysian-gate/internal/changelog"
+	"github.com/elysiandb/elysian-gate/internal/events"
 	"github.com/elysiandb/elysian-gate/internal/forward"
 	"github.com/elysiandb/elysian-gate/internal/global"
 	"github.com/elysiandb/elysian-gate/internal/logger"
 	"github.com/elysiandb/elysian-gate/internal/nodes"
-	"github.com/elysiandb/elysian-gate/internal/state"
+	"github.com/elysiandb/elysian-gate/internal/requestid"
+	"github.com/elysiandb/elysian-gate/internal/sharding"
+	"github.com/elysiandb/elysian-gate/internal/tracing"
 )
 
 type Operation struct {
-	Method  string
-	Path    string
-	Payload string
-	Seq     int64
+	Method    string
+	Path      string
+	Payload   string
+	Seq       int64
+	Group     string
+	RequestID string
+	Trace     tracing.SpanContext
 }
 
 var (
 	lastSeq    int64
-	pendingOps []Operation
+	pendingOps = map[string][]Operation{}
 	mu         sync.Mutex
+	writeGate  sync.RWMutex
 )
 
-func SendReadRequest(path string, query string) (int, []byte, error) {
-	nodes := GetReadRequestNodes()
-	if len(nodes) == 0 {
-		return 503, []byte(`{"error":"no available node"}`), fmt.Errorf("no available node")
-	}
+func SendReadRequest(path string, query string, vary ...string) (int, []byte, error) {
+	return SendReadRequestContext(context.Background(), path, query, vary...)
+}
 
-	for _, node := range nodes {
-		logger.Info(fmt.Sprintf("trying read from node %s", node.Name))
-		url := fmt.Sprintf("http://%s:%d%s", node.HTTP.Host, node.HTTP.Port, path)
-		if query != "" {
-			url += "?" + query
-		}
+func SendReadRequestContext(ctx context.Context, path string, query string, vary ...string) (int, []byte, error) {
+	entity, id := sharding.ParseAPIPath(path)
+	if id == "" && sharding.IsHashed(entity) {
+		return SendListRequestContext(ctx, path, query, vary...)
+	}
+	return coalesced(RouteGet, path, query, vary, func() (int, []byte, error) {
+		return sendGroupReadRequest(ctx, sharding.GroupFor(entity, id), path, query)
+	})
+}
 
-		status, body, err := forward.ForwardRequest("GET", url, "")
-		if err != nil || status >= 300 {
-			logger.Error(fmt.Sprintf("read from node %s failed: %v", node.Name, err))
-			continue
-		}
+func SendGroupReadRequest(group string, path string, query string) (int, []byte, error) {
+	return sendGroupReadRequest(context.Background(), group, path, query)
+}
 
-		var formatted any
-		if json.Unmarshal([]byte(body), &formatted) == nil {
-			data, _ := json.MarshalIndent(formatted, "", "  ")
-			return status, data, nil
-		}
+func sendGroupReadRequest(ctx context.Context, group string, path string, query string) (int, []byte, error) {
+	status, body, err := readFromGroup(ctx, group, path, query)
+	if err != nil {
+		return status, []byte(body), err
+	}
 
-		return status, []byte(body), nil
+	var formatted any
+	if json.Unmarshal([]byte(body), &formatted) == nil {
+		data, _ := json.MarshalIndent(formatted, "", "  ")
+		return status, data, nil
 	}
 
-	return 502, []byte(`{"error":"all nodes failed"}`), fmt.Errorf("all nodes failed")
+	return status, []byte(body), nil
 }
 
 func SendWriteRequestToMaster(method string, path string, payload string) (int, string, error) {
-	master := getMaster()
+	return SendWriteRequestToMasterContext(context.Background(), method, path, payload)
+}
+
+func SendWriteRequestToMasterContext(ctx context.Context, method string, path string, payload string) (int, string, error) {
+	writeGate.RLock()
+	defer writeGate.RUnlock()
+
+	entity, id := sharding.ParseAPIPath(path)
+	ctx, span := tracing.Start(ctx, "balancer.SendWriteRequestToMaster",
+		tracing.Attr("http.method", method),
+		tracing.Attr("elysian.path", path),
+		tracing.Attr("elysian.entity", entity),
+	)
+	status, body, err := sendWriteRequestToMaster(ctx, entity, id, method, path, payload)
+	span.SetAttributes(tracing.Attr("http.status_code", status))
+	span.SetError(err)
+	span.End()
+	return status, body, err
+}
+
+func sendWriteRequestToMaster(ctx context.Context, entity string, id string, method string, path string, payload string) (int, string, error) {
+	if id == "" && sharding.IsHashed(entity) {
+		if method != "POST" {
+			return broadcastWrite(ctx, method, path, payload)
+		}
+		withID, newID, err := ensurePayloadID(payload)
+		if err != nil {
+			return 400, `{"error":"payload must be a JSON object"}`, nil
+		}
+		payload, id = withID, newID
+	}
+	return sendGroupWriteRequest(ctx, sharding.GroupFor(entity, id), method, path, payload)
+}
+
+func SendGroupWriteRequest(group string, method string, path string, payload string) (int, string, error) {
+	writeGate.RLock()
+	defer writeGate.RUnlock()
+	return sendGroupWriteRequest(context.Background(), group, method, path, payload)
+}
+
+func sendGroupWriteRequest(ctx context.Context, group string, method string, path string, payload string) (int, string, error) {
+	master := getGroupMaster(group)
 	if master == nil {
-		logger.Error("no master node available for write")
+		logger.Error("no master node available for write", logger.F("group", group))
 		return 0, "", fmt.Errorf("no master node available for write")
 	}
 
-	state.MarkAllSlavesDirty()
+	if !breaker.Allow(master.Name) {
+		logger.Warn("circuit breaker open for master, rejecting write", logger.F("node", master.Name))
+		return 503, `{"error":"circuit breaker open"}`, breaker.ErrOpen
+	}
+
+	nodes.ElysianCluster.MarkAllDirty()
 
-	url := fmt.Sprintf("http://%s:%d%s", master.HTTP.Host, master.HTTP.Port, path)
-	status, body, err := forward.ForwardRequest(method, url, payload)
+	url := master.URL(path)
+	status, body, err := forward.ForwardRequestContext(ctx, method, url, payload)
+	recordRequest(master.Name)
+	requestid.AddNode(ctx, master.Name)
+	recordOutcome(master.Name, status, err)
+	invalidateCache(method, path)
 	if err != nil || status >= 300 {
-		logger.Error(fmt.Sprintf("write to master failed: %v", err))
+		logger.Error("write to master failed", logger.F("node", master.Name), logger.F("status", status), logger.F("error", err))
 		return status, body, err
 	}
 
@@ -80,130 +144,325 @@ func SendWriteRequestToMaster(method string, path string, payload string) (int,
 		finalPayload = body
 	}
 
+	_, span := tracing.Start(ctx, "balancer.appendOp", tracing.Attr("elysian.group", group))
 	mu.Lock()
 	op := Operation{
-		Method:  method,
-		Path:    path,
-		Payload: finalPayload,
-		Seq:     atomic.AddInt64(&lastSeq, 1),
+		Method:    method,
+		Path:      path,
+		Payload:   finalPayload,
+		Seq:       atomic.AddInt64(&lastSeq, 1),
+		Group:     group,
+		RequestID: requestid.ID(ctx),
+		Trace:     tracing.FromContext(ctx).Context(),
 	}
-	pendingOps = append(pendingOps, op)
+	pendingOps[group] = append(pendingOps[group], op)
+	changelog.Enqueue(changeFor(op))
 	mu.Unlock()
+	changelog.Flush()
+	span.SetAttributes(tracing.Attr("elysian.seq", op.Seq))
+	span.End()
+
+	entity, _ := sharding.ParseAPIPath(path)
+	logger.Debug("write applied on master", logger.F("node", master.Name), logger.F("entity", entity), logger.F("seq", op.Seq), logger.F("request_id", op.RequestID))
+
+	return status, body, nil
+}
+
+func changeFor(op Operation) changelog.Change {
+	entity, id := sharding.ParseAPIPath(op.Path)
+	c := changelog.Change{
+		Seq:       op.Seq,
+		Entity:    entity,
+		ID:        id,
+		Group:     op.Group,
+		Time:      time.Now().UTC(),
+		RequestID: op.RequestID,
+	}
+	if op.Method != "DELETE" && json.Valid([]byte(op.Payload)) {
+		c.Data = json.RawMessage(op.Payload)
+		if c.ID == "" {
+			var doc struct {
+				ID string `json:"id"`
+			}
+			json.Unmarshal(c.Data, &doc)
+			c.ID = doc.ID
+		}
+	}
+	c.Op = changelog.OpFor(op.Method, c.ID)
+	return c
+}
+
+// RecordRestore gives every restored entity type a change with a new seq, so
+// change feed consumers see that their copy of it must be rebuilt. It returns
+// the last seq it used.
+func RecordRestore(group string, entities []string, archiveSeq int64) int64 {
+	mu.Lock()
+	seq := atomic.LoadInt64(&lastSeq)
+	for _, entity := range entities {
+		seq = atomic.AddInt64(&lastSeq, 1)
+		data, _ := json.Marshal(map[string]int64{"archive_seq": archiveSeq})
+		changelog.Enqueue(changelog.Change{
+			Seq:    seq,
+			Op:     changelog.OpRestore,
+			Entity: entity,
+			Group:  group,
+			Time:   time.Now().UTC(),
+			Data:   data,
+		})
+	}
+	mu.Unlock()
+	changelog.Flush()
+	return seq
+}
+
+func PauseWrites() (int64, func()) {
+	writeGate.Lock()
+	return atomic.LoadInt64(&lastSeq), writeGate.Unlock
+}
+
+func DiscardPending(group string) int {
+	mu.Lock()
+	defer mu.Unlock()
+	n := len(pendingOps[group])
+	delete(pendingOps, group)
+	return n
+}
+
+func PromoteNode(name string) error {
+	n, ok := nodes.ElysianCluster.Snapshot().Node(name)
+	if !ok {
+		return fmt.Errorf("%w %q", nodes.ErrUnknownNode, name)
+	}
+	group := n.ShardGroup()
+
+	_, resume := PauseWrites()
+	defer resume()
+	SyncSlaves()
+	mu.Lock()
+	defer mu.Unlock()
+	if pending := len(pendingOps[group]); pending > 0 {
+		return fmt.Errorf("group %s still has %d unsynced operations, promoting %s would lose them", group, pending, name)
+	}
+	return nodes.ElysianCluster.Promote(name)
+}
 
+func ResumeSeq(seq int64) {
+	for {
+		current := atomic.LoadInt64(&lastSeq)
+		if seq <= current || atomic.CompareAndSwapInt64(&lastSeq, current, seq) {
+			return
+		}
+	}
+}
+
+func invalidateCache(method string, path string) {
+	entity, id := sharding.ParseAPIPath(path)
+	if entity == "" {
+		return
+	}
+	if id == "" && method != "POST" {
+		cache.InvalidateEntity(entity)
+		forgetReads(entity, func(string) bool { return true })
+		return
+	}
+	cache.InvalidateID(entity, id)
+	forgetReads(entity, func(other string) bool { return other == "" || other == id })
+}
+
+func broadcastWrite(ctx context.Context, method string, path string, payload string) (int, string, error) {
+	status, body := 0, ""
+	for _, group := range sharding.Groups() {
+		s, b, err := sendGroupWriteRequest(ctx, group, method, path, payload)
+		if err != nil || s >= 300 {
+			return s, b, err
+		}
+		status, body = s, b
+	}
 	return status, body, nil
 }
 
+var errPayloadNotObject = fmt.Errorf("payload must be a JSON object")
+
+func ensurePayloadID(payload string) (string, string, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(payload), &doc); err != nil || doc == nil {
+		return "", "", errPayloadNotObject
+	}
+	if raw, ok := doc["id"]; ok && string(raw) != "null" {
+		var id string
+		if json.Unmarshal(raw, &id) != nil {
+			id = string(raw)
+		}
+		return payload, id, nil
+	}
+	id := newID()
+	doc["id"], _ = json.Marshal(id)
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return "", "", err
+	}
+	return string(data), id, nil
+}
+
+func newID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
 func GetReadRequestNodes() []*global.Node {
+	return GetGroupReadRequestNodes(sharding.DefaultGroup())
+}
+
+func GetGroupReadRequestNodes(group string) []*global.Node {
 	mu.Lock()
-	hasPending := len(pendingOps) > 0
+	hasPending := len(pendingOps[group]) > 0
 	mu.Unlock()
 
+	snap := nodes.ElysianCluster.Snapshot()
 	nodesList := []*global.Node{}
+	master, hasMaster := snap.Master(group)
+	hasMaster = hasMaster && breaker.Available(master.Name)
 
 	if hasPending {
-		master := getMaster()
-		if master != nil {
-			nodesList = append(nodesList, master)
+		if hasMaster {
+			nodesList = append(nodesList, &master)
 		}
 		return nodesList
 	}
 
-	slaves := getFreshReadySlaves()
+	slaves := getFreshReadySlaves(snap, group)
 	if len(slaves) > 0 {
-		rand.Shuffle(len(slaves), func(i, j int) { slaves[i], slaves[j] = slaves[j], slaves[i] })
+		mrand.Shuffle(len(slaves), func(i, j int) { slaves[i], slaves[j] = slaves[j], slaves[i] })
 		for i := range slaves {
 			nodesList = append(nodesList, &slaves[i])
 		}
 	}
 
-	master := getMaster()
-	if master != nil {
-		nodesList = append(nodesList, master)
+	if hasMaster && (!master.Drained && !master.Unhealthy || len(nodesList) == 0) {
+		nodesList = append(nodesList, &master)
 	}
 
 	return nodesList
 }
 
-func getFreshReadySlaves() []global.Node {
+func getFreshReadySlaves(snap *nodes.Snapshot, group string) []global.Node {
 	res := []global.Node{}
-	for _, n := range nodes.ElysianCluster.Nodes {
-		if n.Role != "slave" || !n.Ready {
+	for _, n := range snap.Nodes {
+		if n.Role != "slave" || !n.Ready || n.Syncing || n.Drained || n.Unhealthy || n.ShardGroup() != group || !breaker.Available(n.Name) {
 			continue
 		}
-		if !state.IsSlaveFresh(n.Name) {
-			state.SetSlaveAsFresh(&n)
-		}
-		if !state.IsSlaveSyncing(n.Name) {
-			res = append(res, n)
-		}
+		res = append(res, n)
 	}
 	return res
 }
 
 func SyncSlaves() {
 	mu.Lock()
-	ops := append([]Operation(nil), pendingOps...)
+	ops := map[string][]Operation{}
+	for group, groupOps := range pendingOps {
+		if len(groupOps) > 0 {
+			ops[group] = append([]Operation(nil), groupOps...)
+		}
+	}
 	mu.Unlock()
 	if len(ops) == 0 {
 		return
 	}
 
 	var wg sync.WaitGroup
-	allSynced := true
+	synced := map[string]bool{}
+	for group := range ops {
+		synced[group] = true
+	}
 	var allMu sync.Mutex
 
-	for i := range nodes.ElysianCluster.Nodes {
-		n := &nodes.ElysianCluster.Nodes[i]
-		if n.Role != "master" && n.Ready {
-			if state.IsSlaveSyncing(n.Name) {
-				continue
-			}
-			wg.Add(1)
-			state.MarkSlaveSyncing(n.Name, true)
-			go func(nn *global.Node) {
-				defer wg.Done()
-				defer state.MarkSlaveSyncing(nn.Name, false)
-				ok := applyOpsToSlave(nn, ops)
+	for _, n := range nodes.ElysianCluster.Snapshot().Nodes {
+		groupOps, ok := ops[n.ShardGroup()]
+		if !ok || n.Role == "master" || !n.Ready {
+			continue
+		}
+		if !nodes.ElysianCluster.BeginSync(n.Name) {
+			synced[n.ShardGroup()] = false
+			continue
+		}
+		wg.Add(1)
+		go func(nn global.Node, groupOps []Operation) {
+			defer wg.Done()
+			ok := syncSlave(&nn, groupOps)
+			nodes.ElysianCluster.EndSync(nn.Name, ok)
+			if !ok {
 				allMu.Lock()
-				if ok {
-					state.SetSlaveAsFresh(nn)
-				} else {
-					allSynced = false
-				}
+				synced[nn.ShardGroup()] = false
 				allMu.Unlock()
-			}(n)
-		}
+			}
+		}(n, groupOps)
 	}
 	wg.Wait()
 
-	if allSynced {
-		mu.Lock()
-		pendingOps = nil
-		mu.Unlock()
+	mu.Lock()
+	for group, ok := range synced {
+		if ok {
+			pendingOps[group] = pendingOps[group][len(ops[group]):]
+		}
+	}
+	mu.Unlock()
+}
+
+func syncSlave(nn *global.Node, ops []Operation) bool {
+	ctx, span := tracing.Start(context.Background(), "balancer.SyncSlave",
+		tracing.Attr("elysian.node", nn.Name),
+		tracing.Attr("elysian.group", nn.ShardGroup()),
+		tracing.Attr("elysian.ops", len(ops)),
+		tracing.Attr("elysian.seq.first", ops[0].Seq),
+		tracing.Attr("elysian.seq.last", ops[len(ops)-1].Seq),
+	)
+	for _, op := range ops {
+		span.AddLink(op.Trace)
+	}
+	ok := applyOpsToSlave(ctx, nn, ops)
+	if !ok {
+		span.SetError(fmt.Errorf("sync failed on slave %s", nn.Name))
+		events.Publish(events.SyncFailed, nn.Name, nn.ShardGroup(), map[string]any{
+			"ops":       len(ops),
+			"seq_first": ops[0].Seq,
+			"seq_last":  ops[len(ops)-1].Seq,
+		})
 	}
+	span.End()
+	return ok
 }
 
-func applyOpsToSlave(nn *global.Node, ops []Operation) bool {
+func applyOpsToSlave(parent context.Context, nn *global.Node, ops []Operation) bool {
 	for _, op := range ops {
-		url := fmt.Sprintf("http://%s:%d%s", nn.HTTP.Host, nn.HTTP.Port, op.Path)
-		status, _, err := forward.ForwardRequest(op.Method, url, op.Payload)
+		url := nn.URL(op.Path)
+		ctx := requestid.NewContext(parent, &requestid.Request{ID: op.RequestID})
+		status, _, err := forward.ForwardRequestContext(ctx, op.Method, url, op.Payload)
+		recordRequest(nn.Name)
+		recordOutcome(nn.Name, status, err)
 		if err != nil || status >= 300 {
-			logger.Error(fmt.Sprintf("sync failed on slave %s: %v", nn.Name, err))
+			logger.Error("sync failed on slave", logger.F("node", nn.Name), logger.F("seq", op.Seq), logger.F("request_id", op.RequestID), logger.F("error", err))
 			return false
 		}
+		recordApplied(nn.Name, op.Seq)
 	}
 	return true
 }
 
-func getMaster() *global.Node {
-	for i := range nodes.ElysianCluster.Nodes {
-		if nodes.ElysianCluster.Nodes[i].Role == "master" {
-			return &nodes.ElysianCluster.Nodes[i]
-		}
+func recordOutcome(node string, status int, err error) {
+	if err != nil || status >= 500 {
+		breaker.Record(node, breaker.Failure)
+		return
 	}
-	return nil
+	breaker.Record(node, breaker.Success)
+}
+
+func getGroupMaster(group string) *global.Node {
+	return nodes.GetGroupMasterNode(group)
 }
 
 func init() {
-	rand.Seed(time.Now().UnixNano())
+	mrand.Seed(time.Now().UnixNano())
 }
@@ -0,0 +1,186 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/breaker"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/forward"
+	"github.com/elysiandb/elysian-gate/internal/global"
+	"github.com/elysiandb/elysian-gate/internal/logger"
+	"github.com/elysiandb/elysian-gate/internal/requestid"
+)
+
+const (
+	defaultHedgePercentile = 95
+	defaultHedgeMinDelay   = 10 * time.Millisecond
+	defaultHedgeMaxDelay   = 500 * time.Millisecond
+)
+
+type attemptResult struct {
+	node   *global.Node
+	status int
+	body   string
+	err    error
+}
+
+func (r attemptResult) retryable() bool {
+	if errors.Is(r.err, forward.ErrBodyTooLarge) {
+		return false
+	}
+	if r.err != nil {
+		return true
+	}
+	return r.status >= 500 || r.status == 429
+}
+
+func readBudget(parent context.Context) (context.Context, context.CancelFunc) {
+	budget := configuration.Config.Gateway.Reads.BudgetMs
+	if budget <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, time.Duration(budget)*time.Millisecond)
+}
+
+func readFromGroup(ctx context.Context, group string, path string, query string) (int, string, error) {
+	nodes := GetGroupReadRequestNodes(group)
+	if len(nodes) == 0 {
+		return 503, `{"error":"no available node"}`, fmt.Errorf("no available node")
+	}
+
+	ctx, cancel := readBudget(ctx)
+	defer cancel()
+	return readWithRetries(ctx, nodes, path, query)
+}
+
+func readWithRetries(ctx context.Context, nodes []*global.Node, path string, query string) (int, string, error) {
+	cfg := configuration.Config.Gateway.Reads
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 || maxAttempts > len(nodes) {
+		maxAttempts = len(nodes)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	results := make(chan attemptResult, maxAttempts)
+	launched, inflight := 0, 0
+	defer func() {
+		cancel()
+		for ; inflight > 0; inflight-- {
+			<-results
+		}
+	}()
+	launch := func() {
+		node := nodes[launched]
+		launched++
+		inflight++
+		go func() {
+			results <- readAttempt(ctx, node, path, query)
+		}()
+	}
+
+	var hedge *time.Timer
+	var hedgeC <-chan time.Time
+	armHedge := func(node *global.Node) {
+		if !cfg.Hedging.Enabled || launched >= maxAttempts {
+			hedgeC = nil
+			return
+		}
+		hedge = time.NewTimer(hedgeDelay(node))
+		hedgeC = hedge.C
+	}
+	defer func() {
+		if hedge != nil {
+			hedge.Stop()
+		}
+	}()
+
+	launch()
+	armHedge(nodes[0])
+
+	for inflight > 0 {
+		select {
+		case r := <-results:
+			inflight--
+			if !r.retryable() {
+				requestid.AddNode(ctx, r.node.Name)
+				return r.status, r.body, r.err
+			}
+			logger.Warn("read failed", logger.F("node", r.node.Name), logger.F("status", r.status), logger.F("error", r.err))
+			if launched < maxAttempts && ctx.Err() == nil {
+				if hedge != nil {
+					hedge.Stop()
+				}
+				launch()
+				armHedge(nodes[launched-1])
+			}
+		case <-hedgeC:
+			logger.Debug("hedging read", logger.F("path", path), logger.F("node", nodes[launched].Name))
+			launch()
+			armHedge(nodes[launched-1])
+		case <-ctx.Done():
+			return 504, `{"error":"read latency budget exceeded"}`, fmt.Errorf("read latency budget exceeded")
+		}
+	}
+
+	if ctx.Err() != nil {
+		return 504, `{"error":"read latency budget exceeded"}`, fmt.Errorf("read latency budget exceeded")
+	}
+	return 502, `{"error":"all nodes failed"}`, fmt.Errorf("all nodes failed")
+}
+
+func readAttempt(ctx context.Context, node *global.Node, path string, query string) attemptResult {
+	if !breaker.Allow(node.Name) {
+		return attemptResult{node: node, err: breaker.ErrOpen}
+	}
+
+	logger.Debug("trying read", logger.F("node", node.Name))
+	url := node.URL(path)
+	if query != "" {
+		url += "?" + query
+	}
+
+	start := time.Now()
+	status, body, err := forward.ForwardRequestContext(ctx, "GET", url, "")
+	recordRequest(node.Name)
+	res := attemptResult{node: node, status: status, body: body, err: err}
+	switch {
+	case ctx.Err() != nil:
+		breaker.Record(node.Name, breaker.Ignored)
+	case res.retryable():
+		breaker.Record(node.Name, breaker.Failure)
+	default:
+		recordLatency(node.Name, time.Since(start))
+		breaker.Record(node.Name, breaker.Success)
+	}
+	return res
+}
+
+func hedgeDelay(node *global.Node) time.Duration {
+	cfg := configuration.Config.Gateway.Reads.Hedging
+	minDelay, maxDelay := defaultHedgeMinDelay, defaultHedgeMaxDelay
+	if cfg.MinDelayMs > 0 {
+		minDelay = time.Duration(cfg.MinDelayMs) * time.Millisecond
+	}
+	if cfg.MaxDelayMs > 0 {
+		maxDelay = time.Duration(cfg.MaxDelayMs) * time.Millisecond
+	}
+	percentile := cfg.Percentile
+	if percentile <= 0 || percentile > 100 {
+		percentile = defaultHedgePercentile
+	}
+
+	d, ok := latencyPercentile(node.Name, percentile)
+	if !ok {
+		return maxDelay
+	}
+	if d < minDelay {
+		return minDelay
+	}
+	if d > maxDelay {
+		return maxDelay
+	}
+	return d
+}
@@ -0,0 +1,88 @@
+package balancer
+
+import (
+	"sync"
+	"time"
+)
+
+const rateWindow = 10
+
+type rateCounter struct {
+	counts  [rateWindow]int64
+	seconds [rateWindow]int64
+}
+
+var (
+	rates = struct {
+		sync.Mutex
+		byNode map[string]*rateCounter
+	}{byNode: map[string]*rateCounter{}}
+	applied = struct {
+		sync.Mutex
+		seq map[string]int64
+	}{seq: map[string]int64{}}
+)
+
+func recordRequest(node string) {
+	now := time.Now().Unix()
+	rates.Lock()
+	defer rates.Unlock()
+	c := rates.byNode[node]
+	if c == nil {
+		c = &rateCounter{}
+		rates.byNode[node] = c
+	}
+	i := now % rateWindow
+	if c.seconds[i] != now {
+		c.seconds[i] = now
+		c.counts[i] = 0
+	}
+	c.counts[i]++
+}
+
+func RequestRate(node string) float64 {
+	now := time.Now().Unix()
+	rates.Lock()
+	defer rates.Unlock()
+	c := rates.byNode[node]
+	if c == nil {
+		return 0
+	}
+	var total int64
+	for i := range c.seconds {
+		if now-c.seconds[i] < rateWindow {
+			total += c.counts[i]
+		}
+	}
+	return float64(total) / rateWindow
+}
+
+func recordApplied(node string, seq int64) {
+	applied.Lock()
+	if seq > applied.seq[node] {
+		applied.seq[node] = seq
+	}
+	applied.Unlock()
+}
+
+func PendingOps(group string) int {
+	mu.Lock()
+	defer mu.Unlock()
+	return len(pendingOps[group])
+}
+
+func ReplicationLag(node string, group string) int {
+	applied.Lock()
+	seq := applied.seq[node]
+	applied.Unlock()
+
+	mu.Lock()
+	defer mu.Unlock()
+	lag := 0
+	for _, op := range pendingOps[group] {
+		if op.Seq > seq {
+			lag++
+		}
+	}
+	return lag
+}
@@ -1,36 +1,79 @@
 package boot
 
 import (
+	"crypto/tls"
 	"fmt"
+	"net"
 	"time"
 
+	"github.com/elysiandb/elysian-gate/internal/accesslog"
+	"github.com/elysiandb/elysian-gate/internal/auth"
 	"github.com/elysiandb/elysian-gate/internal/configuration"
 	"github.com/elysiandb/elysian-gate/internal/logger"
 	"github.com/elysiandb/elysian-gate/internal/routing"
+	"github.com/elysiandb/elysian-gate/internal/tlsconfig"
+	"github.com/elysiandb/elysian-gate/internal/tracing"
 	"github.com/fasthttp/router"
 	"github.com/valyala/fasthttp"
 )
 
 var server *fasthttp.Server
 
-func InitHTTP() {
+func InitHTTP() error {
 	r := router.New()
 	routing.RegisterRoutes(r)
 
+	limit := maxRequestBodySize()
 	server = &fasthttp.Server{
-		Handler:      r.Handler,
-		ReadTimeout:  3 * time.Second,
-		WriteTimeout: 3 * time.Second,
-		Name:         "Elysiangate",
+		Handler:            accesslog.Middleware(tracing.Middleware(auth.Middleware(routing.LimitBodies(r.Handler, limit)))),
+		ReadTimeout:        3 * time.Second,
+		WriteTimeout:       3 * time.Second,
+		Name:               "Elysiangate",
+		MaxRequestBodySize: limit,
+		StreamRequestBody:  true,
 	}
 
-	go func() {
-		host := configuration.Config.Gateway.HTTP.Host
-		port := configuration.Config.Gateway.HTTP.Port
-		logger.Info(fmt.Sprintf("Starting ElysianGate HTTP server on %s:%d", host, port))
+	host := configuration.Config.Gateway.HTTP.Host
+	port := configuration.Config.Gateway.HTTP.Port
+	addr := fmt.Sprintf("%s:%d", host, port)
+
+	tlsCfg := configuration.Config.Gateway.HTTP.TLS
+	var cfg *tls.Config
+	if tlsCfg.Enabled {
+		var err error
+		if cfg, err = tlsconfig.ServerConfig(tlsCfg); err != nil {
+			return fmt.Errorf("invalid TLS settings: %w", err)
+		}
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	if cfg != nil {
+		ln = tls.NewListener(ln, cfg)
+		logger.Info(fmt.Sprintf("Starting ElysianGate HTTPS server on %s", addr))
+	} else {
+		logger.Info(fmt.Sprintf("Starting ElysianGate HTTP server on %s", addr))
+	}
 
-		if err := server.ListenAndServe(fmt.Sprintf("%s:%d", host, port)); err != nil {
+	go func() {
+		if err := server.Serve(ln); err != nil {
 			logger.Error(fmt.Sprintf("server error: %v", err))
 		}
 	}()
+	return nil
+}
+
+func maxRequestBodySize() int {
+	cfg := configuration.Config.Gateway.Validation
+	limit := cfg.MaxBodyBytes
+	if limit <= 0 {
+		return fasthttp.DefaultMaxRequestBodySize
+	}
+	for _, e := range cfg.Entities {
+		if e.MaxBodyBytes > limit {
+			limit = e.MaxBodyBytes
+		}
+	}
+	return limit
 }
@@ -0,0 +1,27 @@
+package boot
+
+import (
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/logger"
+)
+
+func InitLogger() error {
+	cfg := configuration.Config.Gateway.Log
+	level, err := logger.ParseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+
+	logger.Configure(logger.Options{
+		Output:       cfg.Output,
+		Level:        level,
+		Format:       cfg.Format,
+		MaxSizeBytes: int64(cfg.MaxSizeMB) << 20,
+		RotateEvery:  time.Duration(cfg.RotateHours) * time.Hour,
+		MaxBackups:   cfg.MaxBackups,
+	})
+
+	return nil
+}
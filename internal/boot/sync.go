@@ -6,7 +6,6 @@ import (
 	"github.com/elysiandb/elysian-gate/internal/balancer"
 	"github.com/elysiandb/elysian-gate/internal/configuration"
 	"github.com/elysiandb/elysian-gate/internal/nodes"
-	"github.com/elysiandb/elysian-gate/internal/replication"
 )
 
 func BootSyncer() {
@@ -22,16 +21,9 @@ func syncSlavesRoutine() {
 }
 
 func initSlavesReplication() {
-	master := nodes.GetMasterNode()
-	if master == nil {
-		return
-	}
-	for i := range nodes.ElysianCluster.Nodes {
-		n := &nodes.ElysianCluster.Nodes[i]
+	for _, n := range nodes.ElysianCluster.Snapshot().Nodes {
 		if n.Role == "slave" && !n.Ready {
-			if err := replication.ReplicateMasterToNode(master, n); err == nil {
-				n.Ready = true
-			}
+			nodes.ElysianCluster.ResyncSlave(n.Name)
 		}
 	}
 }
@@ -0,0 +1,241 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/logger"
+)
+
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+type Outcome int
+
+const (
+	Success Outcome = iota
+	Failure
+	Ignored
+)
+
+const (
+	defaultConsecutiveFailures = 5
+	defaultErrorRate           = 0.5
+	defaultMinRequests         = 20
+	defaultWindow              = 10 * time.Second
+	defaultOpen                = 5 * time.Second
+	defaultHalfOpenProbes      = 1
+)
+
+var ErrOpen = errors.New("circuit breaker open")
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+type nodeBreaker struct {
+	state       State
+	consecutive int
+	requests    int
+	failures    int
+	windowStart time.Time
+	openUntil   time.Time
+	probes      int
+}
+
+var breakers = struct {
+	sync.Mutex
+	nodes map[string]*nodeBreaker
+}{nodes: map[string]*nodeBreaker{}}
+
+func Enabled() bool {
+	return configuration.Config.Gateway.CircuitBreaker.Enabled
+}
+
+func Reset() {
+	breakers.Lock()
+	defer breakers.Unlock()
+	breakers.nodes = map[string]*nodeBreaker{}
+}
+
+func Available(node string) bool {
+	if !Enabled() {
+		return true
+	}
+	breakers.Lock()
+	defer breakers.Unlock()
+	b := get(node)
+	switch b.state {
+	case Open:
+		return !time.Now().Before(b.openUntil)
+	case HalfOpen:
+		return b.probes < halfOpenProbes()
+	}
+	return true
+}
+
+func Allow(node string) bool {
+	if !Enabled() {
+		return true
+	}
+	breakers.Lock()
+	defer breakers.Unlock()
+	b := get(node)
+	if b.state == Open {
+		if time.Now().Before(b.openUntil) {
+			return false
+		}
+		b.toHalfOpen(node)
+	}
+	if b.state == HalfOpen {
+		if b.probes >= halfOpenProbes() {
+			return false
+		}
+		b.probes++
+	}
+	return true
+}
+
+func Record(node string, outcome Outcome) {
+	if !Enabled() {
+		return
+	}
+	breakers.Lock()
+	defer breakers.Unlock()
+	b := get(node)
+
+	if b.state == HalfOpen {
+		if b.probes > 0 {
+			b.probes--
+		}
+		switch outcome {
+		case Success:
+			b.toClosed(node)
+		case Failure:
+			b.toOpen(node)
+		}
+		return
+	}
+	if b.state == Open || outcome == Ignored {
+		return
+	}
+
+	cfg := configuration.Config.Gateway.CircuitBreaker
+	window := defaultWindow
+	if cfg.WindowSeconds > 0 {
+		window = time.Duration(cfg.WindowSeconds) * time.Second
+	}
+	now := time.Now()
+	if now.Sub(b.windowStart) > window {
+		b.windowStart = now
+		b.requests, b.failures = 0, 0
+	}
+
+	b.requests++
+	if outcome == Success {
+		b.consecutive = 0
+		return
+	}
+	b.failures++
+	b.consecutive++
+
+	maxConsecutive := cfg.ConsecutiveFailures
+	if maxConsecutive <= 0 {
+		maxConsecutive = defaultConsecutiveFailures
+	}
+	maxRate := cfg.ErrorRate
+	if maxRate <= 0 {
+		maxRate = defaultErrorRate
+	}
+	minRequests := cfg.MinRequests
+	if minRequests <= 0 {
+		minRequests = defaultMinRequests
+	}
+
+	if b.consecutive >= maxConsecutive ||
+		(b.requests >= minRequests && float64(b.failures)/float64(b.requests) >= maxRate) {
+		b.toOpen(node)
+	}
+}
+
+func Trip(node string) {
+	if !Enabled() {
+		return
+	}
+	breakers.Lock()
+	defer breakers.Unlock()
+	if b := get(node); b.state != Open {
+		b.toOpen(node)
+	}
+}
+
+func Probe(node string) {
+	if !Enabled() {
+		return
+	}
+	breakers.Lock()
+	defer breakers.Unlock()
+	if b := get(node); b.state == Open {
+		b.toHalfOpen(node)
+	}
+}
+
+func GetState(node string) State {
+	breakers.Lock()
+	defer breakers.Unlock()
+	return get(node).state
+}
+
+func get(node string) *nodeBreaker {
+	b, ok := breakers.nodes[node]
+	if !ok {
+		b = &nodeBreaker{windowStart: time.Now()}
+		breakers.nodes[node] = b
+	}
+	return b
+}
+
+func (b *nodeBreaker) toOpen(node string) {
+	open := defaultOpen
+	if s := configuration.Config.Gateway.CircuitBreaker.OpenSeconds; s > 0 {
+		open = time.Duration(s) * time.Second
+	}
+	b.state = Open
+	b.openUntil = time.Now().Add(open)
+	b.probes = 0
+	logger.Warn("circuit breaker opened", logger.F("node", node))
+}
+
+func (b *nodeBreaker) toHalfOpen(node string) {
+	b.state = HalfOpen
+	b.probes = 0
+	logger.Info("circuit breaker half-open", logger.F("node", node))
+}
+
+func (b *nodeBreaker) toClosed(node string) {
+	b.state = Closed
+	b.consecutive = 0
+	b.requests, b.failures = 0, 0
+	b.windowStart = time.Now()
+	logger.Info("circuit breaker closed", logger.F("node", node))
+}
+
+func halfOpenProbes() int {
+	if n := configuration.Config.Gateway.CircuitBreaker.HalfOpenProbes; n > 0 {
+		return n
+	}
+	return defaultHalfOpenProbes
+}
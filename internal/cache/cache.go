@@ -0,0 +1,214 @@
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+)
+
+const (
+	defaultTTL        = 30 * time.Second
+	defaultMaxEntries = 10000
+	defaultMaxBytes   = 64 << 20
+)
+
+type Entry struct {
+	Status int
+	Body   []byte
+}
+
+type Stats struct {
+	Hits          uint64 `json:"hits"`
+	Misses        uint64 `json:"misses"`
+	Evictions     uint64 `json:"evictions"`
+	Invalidations uint64 `json:"invalidations"`
+	Entries       int    `json:"entries"`
+	Bytes         int    `json:"bytes"`
+}
+
+type item struct {
+	key     string
+	entity  string
+	id      string
+	entry   Entry
+	expires time.Time
+	size    int
+}
+
+type lru struct {
+	mu          sync.Mutex
+	order       *list.List
+	items       map[string]*list.Element
+	generations map[string]uint64
+	bytes       int
+	stats       Stats
+}
+
+var store = newLRU()
+
+func newLRU() *lru {
+	return &lru{
+		order:       list.New(),
+		items:       map[string]*list.Element{},
+		generations: map[string]uint64{},
+	}
+}
+
+func Reset() {
+	fresh := newLRU()
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.order = fresh.order
+	store.items = fresh.items
+	store.generations = fresh.generations
+	store.bytes = 0
+	store.stats = Stats{}
+}
+
+func Enabled(entity string) bool {
+	cfg := configuration.Config.Gateway.Cache
+	if !cfg.Enabled {
+		return false
+	}
+	if e, ok := cfg.Entities[entity]; ok && e.Enabled != nil {
+		return *e.Enabled
+	}
+	return true
+}
+
+func Key(path string, query string, vary ...string) string {
+	key := path
+	if query != "" {
+		key += "?" + query
+	}
+	if len(vary) > 0 {
+		key += "\x00" + strings.Join(vary, "\x00")
+	}
+	return key
+}
+
+func Generation(entity string) uint64 {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.generations[entity]
+}
+
+func Get(key string) (Entry, bool) {
+	s := store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.items[key]
+	if !ok {
+		s.stats.Misses++
+		return Entry{}, false
+	}
+	it := el.Value.(*item)
+	if time.Now().After(it.expires) {
+		s.remove(el)
+		s.stats.Misses++
+		return Entry{}, false
+	}
+	s.order.MoveToFront(el)
+	s.stats.Hits++
+	return it.entry, true
+}
+
+func Put(key string, entity string, id string, generation uint64, entry Entry) {
+	cfg := configuration.Config.Gateway.Cache
+	maxEntries, maxBytes := cfg.MaxEntries, cfg.MaxBytes
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxEntries
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBytes
+	}
+
+	size := len(key) + len(entry.Body)
+	if size > maxBytes {
+		return
+	}
+
+	s := store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.generations[entity] != generation {
+		return
+	}
+	if el, ok := s.items[key]; ok {
+		s.remove(el)
+	}
+
+	it := &item{
+		key:     key,
+		entity:  entity,
+		id:      id,
+		entry:   entry,
+		expires: time.Now().Add(ttlFor(entity)),
+		size:    size,
+	}
+	s.items[key] = s.order.PushFront(it)
+	s.bytes += size
+
+	for s.order.Len() > maxEntries || s.bytes > maxBytes {
+		s.remove(s.order.Back())
+		s.stats.Evictions++
+	}
+}
+
+func InvalidateEntity(entity string) {
+	invalidate(entity, func(*item) bool { return true })
+}
+
+func InvalidateID(entity string, id string) {
+	invalidate(entity, func(it *item) bool { return it.id == "" || it.id == id })
+}
+
+func invalidate(entity string, match func(*item) bool) {
+	s := store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.generations[entity]++
+	for el := s.order.Front(); el != nil; {
+		next := el.Next()
+		it := el.Value.(*item)
+		if it.entity == entity && match(it) {
+			s.remove(el)
+			s.stats.Invalidations++
+		}
+		el = next
+	}
+}
+
+func GetStats() Stats {
+	s := store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.stats
+	st.Entries = s.order.Len()
+	st.Bytes = s.bytes
+	return st
+}
+
+func (s *lru) remove(el *list.Element) {
+	it := el.Value.(*item)
+	s.order.Remove(el)
+	delete(s.items, it.key)
+	s.bytes -= it.size
+}
+
+func ttlFor(entity string) time.Duration {
+	cfg := configuration.Config.Gateway.Cache
+	if e, ok := cfg.Entities[entity]; ok && e.TTLSeconds > 0 {
+		return time.Duration(e.TTLSeconds) * time.Second
+	}
+	if cfg.TTLSeconds > 0 {
+		return time.Duration(cfg.TTLSeconds) * time.Second
+	}
+	return defaultTTL
+}
@@ -0,0 +1,277 @@
+package changelog
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/logger"
+)
+
+const (
+	OpCreate  = "create"
+	OpUpdate  = "update"
+	OpDelete  = "delete"
+	OpDestroy = "destroy"
+	OpRestore = "restore"
+
+	defaultDir         = "elysianGate.changes"
+	defaultSegmentMB   = 64
+	defaultMaxSegments = 8
+	tailSize           = 1024
+	defaultReadLimit   = 100
+)
+
+var (
+	ErrDisabled  = errors.New("change feed is disabled")
+	ErrTruncated = errors.New("requested changes are no longer retained")
+)
+
+type Change struct {
+	Seq       int64           `json:"seq"`
+	Op        string          `json:"op"`
+	Entity    string          `json:"entity"`
+	ID        string          `json:"id,omitempty"`
+	Group     string          `json:"group"`
+	Time      time.Time       `json:"time"`
+	RequestID string          `json:"request_id,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+var feed = struct {
+	sync.Mutex
+	enabled bool
+	store   *store
+	last    int64
+	lost    int64
+	tail    []Change
+	notify  chan struct{}
+}{notify: make(chan struct{})}
+
+// pending holds changes in seq order until Flush writes them. Callers enqueue
+// while they hold the lock that hands out seqs and flush after releasing it.
+var pending = struct {
+	sync.Mutex
+	changes []Change
+}{}
+
+var flushing sync.Mutex
+
+func Init() error {
+	Close()
+
+	cfg := configuration.Config.Gateway.ChangeFeed
+	if !cfg.Enabled {
+		return nil
+	}
+
+	dir := cfg.Dir
+	if dir == "" {
+		dir = defaultDir
+	}
+	segmentMB := cfg.SegmentMB
+	if segmentMB <= 0 {
+		segmentMB = defaultSegmentMB
+	}
+	maxSegments := cfg.MaxSegments
+	if maxSegments <= 0 {
+		maxSegments = defaultMaxSegments
+	}
+
+	s, tail, err := openStore(dir, int64(segmentMB)<<20, maxSegments, cfg.Fsync)
+	if err != nil {
+		return err
+	}
+
+	feed.Lock()
+	defer feed.Unlock()
+	feed.enabled = true
+	feed.store = s
+	feed.tail = tail
+	feed.last = 0
+	if len(tail) > 0 {
+		feed.last = tail[len(tail)-1].Seq
+	}
+	return nil
+}
+
+func Close() {
+	feed.Lock()
+	defer feed.Unlock()
+	if feed.store != nil {
+		feed.store.close()
+	}
+	feed.enabled = false
+	feed.store = nil
+	feed.tail = nil
+	feed.last = 0
+	feed.lost = 0
+}
+
+func Enabled() bool {
+	feed.Lock()
+	defer feed.Unlock()
+	return feed.enabled
+}
+
+func LastSeq() int64 {
+	feed.Lock()
+	defer feed.Unlock()
+	return feed.last
+}
+
+func FirstSeq() int64 {
+	feed.Lock()
+	defer feed.Unlock()
+	return firstLocked()
+}
+
+// firstLocked is the oldest seq a reader can resume from without a gap: the
+// first retained change, or the change after the last one that failed to be
+// written.
+func firstLocked() int64 {
+	if feed.store == nil {
+		return 0
+	}
+	first := feed.store.first()
+	if feed.lost > 0 && feed.lost+1 > first {
+		first = feed.lost + 1
+	}
+	return first
+}
+
+func OpFor(method string, id string) string {
+	switch {
+	case method == "POST":
+		return OpCreate
+	case method == "DELETE" && id == "":
+		return OpDestroy
+	case method == "DELETE":
+		return OpDelete
+	}
+	return OpUpdate
+}
+
+func Append(c Change) error {
+	feed.Lock()
+	defer feed.Unlock()
+	if !feed.enabled {
+		return nil
+	}
+
+	if err := feed.store.append(c); err != nil {
+		feed.lost = c.Seq
+		feed.last = c.Seq
+		return err
+	}
+	feed.last = c.Seq
+	feed.tail = append(feed.tail, c)
+	if len(feed.tail) > tailSize {
+		feed.tail = append([]Change(nil), feed.tail[len(feed.tail)-tailSize:]...)
+	}
+	close(feed.notify)
+	feed.notify = make(chan struct{})
+	return nil
+}
+
+func Enqueue(c Change) {
+	pending.Lock()
+	pending.changes = append(pending.changes, c)
+	pending.Unlock()
+}
+
+// Flush appends every enqueued change in order. A change that cannot be
+// written is logged and moves FirstSeq past it, so readers from before the
+// gap get ErrTruncated instead of silently missing it.
+func Flush() {
+	flushing.Lock()
+	defer flushing.Unlock()
+	for {
+		pending.Lock()
+		changes := pending.changes
+		pending.changes = nil
+		pending.Unlock()
+		if len(changes) == 0 {
+			return
+		}
+		for _, c := range changes {
+			if err := Append(c); err != nil {
+				logger.Error("failed to append to change log", logger.F("seq", c.Seq), logger.F("error", err))
+			}
+		}
+	}
+}
+
+func Changed() <-chan struct{} {
+	feed.Lock()
+	defer feed.Unlock()
+	return feed.notify
+}
+
+func Read(after int64, limit int, keep func(Change) bool) ([]Change, int64, error) {
+	if limit <= 0 {
+		limit = defaultReadLimit
+	}
+
+	feed.Lock()
+	if !feed.enabled {
+		feed.Unlock()
+		return nil, after, ErrDisabled
+	}
+	last := feed.last
+	if after >= last {
+		feed.Unlock()
+		return nil, after, nil
+	}
+	if first := firstLocked(); first > 0 && after < first-1 {
+		feed.Unlock()
+		return nil, after, ErrTruncated
+	}
+	if len(feed.tail) > 0 && feed.tail[0].Seq <= after+1 {
+		changes, cursor := collect(feed.tail, after, last, limit, keep)
+		feed.Unlock()
+		return changes, cursor, nil
+	}
+	paths := feed.store.pathsFrom(after + 1)
+	feed.Unlock()
+
+	changes := []Change{}
+	cursor := after
+	err := scan(paths, func(c Change) bool {
+		if c.Seq <= after {
+			return true
+		}
+		if c.Seq > last {
+			return false
+		}
+		cursor = c.Seq
+		if keep == nil || keep(c) {
+			changes = append(changes, c)
+		}
+		return len(changes) < limit
+	})
+	if err != nil {
+		return nil, after, err
+	}
+	return changes, cursor, nil
+}
+
+func collect(list []Change, after int64, last int64, limit int, keep func(Change) bool) ([]Change, int64) {
+	changes := []Change{}
+	cursor := after
+	for _, c := range list {
+		if c.Seq <= after {
+			continue
+		}
+		if c.Seq > last || len(changes) >= limit {
+			break
+		}
+		cursor = c.Seq
+		if keep == nil || keep(c) {
+			changes = append(changes, c)
+		}
+	}
+	return changes, cursor
+}
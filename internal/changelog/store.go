@@ -0,0 +1,216 @@
+package changelog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/elysiandb/elysian-gate/internal/logger"
+)
+
+const (
+	segmentPrefix = "changes-"
+	segmentSuffix = ".jsonl"
+	maxLineBytes  = 64 << 20
+)
+
+type segment struct {
+	first int64
+	path  string
+}
+
+type store struct {
+	dir          string
+	segmentBytes int64
+	maxSegments  int
+	fsync        bool
+	segments     []segment
+	file         *os.File
+	size         int64
+}
+
+func openStore(dir string, segmentBytes int64, maxSegments int, fsync bool) (*store, []Change, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, nil, err
+	}
+	s := &store{dir: dir, segmentBytes: segmentBytes, maxSegments: maxSegments, fsync: fsync}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		first, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		s.segments = append(s.segments, segment{first: first, path: filepath.Join(dir, name)})
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i].first < s.segments[j].first })
+
+	if len(s.segments) == 0 {
+		return s, nil, nil
+	}
+
+	active := s.segments[len(s.segments)-1]
+	tail, size, err := recoverSegment(active.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.OpenFile(active.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.file, s.size = f, size
+	return s, tail, nil
+}
+
+func recoverSegment(path string) ([]Change, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	tail := []Change{}
+	var good int64
+	r := bufio.NewReaderSize(f, 64<<10)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		var c Change
+		if json.Unmarshal(line, &c) != nil {
+			break
+		}
+		good += int64(len(line))
+		tail = append(tail, c)
+		if len(tail) > tailSize {
+			tail = tail[1:]
+		}
+	}
+
+	if info, err := f.Stat(); err == nil && info.Size() > good {
+		logger.Warn("truncating torn change log record", logger.F("file", path), logger.F("offset", good))
+		if err := os.Truncate(path, good); err != nil {
+			return nil, 0, err
+		}
+	}
+	return tail, good, nil
+}
+
+func (s *store) append(c Change) error {
+	if s.file == nil || s.size >= s.segmentBytes {
+		if err := s.rotate(c.Seq); err != nil {
+			return err
+		}
+	}
+
+	line, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return err
+	}
+	if s.fsync {
+		return s.file.Sync()
+	}
+	return nil
+}
+
+func (s *store) rotate(first int64) error {
+	if s.file != nil {
+		s.file.Close()
+	}
+	path := filepath.Join(s.dir, fmt.Sprintf("%s%020d%s", segmentPrefix, first, segmentSuffix))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		s.file = nil
+		return err
+	}
+	s.file, s.size = f, 0
+	s.segments = append(s.segments, segment{first: first, path: path})
+
+	for len(s.segments) > s.maxSegments {
+		if err := os.Remove(s.segments[0].path); err != nil && !os.IsNotExist(err) {
+			logger.Warn("failed to remove old change log segment", logger.F("file", s.segments[0].path), logger.F("error", err))
+		}
+		s.segments = s.segments[1:]
+	}
+	return nil
+}
+
+func (s *store) first() int64 {
+	if len(s.segments) == 0 {
+		return 0
+	}
+	return s.segments[0].first
+}
+
+func (s *store) pathsFrom(seq int64) []string {
+	start := 0
+	for i, seg := range s.segments {
+		if seg.first <= seq {
+			start = i
+		}
+	}
+	paths := []string{}
+	for _, seg := range s.segments[start:] {
+		paths = append(paths, seg.path)
+	}
+	return paths
+}
+
+func (s *store) close() {
+	if s.file != nil {
+		if s.fsync {
+			s.file.Sync()
+		}
+		s.file.Close()
+		s.file = nil
+	}
+}
+
+func scan(paths []string, fn func(Change) bool) error {
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			return ErrTruncated
+		}
+		if err != nil {
+			return err
+		}
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 64<<10), maxLineBytes)
+		for sc.Scan() {
+			var c Change
+			if json.Unmarshal(sc.Bytes(), &c) != nil {
+				break
+			}
+			if !fn(c) {
+				f.Close()
+				return nil
+			}
+		}
+		f.Close()
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/elysiandb/elysian-gate/internal/backup"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+	"github.com/elysiandb/elysian-gate/internal/sharding"
+)
+
+func Backup(args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	r := remoteFlags(fs)
+	out := fs.String("out", "", "Archive file to write (required)")
+	offline := fs.Bool("offline", false, "Read the nodes directly instead of going through a running gateway")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *out == "" {
+		fmt.Fprintln(stderr, "backup: --out is required")
+		return 2
+	}
+
+	tmp := *out + ".partial"
+	f, err := os.Create(tmp)
+	if err != nil {
+		fmt.Fprintf(stderr, "backup: %v\n", err)
+		return 1
+	}
+	defer os.Remove(tmp)
+
+	if *offline {
+		err = backupOffline(*r.config, f)
+	} else {
+		err = backupRemote(r, f)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, *out)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "backup: %v\n", err)
+		return 1
+	}
+
+	m, err := readArchive(*out)
+	if err != nil {
+		fmt.Fprintf(stderr, "backup: written archive is unreadable: %v\n", err)
+		return 1
+	}
+	records := 0
+	for _, g := range m.Groups {
+		for _, e := range g.Entities {
+			records += e.Records
+		}
+	}
+	fmt.Fprintf(stdout, "Backup written to %s: seq %d, %d group(s), %d record(s)\n", *out, m.Seq, len(m.Groups), records)
+	return 0
+}
+
+func backupRemote(r *remote, w io.Writer) error {
+	resp, err := r.do("GET", "/admin/backup", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := failed(resp); err != nil {
+		return err
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+func backupOffline(config string, w io.Writer) error {
+	if err := loadOffline(config); err != nil {
+		return err
+	}
+	_, err := backup.Create(context.Background(), w)
+	return err
+}
+
+func Restore(args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	r := remoteFlags(fs)
+	in := fs.String("in", "", "Archive file to restore (required)")
+	offline := fs.Bool("offline", false, "Write to the nodes directly instead of going through a running gateway")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *in == "" {
+		fmt.Fprintln(stderr, "restore: --in is required")
+		return 2
+	}
+
+	if _, err := readArchive(*in); err != nil {
+		fmt.Fprintf(stderr, "restore: %v\n", err)
+		return 1
+	}
+	f, err := os.Open(*in)
+	if err != nil {
+		fmt.Fprintf(stderr, "restore: %v\n", err)
+		return 1
+	}
+	defer f.Close()
+
+	var report backup.RestoreReport
+	if *offline {
+		if err = loadOffline(*r.config); err == nil {
+			report, err = backup.Restore(context.Background(), f)
+		}
+	} else {
+		report, err = restoreRemote(r, f)
+	}
+
+	printReport(stdout, report)
+	if err != nil {
+		fmt.Fprintf(stderr, "restore: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func restoreRemote(r *remote, archive io.Reader) (backup.RestoreReport, error) {
+	var body struct {
+		Report backup.RestoreReport `json:"report"`
+		Error  string               `json:"error"`
+	}
+	resp, err := r.do("POST", "/admin/restore", archive)
+	if err != nil {
+		return body.Report, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 && resp.StatusCode != 502 {
+		return body.Report, failed(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return body.Report, err
+	}
+	if body.Error != "" {
+		return body.Report, errors.New(body.Error)
+	}
+	return body.Report, nil
+}
+
+func printReport(w io.Writer, report backup.RestoreReport) {
+	if report.Records == nil {
+		return
+	}
+	fmt.Fprintf(w, "Restored snapshot taken at seq %d\n", report.Seq)
+	for _, entity := range slices.Sorted(maps.Keys(report.Records)) {
+		fmt.Fprintf(w, "  %-30s %d records\n", entity, report.Records[entity])
+	}
+	for _, entity := range report.Removed {
+		fmt.Fprintf(w, "  removed %s\n", entity)
+	}
+	if report.ChangeSeq > 0 {
+		fmt.Fprintf(w, "  change feed marked at seq %d\n", report.ChangeSeq)
+	}
+	if report.Discarded > 0 {
+		fmt.Fprintf(w, "  discarded %d unsynced operations\n", report.Discarded)
+	}
+	for _, name := range report.Resynced {
+		fmt.Fprintf(w, "  resynced %s\n", name)
+	}
+	for _, name := range slices.Sorted(maps.Keys(report.Failed)) {
+		fmt.Fprintf(w, "  failed to resync %s: %v\n", name, report.Failed[name])
+	}
+}
+
+func readArchive(path string) (backup.Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return backup.Manifest{}, err
+	}
+	defer f.Close()
+	return backup.Walk(f, nil)
+}
+
+func loadOffline(config string) error {
+	if err := configuration.LoadConfig(&config); err != nil {
+		return err
+	}
+	configuration.Config.Gateway.StartsNodes = false
+	if err := nodes.Init(); err != nil {
+		return err
+	}
+	sharding.Init(nodes.ElysianCluster.Groups())
+	return nil
+}
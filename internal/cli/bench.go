@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+type benchResult struct {
+	reads     int
+	writes    int
+	errors    int
+	latencies []time.Duration
+	firstErr  error
+}
+
+func Bench(args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	r := remoteFlags(fs)
+	entity := fs.String("entity", "bench", "Entity the load is sent to")
+	keys := fs.Int("keys", 100, "Number of records created before the run and then read and updated")
+	duration := fs.Duration("duration", 10*time.Second, "How long to run")
+	concurrency := fs.Int("concurrency", 8, "Number of concurrent clients")
+	writeRatio := fs.Float64("write-ratio", 0.1, "Fraction of requests that are updates (0..1)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *keys <= 0 || *concurrency <= 0 || *duration <= 0 || *writeRatio < 0 || *writeRatio > 1 {
+		fmt.Fprintln(stderr, "bench: --keys, --concurrency and --duration must be positive and --write-ratio within 0..1")
+		return 2
+	}
+	if *r.timeout > 30*time.Second {
+		*r.timeout = 30 * time.Second
+	}
+	r.client = r.newClient(*concurrency)
+
+	path := "/api/" + url.PathEscape(*entity)
+	ids := make([]string, *keys)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("bench-%d", i)
+		if err := benchRequest(r, "POST", path, record(ids[i], 0)); err != nil {
+			fmt.Fprintf(stderr, "bench: seeding %s: %v\n", *entity, err)
+			return 1
+		}
+	}
+
+	results := make([]benchResult, *concurrency)
+	deadline := time.Now().Add(*duration)
+	var wg sync.WaitGroup
+	for w := range results {
+		wg.Add(1)
+		go func(res *benchResult) {
+			defer wg.Done()
+			for i := 0; time.Now().Before(deadline); i++ {
+				id := ids[rand.IntN(len(ids))]
+				method, body := "GET", []byte(nil)
+				if rand.Float64() < *writeRatio {
+					method, body = "PUT", record(id, i)
+					res.writes++
+				} else {
+					res.reads++
+				}
+				start := time.Now()
+				err := benchRequest(r, method, path+"/"+url.PathEscape(id), body)
+				res.latencies = append(res.latencies, time.Since(start))
+				if err != nil {
+					res.errors++
+					if res.firstErr == nil {
+						res.firstErr = err
+					}
+				}
+			}
+		}(&results[w])
+	}
+	wg.Wait()
+
+	var total benchResult
+	for _, res := range results {
+		total.reads += res.reads
+		total.writes += res.writes
+		total.errors += res.errors
+		total.latencies = append(total.latencies, res.latencies...)
+		if total.firstErr == nil {
+			total.firstErr = res.firstErr
+		}
+	}
+	printBench(stdout, total, *duration)
+	if total.firstErr != nil {
+		fmt.Fprintf(stderr, "bench: first error: %v\n", total.firstErr)
+		return 1
+	}
+	return 0
+}
+
+func record(id string, n int) []byte {
+	return []byte(fmt.Sprintf(`{"id":%q,"n":%d,"payload":"elysiangate-bench"}`, id, n))
+}
+
+func benchRequest(r *remote, method string, path string, body []byte) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	resp, err := r.do(method, path, reader)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := failed(resp); err != nil {
+		return err
+	}
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
+}
+
+func printBench(w io.Writer, res benchResult, elapsed time.Duration) {
+	n := len(res.latencies)
+	fmt.Fprintf(w, "requests   %d (%d reads, %d writes, %d errors)\n", n, res.reads, res.writes, res.errors)
+	fmt.Fprintf(w, "throughput %.1f req/s\n", float64(n)/elapsed.Seconds())
+	if n == 0 {
+		return
+	}
+	slices.Sort(res.latencies)
+	fmt.Fprintf(w, "latency    p50 %v  p95 %v  p99 %v  max %v\n",
+		percentile(res.latencies, 0.50), percentile(res.latencies, 0.95), percentile(res.latencies, 0.99), res.latencies[n-1])
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	return sorted[int(float64(len(sorted)-1)*p)].Round(time.Microsecond)
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/datadir"
+)
+
+var Stdin io.Reader = os.Stdin
+
+func Clear(args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("clear", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: elysiangate clear [node] [flags]")
+		fs.PrintDefaults()
+	}
+	configFile := fs.String("config", "elysiangate.yaml", "Path to gateway config file")
+	yes := fs.Bool("yes", false, "Do not ask for confirmation")
+	name, err := parseWithArg(fs, args)
+	if err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintf(stderr, "clear: %v\n", err)
+		}
+		return 2
+	}
+
+	cfg, err := configuration.ReadElysianConfig(*configFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "clear: %v\n", err)
+		return 1
+	}
+	var only []string
+	if name != "" {
+		only = append(only, name)
+	}
+	if err := clearData(cfg, only, *yes, stdout); err != nil {
+		fmt.Fprintf(stderr, "clear: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func clearData(cfg configuration.ElysianGateConfig, only []string, yes bool, stdout io.Writer) error {
+	dirs, err := datadir.Resolve(cfg, only...)
+	if err != nil {
+		return err
+	}
+
+	if !yes {
+		fmt.Fprintln(stdout, "This permanently deletes the data of:")
+		for _, d := range dirs {
+			fmt.Fprintf(stdout, "  %-12s %s\n", d.Node, d.Path)
+		}
+		fmt.Fprint(stdout, "Continue? [y/N] ")
+		answer, _ := bufio.NewReader(Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			return fmt.Errorf("aborted, nothing was removed (use --yes to skip this question)")
+		}
+	}
+
+	results, err := datadir.Remove(dirs)
+	for _, r := range results {
+		if r.Missing {
+			fmt.Fprintf(stdout, "%-12s %s: nothing to remove\n", r.Node, r.Path)
+			continue
+		}
+		fmt.Fprintf(stdout, "%-12s %s: removed %d file(s), %d bytes\n", r.Node, r.Path, r.Files, r.Bytes)
+	}
+	return err
+}
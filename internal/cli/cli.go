@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout io.Writer, stderr io.Writer) int
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"serve", "Start the gateway (default)", Serve},
+		{"status", "Print the cluster state of a running gateway", Status},
+		{"resync", "Resync a slave from its group master", nodeAction("resync")},
+		{"promote", "Promote a slave to master of its group", nodeAction("promote")},
+		{"drain", "Drain a node (--undo to put it back in rotation)", nodeAction("drain")},
+		{"clear", "Delete the store folders of all nodes, or of one node", Clear},
+		{"gen-configs", "Write the ElysianDB config file of every node", GenConfigs},
+		{"fake-nodes", "Run in-memory ElysianDB nodes on the configured ports", FakeNodes},
+		{"validate", "Check a config file without starting the gateway", Validate},
+		{"bench", "Run a read/write load test against a running gateway", Bench},
+		{"backup", "Write a backup archive of the cluster", Backup},
+		{"restore", "Restore the cluster from a backup archive", Restore},
+	}
+}
+
+func Run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") && args[0] != "-h" && args[0] != "--help" {
+		return Serve(args, stdout, stderr)
+	}
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(args[1:], stdout, stderr)
+		}
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(stdout)
+		return 0
+	}
+	fmt.Fprintf(stderr, "unknown command %q\n\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: elysiangate [command] [flags]")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w, "\nRun 'elysiangate <command> -h' for the flags of a command.")
+}
+
+func parseWithArg(fs *flag.FlagSet, args []string) (string, error) {
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+	if fs.NArg() == 0 {
+		return "", nil
+	}
+	arg := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return "", err
+	}
+	if fs.NArg() > 0 {
+		return "", fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	return arg, nil
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+)
+
+const apiKeyEnv = "ELYSIAN_GATE_API_KEY"
+
+type remote struct {
+	config   *string
+	gateway  *string
+	apiKey   *string
+	insecure *bool
+	timeout  *time.Duration
+	base     string
+	client   *http.Client
+}
+
+func remoteFlags(fs *flag.FlagSet) *remote {
+	return &remote{
+		config:   fs.String("config", "elysiangate.yaml", "Path to gateway config file"),
+		gateway:  fs.String("gateway", "", "Gateway base URL (default: from the config file)"),
+		apiKey:   fs.String("api-key", os.Getenv(apiKeyEnv), "API key sent as X-API-Key (default: $"+apiKeyEnv+")"),
+		insecure: fs.Bool("insecure", false, "Skip TLS certificate verification"),
+		timeout:  fs.Duration("timeout", 10*time.Minute, "Request timeout"),
+	}
+}
+
+func (r *remote) baseURL() (string, error) {
+	if *r.gateway != "" {
+		return strings.TrimRight(*r.gateway, "/"), nil
+	}
+	cfg, err := configuration.ReadElysianConfig(*r.config)
+	if err != nil {
+		return "", fmt.Errorf("no --gateway given and config unreadable: %w", err)
+	}
+	host := cfg.Gateway.HTTP.Host
+	if host == "" || host == "0.0.0.0" {
+		host = "127.0.0.1"
+	}
+	scheme := "http"
+	if cfg.Gateway.HTTP.TLS.Enabled {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%d", scheme, host, cfg.Gateway.HTTP.Port), nil
+}
+
+func (r *remote) do(method string, path string, body io.Reader) (*http.Response, error) {
+	if r.base == "" {
+		base, err := r.baseURL()
+		if err != nil {
+			return nil, err
+		}
+		r.base = base
+	}
+	req, err := http.NewRequest(method, r.base+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if *r.apiKey != "" {
+		req.Header.Set("X-API-Key", *r.apiKey)
+	}
+	if r.client == nil {
+		r.client = r.newClient(0)
+	}
+	return r.client.Do(req)
+}
+
+func (r *remote) newClient(maxConns int) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if maxConns > 0 {
+		transport.MaxIdleConnsPerHost = maxConns
+	}
+	if *r.insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return &http.Client{Timeout: *r.timeout, Transport: transport}
+}
+
+func failed(resp *http.Response) error {
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return fmt.Errorf("gateway answered %s: %s", resp.Status, strings.TrimSpace(string(data)))
+}
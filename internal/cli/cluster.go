@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/elysiandb/elysian-gate/internal/dashboard"
+)
+
+func Status(args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	r := remoteFlags(fs)
+	asJSON := fs.Bool("json", false, "Print the raw JSON answered by the gateway")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	resp, err := r.do("GET", "/admin/cluster", nil)
+	if err != nil {
+		fmt.Fprintf(stderr, "status: %v\n", err)
+		return 1
+	}
+	defer resp.Body.Close()
+	if err := failed(resp); err != nil {
+		fmt.Fprintf(stderr, "status: %v\n", err)
+		return 1
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Fprintf(stderr, "status: %v\n", err)
+		return 1
+	}
+	if *asJSON {
+		stdout.Write(data)
+		fmt.Fprintln(stdout)
+		return 0
+	}
+	var v dashboard.View
+	if err := json.Unmarshal(data, &v); err != nil {
+		fmt.Fprintf(stderr, "status: invalid answer from gateway: %v\n", err)
+		return 1
+	}
+	dashboard.WriteTable(stdout, v)
+	return 0
+}
+
+func nodeAction(action string) func(args []string, stdout io.Writer, stderr io.Writer) int {
+	return func(args []string, stdout io.Writer, stderr io.Writer) int {
+		fs := flag.NewFlagSet(action, flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		fs.Usage = func() {
+			fmt.Fprintf(stderr, "Usage: elysiangate %s <node> [flags]\n", action)
+			fs.PrintDefaults()
+		}
+		r := remoteFlags(fs)
+		var undo *bool
+		if action == "drain" {
+			undo = fs.Bool("undo", false, "Undrain the node instead")
+		}
+		name, err := parseWithArg(fs, args)
+		if err != nil {
+			if err != flag.ErrHelp {
+				fmt.Fprintf(stderr, "%s: %v\n", action, err)
+			}
+			return 2
+		}
+		if name == "" {
+			fs.Usage()
+			return 2
+		}
+
+		verb := action
+		if undo != nil && *undo {
+			verb = "undrain"
+		}
+		resp, err := r.do("POST", "/admin/nodes/"+url.PathEscape(name)+"/"+verb, nil)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", verb, err)
+			return 1
+		}
+		defer resp.Body.Close()
+		if err := failed(resp); err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", verb, err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "%s: %s ok\n", name, verb)
+		return 0
+	}
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/fakedb"
+)
+
+func FakeNodes(args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("fake-nodes", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configFile := fs.String("config", "elysiangate.yaml", "Path to gateway config file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := configuration.ReadElysianConfig(*configFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "fake-nodes: %v\n", err)
+		return 1
+	}
+	if err := configuration.Validate(cfg); err != nil {
+		fmt.Fprintf(stderr, "fake-nodes: %v\n", err)
+		return 1
+	}
+
+	servers := []*fakedb.Server{}
+	defer func() {
+		for _, s := range servers {
+			s.Close()
+		}
+	}()
+	for _, name := range slices.Sorted(maps.Keys(cfg.Nodes)) {
+		n := cfg.Nodes[name]
+		s, err := fakedb.Listen(fmt.Sprintf("%s:%d", n.HTTP.Host, n.HTTP.Port), fmt.Sprintf("%s:%d", n.TCP.Host, n.TCP.Port))
+		if err != nil {
+			fmt.Fprintf(stderr, "fake-nodes: node %s: %v\n", name, err)
+			return 1
+		}
+		servers = append(servers, s)
+		fmt.Fprintf(stdout, "%-12s %-7s HTTP %s | TCP %s\n", name, n.Role, s.HTTPAddr(), s.TCPAddr())
+	}
+	fmt.Fprintln(stdout, "In-memory nodes running, press Ctrl+C to stop")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+	return 0
+}
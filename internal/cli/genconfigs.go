@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/nodeconfig"
+)
+
+func GenConfigs(args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("gen-configs", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configFile := fs.String("config", "elysiangate.yaml", "Path to gateway config file")
+	check := fs.Bool("check", false, "Only report node configs that are missing or out of date")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := configuration.ReadElysianConfig(*configFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "gen-configs: %v\n", err)
+		return 1
+	}
+	if err := configuration.Validate(cfg); err != nil {
+		fmt.Fprintf(stderr, "gen-configs: %v\n", err)
+		return 1
+	}
+
+	generated, err := nodeconfig.Generate(cfg, !*check)
+	stale := 0
+	for _, g := range generated {
+		switch {
+		case g.External:
+			fmt.Fprintf(stdout, "%-12s %s: maintained by hand (elysiandbConfig), skipped\n", g.Node, g.Path)
+		case !g.Changed:
+			fmt.Fprintf(stdout, "%-12s %s: up to date\n", g.Node, g.Path)
+		case *check:
+			stale++
+			fmt.Fprintf(stdout, "%-12s %s: out of date\n", g.Node, g.Path)
+		default:
+			fmt.Fprintf(stdout, "%-12s %s: written\n", g.Node, g.Path)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "gen-configs: %v\n", err)
+		return 1
+	}
+	if stale > 0 {
+		fmt.Fprintf(stderr, "gen-configs: %d node config(s) out of date, run elysianGate gen-configs\n", stale)
+		return 1
+	}
+	return 0
+}
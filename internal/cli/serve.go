@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/elysiandb/elysian-gate/internal/auth"
+	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/boot"
+	"github.com/elysiandb/elysian-gate/internal/changelog"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/dashboard"
+	"github.com/elysiandb/elysian-gate/internal/events"
+	"github.com/elysiandb/elysian-gate/internal/logger"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+	"github.com/elysiandb/elysian-gate/internal/ratelimit"
+	"github.com/elysiandb/elysian-gate/internal/schema"
+	"github.com/elysiandb/elysian-gate/internal/sharding"
+	"github.com/elysiandb/elysian-gate/internal/tracing"
+)
+
+func Serve(args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	clear := fs.Bool("clear", false, "Delete the store folders of all configured nodes before starting")
+	yes := fs.Bool("yes", false, "Do not ask for confirmation before --clear")
+	configFile := fs.String("config", "elysiangate.yaml", "Path to gateway config file")
+	ui := fs.String("ui", string(dashboard.ModeAuto), "Dashboard mode: auto, headless, lines or tui")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	uiMode, err := dashboard.ParseMode(*ui)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	logger.Info("Starting ElysianGate...")
+
+	if err := configuration.LoadConfig(configFile); err != nil {
+		fmt.Fprintf(stderr, "Failed to load config: %v\n", err)
+		return 1
+	}
+
+	if *clear {
+		logger.Info("Clearing previous data...")
+		if err := clearData(configuration.Config, nil, *yes, stdout); err != nil {
+			fmt.Fprintf(stderr, "clear: %v\n", err)
+			return 1
+		}
+	}
+	if err := boot.InitLogger(); err != nil {
+		fmt.Fprintf(stderr, "Invalid log configuration: %v\n", err)
+		return 1
+	}
+	if err := tracing.Init(); err != nil {
+		logger.Error("Failed to start tracing", logger.F("error", err))
+		fmt.Fprintf(stderr, "Failed to start tracing: %v\n", err)
+		return 1
+	}
+
+	if err := changelog.Init(); err != nil {
+		logger.Error("Failed to open change log", logger.F("error", err))
+		fmt.Fprintf(stderr, "Failed to open change log: %v\n", err)
+		return 1
+	}
+	balancer.ResumeSeq(changelog.LastSeq())
+
+	if err := auth.Init(); err != nil {
+		logger.Error(fmt.Sprintf("Failed to load authentication keys: %v", err))
+		fmt.Fprintf(stderr, "Failed to load authentication keys: %v\n", err)
+		return 1
+	}
+
+	if err := schema.Init(); err != nil {
+		logger.Error(fmt.Sprintf("Failed to load JSON schemas: %v", err))
+		fmt.Fprintf(stderr, "Failed to load JSON schemas: %v\n", err)
+		return 1
+	}
+
+	if err := ratelimit.Init(); err != nil {
+		logger.Error(fmt.Sprintf("Failed to load persisted quotas: %v", err))
+	}
+
+	events.StartWebhooks()
+	if err := nodes.Init(); err != nil {
+		logger.Error(fmt.Sprintf("Failed to initialize nodes: %v", err))
+		fmt.Fprintf(stderr, "Failed to initialize nodes: %v\n", err)
+		return 1
+	}
+	sharding.Init(nodes.ElysianCluster.Groups())
+	boot.BootSyncer()
+	if err := boot.InitHTTP(); err != nil {
+		logger.Error(fmt.Sprintf("Failed to start HTTP server: %v", err))
+		fmt.Fprintf(stderr, "Failed to start HTTP server: %v\n", err)
+		return 1
+	}
+
+	logger.Info("───────────────────────────────────────────────")
+	logger.Info(" Gateway is ready to orchestrate the cluster  ")
+	logger.Info("───────────────────────────────────────────────")
+	nodes.ElysianCluster.StartMonitoring()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	done := dashboard.Start(ctx, uiMode)
+
+	<-done
+
+	logger.Info("Shutting down ElysianGate...")
+	tracing.Shutdown()
+	logger.Sync()
+	return 0
+}
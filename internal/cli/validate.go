@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/elysiandb/elysian-gate/internal/auth"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/logger"
+	"github.com/elysiandb/elysian-gate/internal/schema"
+	"github.com/elysiandb/elysian-gate/internal/tlsconfig"
+)
+
+func Validate(args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configFile := fs.String("config", "elysiangate.yaml", "Path to gateway config file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := configuration.ReadElysianConfig(*configFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", *configFile, err)
+		return 1
+	}
+
+	problems := check(cfg)
+	if len(problems) > 0 {
+		fmt.Fprintf(stderr, "%s: %d problem(s)\n", *configFile, len(problems))
+		for _, p := range problems {
+			fmt.Fprintf(stderr, "  - %v\n", p)
+		}
+		return 1
+	}
+	fmt.Fprintf(stdout, "%s: OK (%d nodes)\n", *configFile, len(cfg.Nodes))
+	return 0
+}
+
+func check(cfg configuration.ElysianGateConfig) []error {
+	var problems []error
+	if err := configuration.Validate(cfg); err != nil {
+		problems = append(problems, unjoin(err)...)
+	}
+	if _, err := logger.ParseLevel(cfg.Gateway.Log.Level); err != nil {
+		problems = append(problems, fmt.Errorf("log: %w", err))
+	}
+	if cfg.Gateway.HTTP.TLS.Enabled {
+		if _, err := tlsconfig.ServerConfig(cfg.Gateway.HTTP.TLS); err != nil {
+			problems = append(problems, fmt.Errorf("gateway tls: %w", err))
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Nodes)) {
+		if tls := cfg.Nodes[name].HTTP.TLS; tls.Enabled {
+			if _, err := tlsconfig.ClientConfig(tls); err != nil {
+				problems = append(problems, fmt.Errorf("node %s tls: %w", name, err))
+			}
+		}
+	}
+
+	previous := configuration.Config
+	defer func() { configuration.Config = previous }()
+	configuration.Config = cfg
+	if err := auth.Init(); err != nil {
+		problems = append(problems, fmt.Errorf("auth: %w", err))
+	}
+	if err := schema.Init(); err != nil {
+		problems = append(problems, fmt.Errorf("schemas: %w", err))
+	}
+	return problems
+}
+
+func unjoin(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
@@ -0,0 +1,76 @@
+package coalesce
+
+import (
+	"fmt"
+	"sync"
+)
+
+type Result struct {
+	Status int
+	Body   []byte
+	Err    error
+	Shared bool
+}
+
+type call struct {
+	wg     sync.WaitGroup
+	result Result
+	dups   int
+}
+
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+func (g *Group) Do(key string, fn func() (int, []byte, error)) Result {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = map[string]*call{}
+	}
+	if c, ok := g.calls[key]; ok {
+		c.dups++
+		g.mu.Unlock()
+		c.wg.Wait()
+		res := c.result
+		res.Shared = true
+		return res
+	}
+	c := &call{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	g.run(key, c, fn)
+	return c.result
+}
+
+// Forget drops the in-flight calls whose key matches, so later callers start
+// a fresh call instead of joining one that began before a write.
+func (g *Group) Forget(match func(key string) bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for key := range g.calls {
+		if match(key) {
+			delete(g.calls, key)
+		}
+	}
+}
+
+func (g *Group) run(key string, c *call, fn func() (int, []byte, error)) {
+	defer c.wg.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			c.result = Result{Err: fmt.Errorf("coalesced call panicked: %v", r)}
+		}
+		g.mu.Lock()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		c.result.Shared = c.dups > 0
+		g.mu.Unlock()
+	}()
+
+	status, body, err := fn()
+	c.result = Result{Status: status, Body: body, Err: err}
+}
@@ -1,34 +1,285 @@
 package configuration
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 
+	"github.com/elysiandb/elysian-gate/internal/global"
 	"github.com/elysiandb/elysian-gate/internal/logger"
 	"gopkg.in/yaml.v3"
 )
 
+type TLS struct {
+	Enabled            bool   `yaml:"enabled"`
+	CAFile             string `yaml:"caFile"`
+	CertFile           string `yaml:"certFile"`
+	KeyFile            string `yaml:"keyFile"`
+	ServerName         string `yaml:"serverName"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+}
+
+type ServerTLS struct {
+	Enabled           bool   `yaml:"enabled"`
+	CertFile          string `yaml:"certFile"`
+	KeyFile           string `yaml:"keyFile"`
+	ClientCAFile      string `yaml:"clientCAFile"`
+	RequireClientCert bool   `yaml:"requireClientCert"`
+}
+
 type Transport struct {
 	Enabled bool   `yaml:"enabled"`
 	Host    string `yaml:"host"`
 	Port    int    `yaml:"port"`
+	TLS     TLS    `yaml:"tls"`
+}
+
+type DeepCheck struct {
+	Enabled     bool   `yaml:"enabled"`
+	Key         string `yaml:"key"`
+	ExpectValue string `yaml:"expectValue"`
+}
+
+type HealthCheck struct {
+	IntervalMs  int       `yaml:"intervalMs"`
+	TimeoutMs   int       `yaml:"timeoutMs"`
+	Rise        int       `yaml:"rise"`
+	Fall        int       `yaml:"fall"`
+	DeepCheck   DeepCheck `yaml:"deepCheck"`
+	MaxParallel int       `yaml:"maxParallel"`
+}
+
+type CrashRecovery struct {
+	Enabled  *bool `yaml:"enabled"`
+	MaxLogMB int   `yaml:"maxLogMB"`
+}
+
+type Store struct {
+	Folder               string        `yaml:"folder"`
+	Shards               int           `yaml:"shards"`
+	FlushIntervalSeconds int           `yaml:"flushIntervalSeconds"`
+	CrashRecovery        CrashRecovery `yaml:"crashRecovery"`
+}
+
+type NodeCache struct {
+	Enabled                *bool `yaml:"enabled"`
+	CleanupIntervalSeconds int   `yaml:"cleanupIntervalSeconds"`
+}
+
+type ElysianDB struct {
+	Store                   Store     `yaml:"store"`
+	Cache                   NodeCache `yaml:"cache"`
+	LogFlushIntervalSeconds int       `yaml:"logFlushIntervalSeconds"`
+	IndexWorkers            int       `yaml:"indexWorkers"`
+	Stats                   *bool     `yaml:"stats"`
 }
 
 type Node struct {
-	Role string    `yaml:"role"`
-	HTTP Transport `yaml:"http"`
-	TCP  Transport `yaml:"tcp"`
+	Role            string      `yaml:"role"`
+	Group           string      `yaml:"group"`
+	HTTP            Transport   `yaml:"http"`
+	TCP             Transport   `yaml:"tcp"`
+	HealthCheck     HealthCheck `yaml:"healthCheck"`
+	ElysianDB       `yaml:",inline"`
+	ElysianDBConfig string `yaml:"elysiandbConfig"`
+}
+
+type List struct {
+	Fanout   int `yaml:"fanout"`
+	MaxItems int `yaml:"maxItems"`
+	MaxBytes int `yaml:"maxBytes"`
+}
+
+type EntityCache struct {
+	Enabled    *bool `yaml:"enabled"`
+	TTLSeconds int   `yaml:"ttlSeconds"`
+}
+
+type Cache struct {
+	Enabled    bool                   `yaml:"enabled"`
+	TTLSeconds int                    `yaml:"ttlSeconds"`
+	MaxEntries int                    `yaml:"maxEntries"`
+	MaxBytes   int                    `yaml:"maxBytes"`
+	Entities   map[string]EntityCache `yaml:"entities"`
+}
+
+type Coalescing struct {
+	Enabled     bool     `yaml:"enabled"`
+	Routes      []string `yaml:"routes"`
+	VaryHeaders []string `yaml:"varyHeaders"`
+}
+
+type Hedging struct {
+	Enabled    bool `yaml:"enabled"`
+	Percentile int  `yaml:"percentile"`
+	MinDelayMs int  `yaml:"minDelayMs"`
+	MaxDelayMs int  `yaml:"maxDelayMs"`
+}
+
+type Reads struct {
+	BudgetMs    int     `yaml:"budgetMs"`
+	MaxAttempts int     `yaml:"maxAttempts"`
+	Hedging     Hedging `yaml:"hedging"`
+}
+
+type CircuitBreaker struct {
+	Enabled             bool    `yaml:"enabled"`
+	ConsecutiveFailures int     `yaml:"consecutiveFailures"`
+	ErrorRate           float64 `yaml:"errorRate"`
+	MinRequests         int     `yaml:"minRequests"`
+	WindowSeconds       int     `yaml:"windowSeconds"`
+	OpenSeconds         int     `yaml:"openSeconds"`
+	HalfOpenProbes      int     `yaml:"halfOpenProbes"`
+}
+
+type APIKey struct {
+	Name  string   `yaml:"name"`
+	Key   string   `yaml:"key"`
+	Roles []string `yaml:"roles"`
+}
+
+type JWT struct {
+	HMACSecret       string `yaml:"hmacSecret"`
+	RSAPublicKeyFile string `yaml:"rsaPublicKeyFile"`
+	JWKSFile         string `yaml:"jwksFile"`
+	Issuer           string `yaml:"issuer"`
+	Audience         string `yaml:"audience"`
+	RolesClaim       string `yaml:"rolesClaim"`
+	LeewaySeconds    int    `yaml:"leewaySeconds"`
+	AllowMissingExp  bool   `yaml:"allowMissingExp"`
+}
+
+type Auth struct {
+	Enabled bool     `yaml:"enabled"`
+	APIKeys []APIKey `yaml:"apiKeys"`
+	JWT     JWT      `yaml:"jwt"`
+}
+
+type Grants map[string][]string
+
+type Authorization struct {
+	Enabled    bool              `yaml:"enabled"`
+	Roles      map[string]Grants `yaml:"roles"`
+	Principals map[string]Grants `yaml:"principals"`
+}
+
+type RateLimitRule struct {
+	Name   string  `yaml:"name"`
+	Client string  `yaml:"client"`
+	Entity string  `yaml:"entity"`
+	Class  string  `yaml:"class"`
+	Rate   float64 `yaml:"rate"`
+	Burst  int     `yaml:"burst"`
+	Daily  int64   `yaml:"daily"`
+}
+
+// Key identifies a rule's buckets and persisted quota counts. It does not
+// depend on the rule's position, so reordering rules keeps their usage.
+func (r RateLimitRule) Key() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return fmt.Sprintf("client=%s,entity=%s,class=%s", r.Client, r.Entity, r.Class)
+}
+
+type RateLimit struct {
+	Enabled                bool            `yaml:"enabled"`
+	Rules                  []RateLimitRule `yaml:"rules"`
+	PersistFile            string          `yaml:"persistFile"`
+	PersistIntervalSeconds int             `yaml:"persistIntervalSeconds"`
+}
+
+type EntityValidation struct {
+	MaxBodyBytes int    `yaml:"maxBodyBytes"`
+	SchemaFile   string `yaml:"schemaFile"`
+}
+
+type Validation struct {
+	MaxBodyBytes int                         `yaml:"maxBodyBytes"`
+	Entities     map[string]EntityValidation `yaml:"entities"`
+}
+
+type Log struct {
+	Output      string `yaml:"output"`
+	Level       string `yaml:"level"`
+	Format      string `yaml:"format"`
+	MaxSizeMB   int    `yaml:"maxSizeMB"`
+	RotateHours int    `yaml:"rotateHours"`
+	MaxBackups  int    `yaml:"maxBackups"`
+}
+
+type Tracing struct {
+	Enabled     bool    `yaml:"enabled"`
+	ServiceName string  `yaml:"serviceName"`
+	SampleRatio float64 `yaml:"sampleRatio"`
+	Exporter    string  `yaml:"exporter"`
+	File        string  `yaml:"file"`
+	Endpoint    string  `yaml:"endpoint"`
+}
+
+type Webhook struct {
+	URL        string   `yaml:"url"`
+	Secret     string   `yaml:"secret"`
+	Events     []string `yaml:"events"`
+	MaxRetries int      `yaml:"maxRetries"`
+	BackoffMs  int      `yaml:"backoffMs"`
+	TimeoutMs  int      `yaml:"timeoutMs"`
+}
+
+type Events struct {
+	Webhooks []Webhook `yaml:"webhooks"`
+}
+
+type ChangeFeed struct {
+	Enabled     bool   `yaml:"enabled"`
+	Dir         string `yaml:"dir"`
+	SegmentMB   int    `yaml:"segmentMB"`
+	MaxSegments int    `yaml:"maxSegments"`
+	Fsync       bool   `yaml:"fsync"`
+}
+
+type Backup struct {
+	MaxRestoreMB int    `yaml:"maxRestoreMB"`
+	SpoolDir     string `yaml:"spoolDir"`
+}
+
+type Sharding struct {
+	DefaultGroup   string            `yaml:"defaultGroup"`
+	Entities       map[string]string `yaml:"entities"`
+	HashedEntities []string          `yaml:"hashedEntities"`
+	VirtualNodes   int               `yaml:"virtualNodes"`
 }
 
 type ElysianGateConfig struct {
 	Nodes   map[string]Node `yaml:"nodes"`
 	Gateway struct {
-		StartsNodes bool `yaml:"startsNodes"`
-		HTTP        struct {
-			Host string `yaml:"host"`
-			Port int    `yaml:"port"`
+		StartsNodes   bool      `yaml:"startsNodes"`
+		NodeDefaults  ElysianDB `yaml:"nodeDefaults"`
+		NodeConfigDir string    `yaml:"nodeConfigDir"`
+		HTTP          struct {
+			Host string    `yaml:"host"`
+			Port int       `yaml:"port"`
+			TLS  ServerTLS `yaml:"tls"`
 		} `yaml:"http"`
-		SynchronizationInterval int `yaml:"synchronizationInterval"`
+		SynchronizationInterval int            `yaml:"synchronizationInterval"`
+		Sharding                Sharding       `yaml:"sharding"`
+		List                    List           `yaml:"list"`
+		Cache                   Cache          `yaml:"cache"`
+		Coalescing              Coalescing     `yaml:"coalescing"`
+		Reads                   Reads          `yaml:"reads"`
+		CircuitBreaker          CircuitBreaker `yaml:"circuitBreaker"`
+		Auth                    Auth           `yaml:"auth"`
+		Authorization           Authorization  `yaml:"authorization"`
+		RateLimit               RateLimit      `yaml:"rateLimit"`
+		Validation              Validation     `yaml:"validation"`
+		Log                     Log            `yaml:"log"`
+		Tracing                 Tracing        `yaml:"tracing"`
+		HealthCheck             HealthCheck    `yaml:"healthCheck"`
+		Events                  Events         `yaml:"events"`
+		ChangeFeed              ChangeFeed     `yaml:"changeFeed"`
+		Backup                  Backup         `yaml:"backup"`
 	} `yaml:"gateway"`
 }
 
@@ -49,16 +300,116 @@ func ReadElysianConfig(path string) (ElysianGateConfig, error) {
 func LoadConfig(configFile *string) error {
 	data, err := os.ReadFile(*configFile)
 	if err != nil {
-		logger.Error(fmt.Sprintf("Failed to read config file: %v\n", err))
+		logger.Error(fmt.Sprintf("Failed to read config file: %v", err))
 		return err
 	}
 	if err := yaml.Unmarshal(data, &Config); err != nil {
-		logger.Error(fmt.Sprintf("Invalid YAML config: %v\n", err))
+		logger.Error(fmt.Sprintf("Invalid YAML config: %v", err))
 		return err
 	}
-	if len(Config.Nodes) == 0 {
-		logger.Error("No nodes defined in config file.")
-		return fmt.Errorf("no nodes defined")
+	if err := Validate(Config); err != nil {
+		logger.Error(fmt.Sprintf("Invalid config: %v", err))
+		return err
 	}
 	return nil
 }
+
+func Validate(cfg ElysianGateConfig) error {
+	if len(cfg.Nodes) == 0 {
+		return errors.New("no nodes defined")
+	}
+
+	problems := []error{}
+	addresses := map[string]string{}
+	names := make([]string, 0, len(cfg.Nodes))
+	for name := range cfg.Nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		n := cfg.Nodes[name]
+		if n.Role != "master" && n.Role != "slave" {
+			problems = append(problems, fmt.Errorf("node %s has role %q, expected master or slave", name, n.Role))
+		}
+		for _, t := range []struct {
+			name string
+			Transport
+		}{{"http", n.HTTP}, {"tcp", n.TCP}} {
+			if t.Port <= 0 || t.Port > 65535 {
+				problems = append(problems, fmt.Errorf("node %s has invalid %s port %d", name, t.name, t.Port))
+				continue
+			}
+			addr := fmt.Sprintf("%s:%d", t.Host, t.Port)
+			if other, ok := addresses[addr]; ok {
+				problems = append(problems, fmt.Errorf("nodes %s and %s both listen on %s", other, name, addr))
+			}
+			addresses[addr] = name
+		}
+	}
+	if tls := cfg.Gateway.HTTP.TLS; tls.Enabled && tls.RequireClientCert && tls.ClientCAFile == "" {
+		problems = append(problems, errors.New("gateway TLS: requireClientCert needs clientCAFile"))
+	}
+	principals := make([]string, 0, len(cfg.Gateway.Authorization.Principals))
+	for name := range cfg.Gateway.Authorization.Principals {
+		principals = append(principals, name)
+	}
+	sort.Strings(principals)
+	for _, name := range principals {
+		if !strings.HasPrefix(name, "apikey:") && !strings.HasPrefix(name, "jwt:") {
+			problems = append(problems, fmt.Errorf("authorization principal %q must be written apikey:<name> or jwt:<subject>", name))
+		}
+	}
+	problems = append(problems, validateGroups(cfg, names)...)
+	keys := map[string]int{}
+	for i, rule := range cfg.Gateway.RateLimit.Rules {
+		if j, ok := keys[rule.Key()]; ok {
+			problems = append(problems, fmt.Errorf("rate limit rules %d and %d are both keyed %q, give them distinct names", j+1, i+1, rule.Key()))
+		}
+		keys[rule.Key()] = i
+	}
+	return errors.Join(problems...)
+}
+
+func validateGroups(cfg ElysianGateConfig, names []string) []error {
+	problems := []error{}
+	masters := map[string]int{}
+	groups := []string{}
+	for _, name := range names {
+		n := cfg.Nodes[name]
+		group := n.Group
+		if group == "" {
+			group = global.DefaultGroup
+		}
+		if _, ok := masters[group]; !ok {
+			masters[group] = 0
+			groups = append(groups, group)
+		}
+		if n.Role == "master" {
+			masters[group]++
+		}
+	}
+	sort.Strings(groups)
+	for _, group := range groups {
+		if count := masters[group]; count != 1 {
+			problems = append(problems, fmt.Errorf("group %s must have exactly one master, found %d", group, count))
+		}
+	}
+	sharding := cfg.Gateway.Sharding
+	if sharding.DefaultGroup != "" {
+		if _, ok := masters[sharding.DefaultGroup]; !ok {
+			problems = append(problems, fmt.Errorf("default group %s has no nodes", sharding.DefaultGroup))
+		}
+	}
+	entities := make([]string, 0, len(sharding.Entities))
+	for entity := range sharding.Entities {
+		entities = append(entities, entity)
+	}
+	sort.Strings(entities)
+	for _, entity := range entities {
+		group := sharding.Entities[entity]
+		if _, ok := masters[group]; !ok {
+			problems = append(problems, fmt.Errorf("entity %s is mapped to unknown group %s", entity, group))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,159 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/breaker"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+	"golang.org/x/term"
+)
+
+type Mode string
+
+const (
+	ModeAuto     Mode = "auto"
+	ModeHeadless Mode = "headless"
+	ModeLines    Mode = "lines"
+	ModeTUI      Mode = "tui"
+
+	refreshInterval = time.Second
+)
+
+type NodeView struct {
+	Name      string  `json:"name"`
+	Role      string  `json:"role"`
+	Group     string  `json:"group"`
+	HTTPUp    bool    `json:"http_up"`
+	TCPUp     bool    `json:"tcp_up"`
+	Ready     bool    `json:"ready"`
+	Drained   bool    `json:"drained"`
+	Unhealthy bool    `json:"unhealthy"`
+	Resyncing bool    `json:"resyncing"`
+	Breaker   string  `json:"breaker"`
+	QPS       float64 `json:"qps"`
+	Lag       int     `json:"lag"`
+}
+
+type GroupView struct {
+	Name       string `json:"name"`
+	PendingOps int    `json:"pending_ops"`
+}
+
+type View struct {
+	Time   time.Time   `json:"time"`
+	Nodes  []NodeView  `json:"nodes"`
+	Groups []GroupView `json:"groups"`
+}
+
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(s); m {
+	case ModeAuto, ModeHeadless, ModeLines, ModeTUI:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown ui mode %q (expected auto, headless, lines or tui)", s)
+}
+
+// Start runs the dashboard until ctx ends or the user quits the TUI. The
+// returned channel is closed once it has stopped and the terminal is restored.
+func Start(ctx context.Context, mode Mode) <-chan struct{} {
+	if mode == ModeAuto {
+		mode = ModeLines
+		if term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd())) {
+			mode = ModeTUI
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		switch mode {
+		case ModeLines:
+			runLines(ctx, os.Stdout)
+		case ModeTUI:
+			runTUI(ctx)
+		default:
+			<-ctx.Done()
+		}
+	}()
+	return done
+}
+
+func Collect() View {
+	v := View{Time: time.Now()}
+	groups := map[string]bool{}
+
+	for _, n := range nodes.ElysianCluster.Snapshot().Nodes {
+		group := n.ShardGroup()
+		nv := NodeView{
+			Name:      n.Name,
+			Role:      n.Role,
+			Group:     group,
+			HTTPUp:    n.HTTP.Up,
+			TCPUp:     n.TCP.Up,
+			Ready:     n.Ready,
+			Drained:   n.Drained,
+			Unhealthy: n.Unhealthy,
+			Resyncing: n.Resyncing,
+			Breaker:   breaker.GetState(n.Name).String(),
+			QPS:       balancer.RequestRate(n.Name),
+		}
+		if n.Role == "slave" {
+			nv.Lag = balancer.ReplicationLag(n.Name, group)
+		}
+		v.Nodes = append(v.Nodes, nv)
+		groups[group] = true
+	}
+
+	sort.Slice(v.Nodes, func(i, j int) bool {
+		a, b := v.Nodes[i], v.Nodes[j]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Role != b.Role {
+			return a.Role == "master"
+		}
+		return a.Name < b.Name
+	})
+
+	for g := range groups {
+		v.Groups = append(v.Groups, GroupView{Name: g, PendingOps: balancer.PendingOps(g)})
+	}
+	sort.Slice(v.Groups, func(i, j int) bool { return v.Groups[i].Name < v.Groups[j].Name })
+	return v
+}
+
+func (n NodeView) State() string {
+	switch {
+	case n.Drained:
+		return "drained"
+	case n.Unhealthy:
+		return "unhealthy"
+	case n.Resyncing:
+		return "resyncing"
+	case n.Ready:
+		return "ready"
+	}
+	return "not ready"
+}
+
+func (n NodeView) LagLabel() string {
+	switch {
+	case n.Role != "slave":
+		return "-"
+	case !n.Ready:
+		return "resync"
+	}
+	return fmt.Sprint(n.Lag)
+}
+
+func upDown(up bool) string {
+	if up {
+		return "up"
+	}
+	return "down"
+}
@@ -0,0 +1,57 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+type LineWriter struct {
+	w    io.Writer
+	prev map[string]string
+}
+
+func NewLineWriter(w io.Writer) *LineWriter {
+	return &LineWriter{w: w, prev: map[string]string{}}
+}
+
+func (lw *LineWriter) Update(v View) {
+	for _, n := range v.Nodes {
+		line := fmt.Sprintf("node=%s role=%s group=%s http=%s tcp=%s state=%q breaker=%s",
+			n.Name, n.Role, n.Group, upDown(n.HTTPUp), upDown(n.TCPUp), n.State(), n.Breaker)
+		if lw.prev[n.Name] == line {
+			continue
+		}
+		lw.prev[n.Name] = line
+		fmt.Fprintf(lw.w, "%s %s\n", v.Time.Format(time.RFC3339), line)
+	}
+}
+
+func WriteTable(w io.Writer, v View) {
+	fmt.Fprintf(w, "%-12s %-7s %-12s %-5s %-5s %-10s %-9s %8s %7s\n",
+		"NODE", "ROLE", "GROUP", "HTTP", "TCP", "STATE", "BREAKER", "QPS", "LAG")
+	for _, n := range v.Nodes {
+		fmt.Fprintf(w, "%-12s %-7s %-12s %-5s %-5s %-10s %-9s %8.1f %7s\n",
+			n.Name, n.Role, n.Group, upDown(n.HTTPUp), upDown(n.TCPUp), n.State(), n.Breaker, n.QPS, n.LagLabel())
+	}
+	fmt.Fprint(w, "\nPENDING OPS")
+	for _, g := range v.Groups {
+		fmt.Fprintf(w, "  %s: %d", g.Name, g.PendingOps)
+	}
+	fmt.Fprintln(w)
+}
+
+func runLines(ctx context.Context, w io.Writer) {
+	lw := NewLineWriter(w)
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+	for {
+		lw.Update(Collect())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
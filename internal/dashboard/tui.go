@@ -0,0 +1,261 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/logger"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+	"golang.org/x/term"
+)
+
+const (
+	enterScreen = "\033[?1049h\033[?25l"
+	leaveScreen = "\033[?25h\033[?1049l"
+	clearScreen = "\033[H\033[2J"
+	bold        = "\033[1m"
+	inverse     = "\033[7m"
+	green       = "\033[32m"
+	red         = "\033[31m"
+	yellow      = "\033[33m"
+	reset       = "\033[0m"
+)
+
+type key int
+
+const (
+	keyOther key = iota
+	keyUp
+	keyDown
+	keyQuit
+	keyInterrupt
+	keyEscape
+)
+
+type action struct {
+	prompt string
+	run    func() error
+	done   string
+}
+
+type tui struct {
+	mu       sync.Mutex
+	selected int
+	status   string
+	confirm  *action
+	redraw   chan struct{}
+}
+
+func runTUI(ctx context.Context) {
+	fd := int(os.Stdin.Fd())
+	old, err := term.MakeRaw(fd)
+	if err != nil {
+		logger.Warn("cannot start interactive dashboard, falling back to lines", logger.F("error", err))
+		runLines(ctx, os.Stdout)
+		return
+	}
+	fmt.Fprint(os.Stdout, enterScreen)
+	defer func() {
+		fmt.Fprint(os.Stdout, leaveScreen)
+		term.Restore(fd, old)
+	}()
+
+	t := &tui{redraw: make(chan struct{}, 1)}
+	keys := make(chan []byte)
+	go readInput(os.Stdin, keys)
+
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+	for {
+		v := Collect()
+		t.draw(os.Stdout, v)
+
+		select {
+		case in := <-keys:
+			if !t.handle(in, v) {
+				return
+			}
+		case <-ctx.Done():
+			return
+		case <-t.redraw:
+		case <-ticker.C:
+		}
+	}
+}
+
+func readInput(r io.Reader, keys chan<- []byte) {
+	buf := make([]byte, 16)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			return
+		}
+		keys <- append([]byte(nil), buf[:n]...)
+	}
+}
+
+func parseKeys(in []byte) ([]key, []byte) {
+	var keys []key
+	var runes []byte
+	for i := 0; i < len(in); i++ {
+		switch b := in[i]; {
+		case b == 0x1b && i+2 < len(in) && in[i+1] == '[' && in[i+2] == 'A':
+			keys, runes, i = append(keys, keyUp), append(runes, 0), i+2
+		case b == 0x1b && i+2 < len(in) && in[i+1] == '[' && in[i+2] == 'B':
+			keys, runes, i = append(keys, keyDown), append(runes, 0), i+2
+		case b == 0x1b:
+			keys, runes = append(keys, keyEscape), append(runes, 0)
+		case b == 0x03:
+			keys, runes = append(keys, keyInterrupt), append(runes, 0)
+		case b == 'q':
+			keys, runes = append(keys, keyQuit), append(runes, 0)
+		case b == 'k':
+			keys, runes = append(keys, keyUp), append(runes, 0)
+		case b == 'j':
+			keys, runes = append(keys, keyDown), append(runes, 0)
+		default:
+			keys, runes = append(keys, keyOther), append(runes, b)
+		}
+	}
+	return keys, runes
+}
+
+func (t *tui) handle(in []byte, v View) bool {
+	keys, runes := parseKeys(in)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, k := range keys {
+		if t.confirm != nil {
+			switch {
+			case k == keyInterrupt:
+				return false
+			case k == keyOther && (runes[i] == 'y' || runes[i] == 'Y'):
+				t.run(t.confirm)
+			default:
+				t.status = "cancelled"
+			}
+			t.confirm = nil
+			continue
+		}
+
+		switch k {
+		case keyQuit, keyInterrupt:
+			return false
+		case keyUp:
+			if t.selected > 0 {
+				t.selected--
+			}
+		case keyDown:
+			if t.selected < len(v.Nodes)-1 {
+				t.selected++
+			}
+		case keyOther:
+			if t.selected >= len(v.Nodes) {
+				continue
+			}
+			n := v.Nodes[t.selected]
+			switch runes[i] {
+			case 'd':
+				drained := !n.Drained
+				verb := "drained"
+				if !drained {
+					verb = "undrained"
+				}
+				t.run(&action{
+					run:  func() error { return nodes.ElysianCluster.Drain(n.Name, drained) },
+					done: fmt.Sprintf("%s %s", n.Name, verb),
+				})
+			case 'r':
+				t.confirm = &action{
+					prompt: fmt.Sprintf("Resync %s from the master of %s? (y/n)", n.Name, n.Group),
+					run:    func() error { return nodes.ElysianCluster.Resync(n.Name) },
+					done:   fmt.Sprintf("resync of %s started", n.Name),
+				}
+			case 'p':
+				t.confirm = &action{
+					prompt: fmt.Sprintf("Promote %s to master of %s? (y/n)", n.Name, n.Group),
+					run:    func() error { return balancer.PromoteNode(n.Name) },
+					done:   fmt.Sprintf("%s promoted to master", n.Name),
+				}
+			}
+		}
+	}
+	return true
+}
+
+func (t *tui) run(a *action) {
+	t.status = "working..."
+	go func() {
+		err := a.run()
+		t.mu.Lock()
+		t.status = a.done
+		if err != nil {
+			t.status = "error: " + err.Error()
+		}
+		t.mu.Unlock()
+		select {
+		case t.redraw <- struct{}{}:
+		default:
+		}
+	}()
+}
+
+func (t *tui) draw(w io.Writer, v View) {
+	t.mu.Lock()
+	if t.selected >= len(v.Nodes) && len(v.Nodes) > 0 {
+		t.selected = len(v.Nodes) - 1
+	}
+	status := t.status
+	if t.confirm != nil {
+		status = t.confirm.prompt
+	}
+	selected := t.selected
+	t.mu.Unlock()
+
+	fmt.Fprint(w, clearScreen+strings.ReplaceAll(Render(v, selected, status), "\n", "\r\n"))
+}
+
+func Render(v View, selected int, status string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%sElysianGate%s  %s\n\n", bold, reset, v.Time.Format("15:04:05"))
+	fmt.Fprintf(&b, "%s  %-12s %-7s %-12s %-5s %-5s %-10s %-9s %8s %7s%s\n",
+		bold, "NODE", "ROLE", "GROUP", "HTTP", "TCP", "STATE", "BREAKER", "QPS", "LAG", reset)
+
+	for i, n := range v.Nodes {
+		cursor, style := "  ", ""
+		if i == selected {
+			cursor, style = "> ", inverse
+		}
+		fmt.Fprintf(&b, "%s%s%-12s %-7s %-12s %s %s %s %-9s %8.1f %7s%s\n",
+			style, cursor, n.Name, n.Role, n.Group,
+			colorize(upDown(n.HTTPUp), n.HTTPUp, 5, style),
+			colorize(upDown(n.TCPUp), n.TCPUp, 5, style),
+			colorize(n.State(), n.State() == "ready", 10, style),
+			n.Breaker, n.QPS, n.LagLabel(), reset)
+	}
+
+	b.WriteString("\n" + bold + "PENDING OPS" + reset)
+	for _, g := range v.Groups {
+		fmt.Fprintf(&b, "  %s: %d", g.Name, g.PendingOps)
+	}
+	b.WriteString("\n\n[↑/↓] select  [d] drain/undrain  [r] resync  [p] promote  [q] quit\n")
+	if status != "" {
+		b.WriteString(yellow + status + reset + "\n")
+	}
+	return b.String()
+}
+
+func colorize(s string, ok bool, width int, style string) string {
+	color := red
+	if ok {
+		color = green
+	}
+	return color + fmt.Sprintf("%-*s", width, s) + reset + style
+}
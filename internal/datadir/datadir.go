@@ -0,0 +1,108 @@
+package datadir
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/nodeconfig"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+)
+
+type Dir struct {
+	Node string
+	Path string
+}
+
+type Result struct {
+	Dir
+	Files   int
+	Bytes   int64
+	Missing bool
+}
+
+func Resolve(cfg configuration.ElysianGateConfig, only ...string) ([]Dir, error) {
+	names := only
+	if len(names) == 0 {
+		for name := range cfg.Nodes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	dirs := []Dir{}
+	owners := map[string]string{}
+	for _, name := range names {
+		if _, ok := cfg.Nodes[name]; !ok {
+			return nil, fmt.Errorf("%w: %s", nodes.ErrUnknownNode, name)
+		}
+		folder, err := nodeconfig.StoreFolder(cfg, name)
+		if err != nil {
+			return nil, err
+		}
+		path, err := filepath.Abs(folder)
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", name, err)
+		}
+		if err := checkSafe(path); err != nil {
+			return nil, fmt.Errorf("node %s: %w", name, err)
+		}
+		if other, ok := owners[path]; ok {
+			return nil, fmt.Errorf("nodes %s and %s share the store folder %s", other, name, path)
+		}
+		owners[path] = name
+		dirs = append(dirs, Dir{Node: name, Path: path})
+	}
+	return dirs, nil
+}
+
+func checkSafe(path string) error {
+	if strings.Count(path, string(filepath.Separator)) < 2 {
+		return fmt.Errorf("refusing to clear top-level directory %s", path)
+	}
+	if home, err := os.UserHomeDir(); err == nil && path == filepath.Clean(home) {
+		return fmt.Errorf("refusing to clear home directory %s", path)
+	}
+	if wd, err := os.Getwd(); err == nil && (wd == path || strings.HasPrefix(wd, path+string(filepath.Separator))) {
+		return fmt.Errorf("refusing to clear %s: it contains the working directory", path)
+	}
+	return nil
+}
+
+func Remove(dirs []Dir) ([]Result, error) {
+	results := []Result{}
+	for _, d := range dirs {
+		r := Result{Dir: d}
+		info, err := os.Lstat(d.Path)
+		if errors.Is(err, fs.ErrNotExist) {
+			r.Missing = true
+			results = append(results, r)
+			continue
+		}
+		if err != nil {
+			return results, err
+		}
+		if !info.IsDir() {
+			return results, fmt.Errorf("store folder %s of node %s is not a directory", d.Path, d.Node)
+		}
+		filepath.WalkDir(d.Path, func(_ string, e fs.DirEntry, err error) error {
+			if err == nil && !e.IsDir() {
+				r.Files++
+				if fi, err := e.Info(); err == nil {
+					r.Bytes += fi.Size()
+				}
+			}
+			return nil
+		})
+		if err := os.RemoveAll(d.Path); err != nil {
+			return results, err
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
@@ -0,0 +1,150 @@
+package events
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	NodeUp         = "node.up"
+	NodeDown       = "node.down"
+	NodeUnhealthy  = "node.unhealthy"
+	NodeHealthy    = "node.healthy"
+	NodeDrained    = "node.drained"
+	NodeUndrained  = "node.undrained"
+	NodePromoted   = "node.promoted"
+	ResyncStarted  = "resync.started"
+	ResyncFinished = "resync.finished"
+	ResyncFailed   = "resync.failed"
+	SyncFailed     = "sync.failed"
+	BackupRestored = "backup.restored"
+)
+
+const (
+	historySize      = 256
+	subscriberBuffer = 256
+)
+
+type Event struct {
+	ID    uint64         `json:"id"`
+	Type  string         `json:"type"`
+	Time  time.Time      `json:"time"`
+	Node  string         `json:"node,omitempty"`
+	Group string         `json:"group,omitempty"`
+	Data  map[string]any `json:"data,omitempty"`
+}
+
+type Subscription struct {
+	C       <-chan Event
+	Backlog []Event
+	ch      chan Event
+	types   []string
+}
+
+var (
+	bus = struct {
+		sync.Mutex
+		seq     uint64
+		history []Event
+		subs    map[*Subscription]struct{}
+	}{subs: map[*Subscription]struct{}{}}
+	dropped atomic.Int64
+)
+
+func Publish(typ string, node string, group string, data map[string]any) Event {
+	bus.Lock()
+	defer bus.Unlock()
+
+	bus.seq++
+	e := Event{ID: bus.seq, Type: typ, Time: time.Now().UTC(), Node: node, Group: group, Data: data}
+	bus.history = append(bus.history, e)
+	if len(bus.history) > historySize {
+		bus.history = bus.history[len(bus.history)-historySize:]
+	}
+
+	for s := range bus.subs {
+		if !Matches(s.types, typ) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			dropped.Add(1)
+		}
+	}
+	return e
+}
+
+func Subscribe(types ...string) *Subscription {
+	return subscribe(false, 0, types)
+}
+
+func SubscribeFrom(since uint64, types ...string) *Subscription {
+	return subscribe(true, since, types)
+}
+
+func subscribe(replay bool, since uint64, types []string) *Subscription {
+	ch := make(chan Event, subscriberBuffer)
+	s := &Subscription{C: ch, ch: ch, types: types}
+
+	bus.Lock()
+	defer bus.Unlock()
+	if replay {
+		for _, e := range bus.history {
+			if e.ID > since && Matches(types, e.Type) {
+				s.Backlog = append(s.Backlog, e)
+			}
+		}
+	}
+	bus.subs[s] = struct{}{}
+	return s
+}
+
+func (s *Subscription) Close() {
+	bus.Lock()
+	defer bus.Unlock()
+	if _, ok := bus.subs[s]; !ok {
+		return
+	}
+	delete(bus.subs, s)
+	close(s.ch)
+}
+
+func Matches(patterns []string, typ string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		switch {
+		case p == "*" || p == typ:
+			return true
+		case strings.HasSuffix(p, ".*") && strings.HasPrefix(typ, strings.TrimSuffix(p, "*")):
+			return true
+		}
+	}
+	return false
+}
+
+func History() []Event {
+	bus.Lock()
+	defer bus.Unlock()
+	return append([]Event(nil), bus.history...)
+}
+
+func Dropped() int64 {
+	return dropped.Load()
+}
+
+func Reset() {
+	bus.Lock()
+	defer bus.Unlock()
+	for s := range bus.subs {
+		close(s.ch)
+	}
+	bus.subs = map[*Subscription]struct{}{}
+	bus.history = nil
+	bus.seq = 0
+	dropped.Store(0)
+}
@@ -0,0 +1,152 @@
+package events
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/logger"
+)
+
+const (
+	EventHeader     = "X-Elysian-Event"
+	EventIDHeader   = "X-Elysian-Event-ID"
+	TimestampHeader = "X-Elysian-Timestamp"
+	SignatureHeader = "X-Elysian-Signature"
+
+	defaultWebhookTimeout = 5 * time.Second
+	defaultWebhookRetries = 5
+	defaultWebhookBackoff = 500 * time.Millisecond
+	maxWebhookBackoff     = 30 * time.Second
+)
+
+var webhooks = struct {
+	sync.Mutex
+	stop    chan struct{}
+	running sync.WaitGroup
+}{}
+
+func StartWebhooks() {
+	StopWebhooks()
+
+	hooks := configuration.Config.Gateway.Events.Webhooks
+	if len(hooks) == 0 {
+		return
+	}
+
+	webhooks.Lock()
+	defer webhooks.Unlock()
+	webhooks.stop = make(chan struct{})
+	for _, h := range hooks {
+		sub := Subscribe(h.Events...)
+		webhooks.running.Add(1)
+		go runWebhook(h, sub, webhooks.stop)
+	}
+}
+
+func StopWebhooks() {
+	webhooks.Lock()
+	defer webhooks.Unlock()
+	if webhooks.stop == nil {
+		return
+	}
+	close(webhooks.stop)
+	webhooks.running.Wait()
+	webhooks.stop = nil
+}
+
+func runWebhook(h configuration.Webhook, sub *Subscription, stop chan struct{}) {
+	defer webhooks.running.Done()
+	defer sub.Close()
+
+	timeout := defaultWebhookTimeout
+	if h.TimeoutMs > 0 {
+		timeout = time.Duration(h.TimeoutMs) * time.Millisecond
+	}
+	client := &http.Client{Timeout: timeout}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			deliver(client, h, e, stop)
+		}
+	}
+}
+
+func deliver(client *http.Client, h configuration.Webhook, e Event, stop chan struct{}) {
+	body, _ := json.Marshal(e)
+
+	retries := defaultWebhookRetries
+	if h.MaxRetries > 0 {
+		retries = h.MaxRetries
+	}
+	backoff := defaultWebhookBackoff
+	if h.BackoffMs > 0 {
+		backoff = time.Duration(h.BackoffMs) * time.Millisecond
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := post(client, h, e, body)
+		if err == nil {
+			return
+		}
+		if attempt >= retries {
+			logger.Error("webhook delivery failed, giving up", logger.F("url", h.URL), logger.F("event", e.Type), logger.F("event_id", e.ID), logger.F("attempts", attempt+1), logger.F("error", err))
+			return
+		}
+		logger.Warn("webhook delivery failed, retrying", logger.F("url", h.URL), logger.F("event", e.Type), logger.F("event_id", e.ID), logger.F("attempt", attempt+1), logger.F("error", err))
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxWebhookBackoff)
+	}
+}
+
+func post(client *http.Client, h configuration.Webhook, e Event, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, e.Type)
+	req.Header.Set(EventIDHeader, strconv.FormatUint(e.ID, 10))
+	req.Header.Set(TimestampHeader, timestamp)
+	if h.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(h.Secret, timestamp, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,391 @@
+package fakedb
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/global"
+)
+
+const typesKey = "api:entity:types:list"
+
+type Server struct {
+	mu        sync.Mutex
+	store     *store
+	kv        map[string]string
+	requests  []string
+	latency   time.Duration
+	errStatus int
+	errRate   float64
+	partition chan struct{}
+
+	http    *http.Server
+	httpLn  net.Listener
+	tcpLn   net.Listener
+	closing chan struct{}
+	wg      sync.WaitGroup
+}
+
+func New() *Server {
+	s, err := Listen("127.0.0.1:0", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("fakedb: %v", err))
+	}
+	return s
+}
+
+func Listen(httpAddr string, tcpAddr string) (*Server, error) {
+	httpLn, err := net.Listen("tcp", httpAddr)
+	if err != nil {
+		return nil, err
+	}
+	tcpLn, err := net.Listen("tcp", tcpAddr)
+	if err != nil {
+		httpLn.Close()
+		return nil, err
+	}
+
+	s := &Server{
+		store:   newStore(),
+		kv:      map[string]string{},
+		httpLn:  httpLn,
+		tcpLn:   tcpLn,
+		closing: make(chan struct{}),
+	}
+	s.http = &http.Server{Handler: s}
+	s.wg.Add(2)
+	go func() {
+		defer s.wg.Done()
+		s.http.Serve(httpLn)
+	}()
+	go func() {
+		defer s.wg.Done()
+		s.serveTCP()
+	}()
+	return s, nil
+}
+
+func (s *Server) Close() {
+	select {
+	case <-s.closing:
+		return
+	default:
+	}
+	close(s.closing)
+	s.Heal()
+	s.http.Close()
+	s.tcpLn.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) HTTPAddr() string {
+	return s.httpLn.Addr().String()
+}
+
+func (s *Server) TCPAddr() string {
+	return s.tcpLn.Addr().String()
+}
+
+func (s *Server) URL() string {
+	return "http://" + s.HTTPAddr()
+}
+
+func (s *Server) Node(name string, role string) global.Node {
+	h := s.httpLn.Addr().(*net.TCPAddr)
+	t := s.tcpLn.Addr().(*net.TCPAddr)
+	return global.Node{
+		Name:  name,
+		Role:  role,
+		Ready: true,
+		HTTP:  global.Transport{Host: h.IP.String(), Port: h.Port, Up: true},
+		TCP:   global.Transport{Host: t.IP.String(), Port: t.Port, Up: true},
+	}
+}
+
+func (s *Server) NodeConfig(role string, group string) configuration.Node {
+	h := s.httpLn.Addr().(*net.TCPAddr)
+	t := s.tcpLn.Addr().(*net.TCPAddr)
+	return configuration.Node{
+		Role:  role,
+		Group: group,
+		HTTP:  configuration.Transport{Enabled: true, Host: h.IP.String(), Port: h.Port},
+		TCP:   configuration.Transport{Enabled: true, Host: t.IP.String(), Port: t.Port},
+	}
+}
+
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+func (s *Server) FailRequests(status int, rate float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errStatus, s.errRate = status, rate
+}
+
+func (s *Server) Partition() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.partition == nil {
+		s.partition = make(chan struct{})
+	}
+}
+
+func (s *Server) Heal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.partition != nil {
+		close(s.partition)
+		s.partition = nil
+	}
+}
+
+func (s *Server) ClearFaults() {
+	s.SetLatency(0)
+	s.FailRequests(0, 0)
+	s.Heal()
+}
+
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+func (s *Server) Put(entity string, docs ...map[string]any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, doc := range docs {
+		if _, err := s.store.create(entity, doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) Entities(entity string) []map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.list(entity, nil)
+}
+
+func (s *Server) Types() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.types()
+}
+
+func (s *Server) SetKey(key string, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kv[key] = value
+}
+
+func (s *Server) fault() (<-chan struct{}, time.Duration, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := 0
+	if s.errRate > 0 && rand.Float64() < s.errRate {
+		status = s.errStatus
+	}
+	return s.partition, s.latency, status
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.RequestURI())
+	s.mu.Unlock()
+
+	partition, latency, status := s.fault()
+	if partition != nil {
+		select {
+		case <-partition:
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if status != 0 {
+		writeJSON(w, status, map[string]string{"error": "injected fault"})
+		return
+	}
+
+	path := r.URL.EscapedPath()
+	switch {
+	case path == "/health":
+		w.Write([]byte("ok"))
+	case strings.HasPrefix(path, "/kv/"):
+		key, _ := url.PathUnescape(strings.TrimPrefix(path, "/kv/"))
+		s.serveKV(w, r, key)
+	case strings.HasPrefix(path, "/api/"):
+		entity, id, _ := strings.Cut(strings.TrimPrefix(path, "/api/"), "/")
+		entity, _ = url.PathUnescape(entity)
+		id, _ = url.PathUnescape(id)
+		s.serveAPI(w, r, entity, id)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+	}
+}
+
+func (s *Server) serveKV(w http.ResponseWriter, r *http.Request, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.Method {
+	case "GET":
+		value, ok := s.kv[key]
+		if !ok && key == typesKey {
+			value, ok = strings.Join(s.store.types(), ","), true
+		}
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "key not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"key": key, "value": value})
+	case "PUT", "POST":
+		body, _ := io.ReadAll(r.Body)
+		s.kv[key] = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	case "DELETE":
+		delete(s.kv, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request, entity string, id string) {
+	if entity == "" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "missing entity"})
+		return
+	}
+	var doc map[string]any
+	if r.Method == "POST" || r.Method == "PUT" {
+		if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case r.Method == "GET" && id == "":
+		q, err := parseQuery(r.URL.RawQuery)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, s.store.list(entity, q))
+	case r.Method == "GET":
+		if doc, ok := s.store.get(entity, id); ok {
+			writeJSON(w, http.StatusOK, doc)
+			return
+		}
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "entity not found"})
+	case r.Method == "POST" && id == "":
+		created, err := s.store.create(entity, doc)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, created)
+	case r.Method == "PUT" && id != "":
+		if doc, ok := s.store.update(entity, id, doc); ok {
+			writeJSON(w, http.StatusOK, doc)
+			return
+		}
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "entity not found"})
+	case r.Method == "DELETE" && id == "":
+		s.store.destroy(entity)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == "DELETE":
+		if !s.store.remove(entity, id) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "entity not found"})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) serveTCP() {
+	for {
+		conn, err := s.tcpLn.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handleTCP(conn)
+		}()
+	}
+}
+
+func (s *Server) handleTCP(conn net.Conn) {
+	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-s.closing:
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		partition, latency, _ := s.fault()
+		if partition != nil {
+			select {
+			case <-partition:
+			case <-s.closing:
+				return
+			}
+		}
+		if latency > 0 {
+			select {
+			case <-time.After(latency):
+			case <-s.closing:
+				return
+			}
+		}
+		reply := "ERR unknown command"
+		if strings.EqualFold(strings.TrimSpace(line), "PING") {
+			reply = "PONG"
+		}
+		if _, err := conn.Write([]byte(reply + "\n")); err != nil {
+			return
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
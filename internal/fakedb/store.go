@@ -0,0 +1,323 @@
+package fakedb
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var errInvalidID = errors.New("id must be a string")
+
+type collection struct {
+	docs  map[string]map[string]any
+	order []string
+}
+
+type store struct {
+	entities map[string]*collection
+}
+
+type filter struct {
+	field string
+	op    string
+	value string
+}
+
+type sortKey struct {
+	field string
+	desc  bool
+}
+
+type query struct {
+	filters []filter
+	sorts   []sortKey
+	limit   int
+	offset  int
+}
+
+func newStore() *store {
+	return &store{entities: map[string]*collection{}}
+}
+
+func (s *store) collection(entity string) *collection {
+	c := s.entities[entity]
+	if c == nil {
+		c = &collection{docs: map[string]map[string]any{}}
+		s.entities[entity] = c
+	}
+	return c
+}
+
+func (s *store) types() []string {
+	types := make([]string, 0, len(s.entities))
+	for t := range s.entities {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func (s *store) create(entity string, doc map[string]any) (map[string]any, error) {
+	doc = clone(doc)
+	id, ok := doc["id"].(string)
+	if !ok && doc["id"] != nil {
+		return nil, errInvalidID
+	}
+	if id == "" {
+		id = newID()
+		doc["id"] = id
+	}
+	c := s.collection(entity)
+	if _, exists := c.docs[id]; !exists {
+		c.order = append(c.order, id)
+	}
+	c.docs[id] = doc
+	return clone(doc), nil
+}
+
+func (s *store) get(entity string, id string) (map[string]any, bool) {
+	c := s.entities[entity]
+	if c == nil || c.docs[id] == nil {
+		return nil, false
+	}
+	return clone(c.docs[id]), true
+}
+
+func (s *store) update(entity string, id string, patch map[string]any) (map[string]any, bool) {
+	c := s.entities[entity]
+	if c == nil || c.docs[id] == nil {
+		return nil, false
+	}
+	for k, v := range patch {
+		if k != "id" {
+			c.docs[id][k] = v
+		}
+	}
+	return clone(c.docs[id]), true
+}
+
+func (s *store) remove(entity string, id string) bool {
+	c := s.entities[entity]
+	if c == nil || c.docs[id] == nil {
+		return false
+	}
+	delete(c.docs, id)
+	c.order = slices.DeleteFunc(c.order, func(o string) bool { return o == id })
+	return true
+}
+
+func (s *store) destroy(entity string) {
+	delete(s.entities, entity)
+}
+
+func (s *store) list(entity string, q *query) []map[string]any {
+	out := []map[string]any{}
+	c := s.entities[entity]
+	if c == nil {
+		return out
+	}
+	for _, id := range c.order {
+		if q == nil || q.matches(c.docs[id]) {
+			out = append(out, clone(c.docs[id]))
+		}
+	}
+	if q == nil {
+		return out
+	}
+	if len(q.sorts) > 0 {
+		sort.SliceStable(out, func(i, j int) bool {
+			for _, k := range q.sorts {
+				c := compare(lookup(out[i], k.field), lookup(out[j], k.field))
+				switch {
+				case c == 0:
+					continue
+				case k.desc:
+					return c > 0
+				}
+				return c < 0
+			}
+			return false
+		})
+	}
+	if q.offset >= len(out) {
+		return []map[string]any{}
+	}
+	out = out[q.offset:]
+	if q.limit >= 0 && q.limit < len(out) {
+		out = out[:q.limit]
+	}
+	return out
+}
+
+func parseQuery(raw string) (*query, error) {
+	q := &query{limit: -1}
+	for _, part := range strings.Split(raw, "&") {
+		if part == "" {
+			continue
+		}
+		rawKey, rawVal, _ := strings.Cut(part, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			return nil, err
+		}
+		val, err := url.QueryUnescape(rawVal)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case key == "limit" || key == "offset":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid %s %q", key, val)
+			}
+			if key == "limit" {
+				q.limit = n
+			} else {
+				q.offset = n
+			}
+		case strings.HasPrefix(key, "sort[") && strings.HasSuffix(key, "]"):
+			q.sorts = append(q.sorts, sortKey{field: key[len("sort[") : len(key)-1], desc: strings.EqualFold(val, "desc")})
+		case strings.HasPrefix(key, "filter["):
+			f, err := parseFilter(key, val)
+			if err != nil {
+				return nil, err
+			}
+			q.filters = append(q.filters, f)
+		}
+	}
+	return q, nil
+}
+
+func parseFilter(key string, val string) (filter, error) {
+	rest := strings.TrimPrefix(key, "filter[")
+	field, rest, ok := strings.Cut(rest, "]")
+	if !ok || field == "" {
+		return filter{}, fmt.Errorf("invalid filter %q", key)
+	}
+	f := filter{field: field, op: "eq", value: val}
+	if rest != "" {
+		if !strings.HasPrefix(rest, "[") || !strings.HasSuffix(rest, "]") {
+			return filter{}, fmt.Errorf("invalid filter %q", key)
+		}
+		f.op = rest[1 : len(rest)-1]
+	}
+	switch f.op {
+	case "eq", "neq", "lt", "lte", "gt", "gte", "contains":
+		return f, nil
+	}
+	return filter{}, fmt.Errorf("unknown filter operator %q", f.op)
+}
+
+func (q *query) matches(doc map[string]any) bool {
+	for _, f := range q.filters {
+		v := lookup(doc, f.field)
+		if f.op == "contains" {
+			if !strings.Contains(fmt.Sprint(v), f.value) {
+				return false
+			}
+			continue
+		}
+		c := compare(v, typed(f.value, v))
+		ok := false
+		switch f.op {
+		case "eq":
+			ok = c == 0
+		case "neq":
+			ok = c != 0
+		case "lt":
+			ok = c < 0
+		case "lte":
+			ok = c <= 0
+		case "gt":
+			ok = c > 0
+		case "gte":
+			ok = c >= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func typed(val string, like any) any {
+	switch like.(type) {
+	case float64:
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			return f
+		}
+	case bool:
+		if b, err := strconv.ParseBool(val); err == nil {
+			return b
+		}
+	}
+	return val
+}
+
+func lookup(doc map[string]any, field string) any {
+	var cur any = doc
+	for _, part := range strings.Split(field, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[part]
+	}
+	return cur
+}
+
+func compare(a any, b any) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	switch av := a.(type) {
+	case float64:
+		if bv, ok := b.(float64); ok {
+			switch {
+			case av < bv:
+				return -1
+			case av > bv:
+				return 1
+			}
+			return 0
+		}
+	case bool:
+		if bv, ok := b.(bool); ok {
+			switch {
+			case av == bv:
+				return 0
+			case !av:
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func clone(doc map[string]any) map[string]any {
+	data, _ := json.Marshal(doc)
+	out := map[string]any{}
+	json.Unmarshal(data, &out)
+	return out
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
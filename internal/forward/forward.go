@@ -2,32 +2,104 @@ package forward
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/requestid"
+	"github.com/elysiandb/elysian-gate/internal/tracing"
 )
 
+const requestTimeout = 3 * time.Second
+
+var ErrBodyTooLarge = errors.New("upstream response exceeds the size limit")
+
+type maxBodyKey struct{}
+
+// WithMaxBody makes requests sent with ctx stop reading a response after
+// limit bytes and fail with ErrBodyTooLarge.
+func WithMaxBody(ctx context.Context, limit int) context.Context {
+	return context.WithValue(ctx, maxBodyKey{}, limit)
+}
+
+var (
+	client  = &http.Client{Timeout: requestTimeout}
+	clients = struct {
+		sync.RWMutex
+		byHost map[string]*http.Client
+	}{byHost: map[string]*http.Client{}}
+)
+
+func RegisterTLS(hostPort string, cfg *tls.Config) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = cfg
+	clients.Lock()
+	clients.byHost[hostPort] = &http.Client{Timeout: requestTimeout, Transport: transport}
+	clients.Unlock()
+}
+
+func clientFor(req *http.Request) *http.Client {
+	clients.RLock()
+	defer clients.RUnlock()
+	if c, ok := clients.byHost[req.URL.Host]; ok {
+		return c
+	}
+	return client
+}
+
 func ForwardRequest(method string, url string, payload string) (int, string, error) {
+	return ForwardRequestContext(context.Background(), method, url, payload)
+}
+
+func ForwardRequestContext(ctx context.Context, method string, url string, payload string) (int, string, error) {
+	ctx, span := tracing.StartKind(ctx, "HTTP "+method, tracing.KindClient,
+		tracing.Attr("http.method", method),
+		tracing.Attr("http.url", url),
+	)
+	defer span.End()
+
 	var body io.Reader
 
 	if payload != "" {
 		body = bytes.NewBuffer([]byte(payload))
 	}
 
-	client := &http.Client{Timeout: 3 * time.Second}
-	req, err := http.NewRequest(method, url, body)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return 0, "", err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if id := requestid.ID(ctx); id != "" {
+		req.Header.Set(requestid.Header, id)
+	}
+	tracing.Inject(ctx, req.Header)
 
-	resp, err := client.Do(req)
+	resp, err := clientFor(req).Do(req)
 	if err != nil {
+		span.SetError(err)
 		return 0, "", fmt.Errorf("forward error: %w", err)
 	}
 	defer resp.Body.Close()
 
-	data, _ := io.ReadAll(resp.Body)
+	span.SetAttributes(tracing.Attr("http.status_code", resp.StatusCode))
+	if resp.StatusCode >= 500 {
+		span.SetError(fmt.Errorf("status %d", resp.StatusCode))
+	}
+
+	limit, _ := ctx.Value(maxBodyKey{}).(int)
+	if limit <= 0 {
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data), nil
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+	if len(data) > limit {
+		span.SetError(ErrBodyTooLarge)
+		return resp.StatusCode, "", ErrBodyTooLarge
+	}
 	return resp.StatusCode, string(data), nil
 }
@@ -1,15 +1,41 @@
 package global
 
+import "fmt"
+
+const DefaultGroup = "default"
+
 type Node struct {
-	Name  string
-	Role  string
-	HTTP  Transport
-	TCP   Transport
-	Ready bool
+	Name      string
+	Role      string
+	Group     string
+	HTTP      Transport
+	TCP       Transport
+	Ready     bool
+	Drained   bool
+	Unhealthy bool
+	Fresh     bool
+	Syncing   bool
+	Resyncing bool
 }
 
 type Transport struct {
 	Host string
 	Port int
 	Up   bool
+	TLS  bool
+}
+
+func (n Node) ShardGroup() string {
+	if n.Group == "" {
+		return DefaultGroup
+	}
+	return n.Group
+}
+
+func (n Node) URL(path string) string {
+	scheme := "http"
+	if n.HTTP.TLS {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%d%s", scheme, n.HTTP.Host, n.HTTP.Port, path)
 }
@@ -1,27 +1,324 @@
 package logger
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
-const fileName = "elysianGate.log"
+const (
+	fileName      = "elysianGate.log"
+	queueSize     = 8192
+	flushInterval = 200 * time.Millisecond
+)
+
+type Level int32
+
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+type Field struct {
+	Key   string
+	Value any
+}
+
+type Options struct {
+	Output       string
+	Level        Level
+	Format       string
+	MaxSizeBytes int64
+	RotateEvery  time.Duration
+	MaxBackups   int
+}
+
+type entry struct {
+	time   time.Time
+	level  Level
+	msg    string
+	fields []Field
+}
+
+var (
+	queue    = make(chan entry, queueSize)
+	control  = make(chan func(*sink))
+	start    sync.Once
+	minLevel atomic.Int32
+	dropped  atomic.Uint64
+)
+
+func init() {
+	minLevel.Store(int32(InfoLevel))
+}
+
+func F(key string, value any) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Debug(msg string, fields ...Field) { log(DebugLevel, msg, fields) }
+func Info(msg string, fields ...Field)  { log(InfoLevel, msg, fields) }
+func Warn(msg string, fields ...Field)  { log(WarnLevel, msg, fields) }
+func Error(msg string, fields ...Field) { log(ErrorLevel, msg, fields) }
+
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return DebugLevel, nil
+	case "", "info":
+		return InfoLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	}
+	return InfoLevel, fmt.Errorf("unknown log level %q", s)
+}
+
+func (l Level) String() string {
+	switch l {
+	case DebugLevel:
+		return "debug"
+	case WarnLevel:
+		return "warn"
+	case ErrorLevel:
+		return "error"
+	}
+	return "info"
+}
+
+func Configure(opts Options) {
+	ensureStarted()
+	minLevel.Store(int32(opts.Level))
+	done := make(chan struct{})
+	control <- func(s *sink) {
+		s.close()
+		s.opts = normalize(opts)
+		close(done)
+	}
+	<-done
+}
 
-func Info(msg string) {
-	go log("INFO", msg)
+func Sync() {
+	ensureStarted()
+	done := make(chan struct{})
+	control <- func(s *sink) {
+		s.drain()
+		s.flush()
+		close(done)
+	}
+	<-done
 }
 
-func Error(msg string) {
-	go log("ERROR", msg)
+func Dropped() uint64 {
+	return dropped.Load()
+}
+
+func log(level Level, msg string, fields []Field) {
+	if int32(level) < minLevel.Load() {
+		return
+	}
+	ensureStarted()
+	select {
+	case queue <- entry{time: time.Now(), level: level, msg: msg, fields: fields}:
+	default:
+		dropped.Add(1)
+	}
 }
 
-func log(level, msg string) {
-	entry := fmt.Sprintf("[%s] %s %s\n", level, time.Now().Format("2006-01-02 15:04:05"), msg)
-	f, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+func ensureStarted() {
+	start.Do(func() {
+		s := &sink{opts: normalize(Options{Level: InfoLevel})}
+		go s.run()
+	})
+}
+
+func normalize(opts Options) Options {
+	if opts.Output == "" {
+		opts.Output = fileName
+	}
+	if opts.Format != "json" {
+		opts.Format = "logfmt"
+	}
+	return opts
+}
+
+type sink struct {
+	opts   Options
+	file   *os.File
+	w      *bufio.Writer
+	size   int64
+	opened time.Time
+}
+
+func (s *sink) run() {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case e := <-queue:
+			s.write(e)
+		case fn := <-control:
+			fn(s)
+		case <-ticker.C:
+			s.flush()
+		}
+	}
+}
+
+func (s *sink) drain() {
+	for {
+		select {
+		case e := <-queue:
+			s.write(e)
+		default:
+			return
+		}
+	}
+}
+
+func (s *sink) write(e entry) {
+	line := s.format(e)
+	if s.w == nil || s.needsRotation(int64(len(line))) {
+		if err := s.open(); err != nil {
+			return
+		}
+	}
+	n, _ := s.w.WriteString(line)
+	s.size += int64(n)
+}
+
+func (s *sink) format(e entry) string {
+	if s.opts.Format == "json" {
+		m := make(map[string]any, len(e.fields)+3)
+		for _, f := range e.fields {
+			m[f.Key] = jsonValue(f.Value)
+		}
+		m["time"] = e.time.Format(time.RFC3339Nano)
+		m["level"] = e.level.String()
+		m["msg"] = e.msg
+		data, _ := json.Marshal(m)
+		return string(data) + "\n"
+	}
+
+	var b strings.Builder
+	b.WriteString("time=")
+	b.WriteString(e.time.Format(time.RFC3339Nano))
+	b.WriteString(" level=")
+	b.WriteString(e.level.String())
+	b.WriteString(" msg=")
+	b.WriteString(logfmtValue(e.msg))
+	for _, f := range e.fields {
+		b.WriteByte(' ')
+		b.WriteString(f.Key)
+		b.WriteByte('=')
+		b.WriteString(logfmtValue(fmt.Sprint(f.Value)))
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+func (s *sink) needsRotation(next int64) bool {
+	if s.file == nil {
+		return false
+	}
+	if s.opts.MaxSizeBytes > 0 && s.size+next > s.opts.MaxSizeBytes && s.size > 0 {
+		return true
+	}
+	return s.opts.RotateEvery > 0 && time.Since(s.opened) >= s.opts.RotateEvery
+}
+
+func (s *sink) open() error {
+	rotate := s.file != nil
+	s.close()
+
+	switch s.opts.Output {
+	case "stdout":
+		s.w = bufio.NewWriter(os.Stdout)
+		return nil
+	case "stderr":
+		s.w = bufio.NewWriter(os.Stderr)
+		return nil
+	}
+
+	if rotate {
+		s.rotate()
+	}
+	if dir := filepath.Dir(s.opts.Output); dir != "." {
+		os.MkdirAll(dir, 0755)
+	}
+	f, err := os.OpenFile(s.opts.Output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
+		return err
+	}
+	info, _ := f.Stat()
+	s.file = f
+	s.w = bufio.NewWriterSize(f, 64<<10)
+	s.size = 0
+	if info != nil {
+		s.size = info.Size()
+	}
+	s.opened = time.Now()
+	return nil
+}
+
+func (s *sink) rotate() {
+	rotated := fmt.Sprintf("%s.%s", s.opts.Output, time.Now().Format("20060102-150405.000000000"))
+	if err := os.Rename(s.opts.Output, rotated); err != nil {
+		return
+	}
+	if s.opts.MaxBackups <= 0 {
 		return
 	}
-	defer f.Close()
-	f.WriteString(entry)
+	backups, _ := filepath.Glob(s.opts.Output + ".*")
+	sort.Strings(backups)
+	for len(backups) > s.opts.MaxBackups {
+		os.Remove(backups[0])
+		backups = backups[1:]
+	}
+}
+
+func (s *sink) flush() {
+	if s.w != nil {
+		s.w.Flush()
+	}
+}
+
+func (s *sink) close() {
+	s.flush()
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+	s.w = nil
+}
+
+func jsonValue(v any) any {
+	switch val := v.(type) {
+	case error:
+		return val.Error()
+	case fmt.Stringer:
+		return val.String()
+	}
+	return v
+}
+
+func logfmtValue(s string) string {
+	if s == "" {
+		return `""`
+	}
+	if strings.ContainsAny(s, " =\"\t\n") {
+		return strconv.Quote(s)
+	}
+	return s
 }
@@ -0,0 +1,216 @@
+package nodeconfig
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DefaultDir    = "elysiandb/config"
+	defaultFolder = "/tmp/elysiandb-{node}"
+	header        = "# Generated by elysianGate gen-configs from the gateway config. Do not edit.\n"
+)
+
+type CrashRecovery struct {
+	Enabled  bool `yaml:"enabled"`
+	MaxLogMB int  `yaml:"maxLogMB"`
+}
+
+type Store struct {
+	Folder               string        `yaml:"folder"`
+	Shards               int           `yaml:"shards"`
+	FlushIntervalSeconds int           `yaml:"flushIntervalSeconds"`
+	CrashRecovery        CrashRecovery `yaml:"crashRecovery"`
+}
+
+type Listener struct {
+	Enabled bool   `yaml:"enabled"`
+	Host    string `yaml:"host"`
+	Port    int    `yaml:"port"`
+}
+
+type File struct {
+	Store  Store `yaml:"store"`
+	Server struct {
+		HTTP Listener `yaml:"http"`
+		TCP  Listener `yaml:"tcp"`
+	} `yaml:"server"`
+	Log struct {
+		FlushIntervalSeconds int `yaml:"flushIntervalSeconds"`
+	} `yaml:"log"`
+	Stats struct {
+		Enabled bool `yaml:"enabled"`
+	} `yaml:"stats"`
+	API struct {
+		Index struct {
+			Workers int `yaml:"workers"`
+		} `yaml:"index"`
+		Cache struct {
+			Enabled                bool `yaml:"enabled"`
+			CleanupIntervalSeconds int  `yaml:"cleanupIntervalSeconds"`
+		} `yaml:"cache"`
+	} `yaml:"api"`
+}
+
+type Generated struct {
+	Node     string
+	Path     string
+	Changed  bool
+	External bool
+}
+
+func For(cfg configuration.ElysianGateConfig, name string) (File, error) {
+	n, ok := cfg.Nodes[name]
+	if !ok {
+		return File{}, fmt.Errorf("unknown node %s", name)
+	}
+	d := cfg.Gateway.NodeDefaults
+
+	var f File
+	f.Store = Store{
+		Folder:               strings.ReplaceAll(pickString(n.Store.Folder, d.Store.Folder, defaultFolder), "{node}", name),
+		Shards:               pickInt(n.Store.Shards, d.Store.Shards, 512),
+		FlushIntervalSeconds: pickInt(n.Store.FlushIntervalSeconds, d.Store.FlushIntervalSeconds, 5),
+		CrashRecovery: CrashRecovery{
+			Enabled:  pickBool(n.Store.CrashRecovery.Enabled, d.Store.CrashRecovery.Enabled, true),
+			MaxLogMB: pickInt(n.Store.CrashRecovery.MaxLogMB, d.Store.CrashRecovery.MaxLogMB, 100),
+		},
+	}
+	f.Server.HTTP = Listener{Enabled: true, Host: n.HTTP.Host, Port: n.HTTP.Port}
+	f.Server.TCP = Listener{Enabled: true, Host: n.TCP.Host, Port: n.TCP.Port}
+	f.Log.FlushIntervalSeconds = pickInt(n.LogFlushIntervalSeconds, d.LogFlushIntervalSeconds, 5)
+	f.Stats.Enabled = pickBool(n.Stats, d.Stats, false)
+	f.API.Index.Workers = pickInt(n.IndexWorkers, d.IndexWorkers, 4)
+	f.API.Cache.Enabled = pickBool(n.Cache.Enabled, d.Cache.Enabled, true)
+	f.API.Cache.CleanupIntervalSeconds = pickInt(n.Cache.CleanupIntervalSeconds, d.Cache.CleanupIntervalSeconds, 10)
+	return f, nil
+}
+
+// StoreFolder is the store folder a node runs with. Nodes with a hand-written
+// elysiandbConfig file use the folder from that file unless the gateway config
+// sets one.
+func StoreFolder(cfg configuration.ElysianGateConfig, name string) (string, error) {
+	n, ok := cfg.Nodes[name]
+	if !ok {
+		return "", fmt.Errorf("unknown node %s", name)
+	}
+	if n.Store.Folder != "" || n.ElysianDBConfig == "" {
+		f, err := For(cfg, name)
+		return f.Store.Folder, err
+	}
+	data, err := os.ReadFile(n.ElysianDBConfig)
+	if err != nil {
+		return "", fmt.Errorf("node %s: %w", name, err)
+	}
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return "", fmt.Errorf("node %s: %s: %w", name, n.ElysianDBConfig, err)
+	}
+	if f.Store.Folder == "" {
+		return "", fmt.Errorf("node %s: %s has no store.folder", name, n.ElysianDBConfig)
+	}
+	return f.Store.Folder, nil
+}
+
+func PathFor(cfg configuration.ElysianGateConfig, name string) string {
+	if p := cfg.Nodes[name].ElysianDBConfig; p != "" {
+		return p
+	}
+	dir := cfg.Gateway.NodeConfigDir
+	if dir == "" {
+		dir = DefaultDir
+	}
+	return filepath.Join(dir, name+".yaml")
+}
+
+func Render(f File) ([]byte, error) {
+	buf := bytes.NewBufferString(header)
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(f); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), enc.Close()
+}
+
+func Generate(cfg configuration.ElysianGateConfig, write bool) ([]Generated, error) {
+	names := make([]string, 0, len(cfg.Nodes))
+	for name := range cfg.Nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := []Generated{}
+	for _, name := range names {
+		if p := cfg.Nodes[name].ElysianDBConfig; p != "" {
+			out = append(out, Generated{Node: name, Path: p, External: true})
+			continue
+		}
+		f, err := For(cfg, name)
+		if err != nil {
+			return out, err
+		}
+		data, err := Render(f)
+		if err != nil {
+			return out, err
+		}
+		g := Generated{Node: name, Path: PathFor(cfg, name)}
+		current, err := os.ReadFile(g.Path)
+		g.Changed = err != nil || !bytes.Equal(current, data)
+		if write && g.Changed {
+			if err := writeFile(g.Path, data); err != nil {
+				return out, fmt.Errorf("node %s: %w", name, err)
+			}
+		}
+		out = append(out, g)
+	}
+	return out, nil
+}
+
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func pickString(node string, defaults string, def string) string {
+	if node != "" {
+		return node
+	}
+	if defaults != "" {
+		return defaults
+	}
+	return def
+}
+
+func pickInt(node int, defaults int, def int) int {
+	if node > 0 {
+		return node
+	}
+	if defaults > 0 {
+		return defaults
+	}
+	return def
+}
+
+func pickBool(node *bool, defaults *bool, def bool) bool {
+	if node != nil {
+		return *node
+	}
+	if defaults != nil {
+		return *defaults
+	}
+	return def
+}
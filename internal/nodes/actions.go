@@ -0,0 +1,140 @@
+package nodes
+
+import (
+	"fmt"
+
+	"github.com/elysiandb/elysian-gate/internal/events"
+	"github.com/elysiandb/elysian-gate/internal/global"
+	"github.com/elysiandb/elysian-gate/internal/logger"
+)
+
+func (c *Cluster) Drain(name string, drained bool) error {
+	var group string
+	err := c.updateNode(name, func(n *global.Node) error {
+		n.Drained = drained
+		group = n.ShardGroup()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info("node drain changed", logger.F("node", name), logger.F("drained", drained))
+	if drained {
+		events.Publish(events.NodeDrained, name, group, nil)
+	} else {
+		events.Publish(events.NodeUndrained, name, group, nil)
+	}
+	return nil
+}
+
+func (c *Cluster) Resync(name string) error {
+	if err := c.markForResync(name); err != nil {
+		return err
+	}
+	go c.ResyncSlave(name)
+	return nil
+}
+
+func (c *Cluster) markForResync(name string) error {
+	err := c.updateNode(name, func(n *global.Node) error {
+		if n.Role != "slave" {
+			return fmt.Errorf("node %s is a %s, only slaves can be resynced", name, n.Role)
+		}
+		n.Ready = false
+		n.Fresh = false
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info("node resync requested", logger.F("node", name))
+	return nil
+}
+
+func (c *Cluster) Promote(name string) error {
+	var group, previous string
+	err := c.update(func(list []global.Node) error {
+		n := find(list, name)
+		if n == nil {
+			return fmt.Errorf("%w %q", ErrUnknownNode, name)
+		}
+		if n.Role != "slave" {
+			return fmt.Errorf("node %s is already a %s", name, n.Role)
+		}
+		if !n.Ready || !n.HTTP.Up {
+			return fmt.Errorf("node %s is not ready, cannot promote it", name)
+		}
+
+		group = n.ShardGroup()
+		for i := range list {
+			old := &list[i]
+			if old.Role == "master" && old.ShardGroup() == group {
+				previous = old.Name
+				old.Role = "slave"
+				old.Ready = false
+				old.Fresh = false
+			}
+		}
+		n.Role = "master"
+		n.Ready = true
+		n.Fresh = true
+		n.Syncing = false
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Warn("node promoted to master", logger.F("node", name), logger.F("group", group))
+	events.Publish(events.NodePromoted, name, group, map[string]any{"previous_master": previous})
+	return nil
+}
+
+func (c *Cluster) Hold(name string) error {
+	err := c.updateNode(name, func(n *global.Node) error {
+		switch {
+		case n.Role != "slave":
+			return fmt.Errorf("node %s is a %s, only slaves can be held", name, n.Role)
+		case n.Resyncing:
+			return fmt.Errorf("node %s is already being resynced", name)
+		}
+		n.Ready = false
+		n.Fresh = false
+		n.Resyncing = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info("node held out of rotation", logger.F("node", name))
+	return nil
+}
+
+func (c *Cluster) ResyncHeld(name string) error {
+	var node, master global.Node
+	err := c.update(func(list []global.Node) error {
+		n := find(list, name)
+		switch {
+		case n == nil:
+			return fmt.Errorf("%w %q", ErrUnknownNode, name)
+		case n.Role != "slave" || !n.Resyncing:
+			return fmt.Errorf("node %s is not held", name)
+		}
+		for _, m := range list {
+			if m.Role == "master" && m.ShardGroup() == n.ShardGroup() {
+				master = m
+			}
+		}
+		node = *n
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if master.Name == "" {
+		err = fmt.Errorf("no master found in group %s", node.ShardGroup())
+		c.endResync(name, err)
+		return err
+	}
+	return c.resync(node, master)
+}
@@ -0,0 +1,175 @@
+package nodes
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/elysiandb/elysian-gate/internal/global"
+)
+
+var (
+	ErrUnknownNode   = errors.New("unknown node")
+	errResyncRunning = errors.New("resync already running")
+)
+
+type Snapshot struct {
+	Version uint64
+	Nodes   []global.Node
+}
+
+type Cluster struct {
+	mu    sync.Mutex
+	state atomic.Pointer[Snapshot]
+}
+
+func NewCluster(list []global.Node) *Cluster {
+	c := &Cluster{}
+	c.state.Store(&Snapshot{Nodes: append([]global.Node(nil), list...)})
+	return c
+}
+
+func (c *Cluster) Snapshot() *Snapshot {
+	if s := c.state.Load(); s != nil {
+		return s
+	}
+	return &Snapshot{}
+}
+
+func (s *Snapshot) Node(name string) (global.Node, bool) {
+	for _, n := range s.Nodes {
+		if n.Name == name {
+			return n, true
+		}
+	}
+	return global.Node{}, false
+}
+
+func (s *Snapshot) Master(group string) (global.Node, bool) {
+	for _, n := range s.Nodes {
+		if n.Role == "master" && n.ShardGroup() == group {
+			return n, true
+		}
+	}
+	return global.Node{}, false
+}
+
+func (s *Snapshot) Groups() []string {
+	seen := map[string]bool{}
+	groups := []string{}
+	for _, n := range s.Nodes {
+		g := n.ShardGroup()
+		if !seen[g] {
+			seen[g] = true
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+func (c *Cluster) update(fn func(list []global.Node) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current := c.Snapshot()
+	next := append([]global.Node(nil), current.Nodes...)
+	if err := fn(next); err != nil {
+		return err
+	}
+	c.state.Store(&Snapshot{Version: current.Version + 1, Nodes: next})
+	return nil
+}
+
+func (c *Cluster) updateNode(name string, fn func(n *global.Node) error) error {
+	return c.update(func(list []global.Node) error {
+		n := find(list, name)
+		if n == nil {
+			return fmt.Errorf("%w %q", ErrUnknownNode, name)
+		}
+		return fn(n)
+	})
+}
+
+func find(list []global.Node, name string) *global.Node {
+	for i := range list {
+		if list[i].Name == name {
+			return &list[i]
+		}
+	}
+	return nil
+}
+
+func (c *Cluster) MarkAllDirty() {
+	dirty := true
+	for _, n := range c.Snapshot().Nodes {
+		if n.Fresh {
+			dirty = false
+		}
+	}
+	if dirty {
+		return
+	}
+	c.update(func(list []global.Node) error {
+		for i := range list {
+			list[i].Fresh = false
+		}
+		return nil
+	})
+}
+
+func (c *Cluster) BeginSync(name string) bool {
+	return c.updateNode(name, func(n *global.Node) error {
+		if n.Role != "slave" || !n.Ready || n.Syncing {
+			return errors.New("not syncable")
+		}
+		n.Syncing = true
+		return nil
+	}) == nil
+}
+
+func (c *Cluster) EndSync(name string, ok bool) {
+	c.updateNode(name, func(n *global.Node) error {
+		n.Syncing = false
+		n.Fresh = ok
+		return nil
+	})
+}
+
+func (c *Cluster) beginResync(name string) (global.Node, global.Node, error) {
+	var node, master global.Node
+	err := c.update(func(list []global.Node) error {
+		n := find(list, name)
+		switch {
+		case n == nil:
+			return fmt.Errorf("%w %q", ErrUnknownNode, name)
+		case n.Resyncing:
+			return errResyncRunning
+		case n.Role != "slave" || n.Ready:
+			return errResyncRunning
+		}
+		for _, m := range list {
+			if m.Role == "master" && m.ShardGroup() == n.ShardGroup() {
+				master = m
+			}
+		}
+		if master.Name == "" {
+			return fmt.Errorf("no master found in group %s", n.ShardGroup())
+		}
+		n.Resyncing = true
+		node = *n
+		return nil
+	})
+	return node, master, err
+}
+
+func (c *Cluster) endResync(name string, err error) {
+	c.updateNode(name, func(n *global.Node) error {
+		n.Resyncing = false
+		if err == nil && n.Role == "slave" {
+			n.Ready = true
+			n.Fresh = true
+		}
+		return nil
+	})
+}
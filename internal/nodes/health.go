@@ -0,0 +1,217 @@
+package nodes
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/forward"
+	"github.com/elysiandb/elysian-gate/internal/global"
+)
+
+const (
+	defaultHealthInterval = 2 * time.Second
+	defaultHealthTimeout  = 2 * time.Second
+	defaultHealthRise     = 2
+	defaultHealthFall     = 3
+	minHealthTick         = 50 * time.Millisecond
+	defaultMaxParallel    = 8
+)
+
+type HealthCheck struct {
+	Interval    time.Duration
+	Timeout     time.Duration
+	Rise        int
+	Fall        int
+	DeepCheck   bool
+	CanaryKey   string
+	ExpectValue string
+}
+
+type threshold struct {
+	known     bool
+	up        bool
+	successes int
+	failures  int
+}
+
+func (t *threshold) observe(ok bool, rise int, fall int) bool {
+	if !t.known {
+		t.known, t.up = true, ok
+		return t.up
+	}
+	if ok {
+		t.successes++
+		t.failures = 0
+		if !t.up && t.successes >= rise {
+			t.up = true
+		}
+	} else {
+		t.failures++
+		t.successes = 0
+		if t.up && t.failures >= fall {
+			t.up = false
+		}
+	}
+	return t.up
+}
+
+type probe struct {
+	node    global.Node
+	check   HealthCheck
+	httpOK  bool
+	tcpOK   bool
+	deepOK  bool
+	deepRan bool
+}
+
+func probeAll(probes []probe) []probe {
+	if len(probes) == 0 {
+		return probes
+	}
+	parallel := configuration.Config.Gateway.HealthCheck.MaxParallel
+	if parallel <= 0 {
+		parallel = defaultMaxParallel
+	}
+
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i := range probes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(p *probe) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			p.run()
+		}(&probes[i])
+	}
+	wg.Wait()
+	return probes
+}
+
+func (p *probe) run() {
+	p.httpOK = pingHTTP(p.node, p.check.Timeout)
+	p.tcpOK = pingTCP(p.node.TCP.Host, p.node.TCP.Port, p.check.Timeout)
+	if p.check.DeepCheck && p.httpOK && p.tcpOK {
+		p.deepRan = true
+		p.deepOK = deepCheck(p.node, p.check)
+	}
+}
+
+type nodeHealth struct {
+	next time.Time
+	http threshold
+	tcp  threshold
+	deep threshold
+}
+
+var health = struct {
+	sync.Mutex
+	byNode map[string]*nodeHealth
+}{byNode: map[string]*nodeHealth{}}
+
+func healthOf(name string) *nodeHealth {
+	health.Lock()
+	defer health.Unlock()
+	h := health.byNode[name]
+	if h == nil {
+		h = &nodeHealth{}
+		health.byNode[name] = h
+	}
+	return h
+}
+
+func HealthCheckFor(name string) HealthCheck {
+	gw := configuration.Config.Gateway.HealthCheck
+	node := configuration.Config.Nodes[name].HealthCheck
+
+	hc := HealthCheck{
+		Interval: pickDuration(node.IntervalMs, gw.IntervalMs, defaultHealthInterval),
+		Timeout:  pickDuration(node.TimeoutMs, gw.TimeoutMs, defaultHealthTimeout),
+		Rise:     pickInt(node.Rise, gw.Rise, defaultHealthRise),
+		Fall:     pickInt(node.Fall, gw.Fall, defaultHealthFall),
+	}
+	deep := gw.DeepCheck
+	if node.DeepCheck.Enabled || node.DeepCheck.Key != "" {
+		deep = node.DeepCheck
+	}
+	if deep.Enabled && deep.Key != "" {
+		hc.DeepCheck, hc.CanaryKey, hc.ExpectValue = true, deep.Key, deep.ExpectValue
+	}
+	return hc
+}
+
+func pickDuration(node int, gateway int, def time.Duration) time.Duration {
+	if node > 0 {
+		return time.Duration(node) * time.Millisecond
+	}
+	if gateway > 0 {
+		return time.Duration(gateway) * time.Millisecond
+	}
+	return def
+}
+
+func pickInt(node int, gateway int, def int) int {
+	if node > 0 {
+		return node
+	}
+	if gateway > 0 {
+		return gateway
+	}
+	return def
+}
+
+func (c *Cluster) healthTick() time.Duration {
+	tick := defaultHealthInterval
+	for _, n := range c.Snapshot().Nodes {
+		if i := HealthCheckFor(n.Name).Interval; i < tick {
+			tick = i
+		}
+	}
+	if tick < minHealthTick {
+		tick = minHealthTick
+	}
+	return tick
+}
+
+func pingTCP(host string, port int, timeout time.Duration) bool {
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", host, port), timeout)
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	conn.Write([]byte("PING\n"))
+	resp, _ := bufio.NewReader(conn).ReadString('\n')
+	return strings.TrimSpace(resp) == "PONG"
+}
+
+func pingHTTP(n global.Node, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	status, _, err := forward.ForwardRequestContext(ctx, "GET", n.URL("/health"), "")
+	return err == nil && status == 200
+}
+
+func deepCheck(n global.Node, hc HealthCheck) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), hc.Timeout)
+	defer cancel()
+	status, body, err := forward.ForwardRequestContext(ctx, "GET", n.URL("/kv/"+url.PathEscape(hc.CanaryKey)), "")
+	if err != nil {
+		return false
+	}
+	if hc.ExpectValue == "" {
+		return status == 200 || status == 404
+	}
+	var kv struct {
+		Value string `json:"value"`
+	}
+	return status == 200 && json.Unmarshal([]byte(body), &kv) == nil && kv.Value == hc.ExpectValue
+}
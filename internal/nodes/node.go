@@ -1,42 +1,42 @@
 package nodes
 
 import (
-	"bufio"
 	"fmt"
-	"net"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strings"
-	"sync"
 	"time"
 
+	"github.com/elysiandb/elysian-gate/internal/breaker"
 	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/events"
+	"github.com/elysiandb/elysian-gate/internal/forward"
 	"github.com/elysiandb/elysian-gate/internal/global"
 	"github.com/elysiandb/elysian-gate/internal/logger"
+	"github.com/elysiandb/elysian-gate/internal/nodeconfig"
 	"github.com/elysiandb/elysian-gate/internal/replication"
-	"github.com/elysiandb/elysian-gate/internal/state"
+	"github.com/elysiandb/elysian-gate/internal/tlsconfig"
 )
 
-type Cluster struct {
-	Nodes []global.Node
-	mu    sync.Mutex
-}
-
 var ElysianCluster *Cluster
 
-func Init() {
-	ElysianCluster = &Cluster{}
+func Init() error {
 	cfg := configuration.Config
+	list := []global.Node{}
 	for name, nodeCfg := range cfg.Nodes {
+		group := nodeCfg.Group
+		if group == "" {
+			group = global.DefaultGroup
+		}
 		n := global.Node{
-			Name: name,
-			Role: nodeCfg.Role,
+			Name:  name,
+			Role:  nodeCfg.Role,
+			Group: group,
 			HTTP: global.Transport{
 				Host: nodeCfg.HTTP.Host,
 				Port: nodeCfg.HTTP.Port,
 				Up:   false,
+				TLS:  nodeCfg.HTTP.TLS.Enabled,
 			},
 			TCP: global.Transport{
 				Host: nodeCfg.TCP.Host,
@@ -49,173 +49,196 @@ func Init() {
 		} else {
 			n.Ready = true
 		}
-		ElysianCluster.Nodes = append(ElysianCluster.Nodes, n)
+		if n.HTTP.TLS {
+			tlsCfg, err := tlsconfig.ClientConfig(nodeCfg.HTTP.TLS)
+			if err != nil {
+				return fmt.Errorf("invalid TLS settings for node %s: %w", name, err)
+			}
+			forward.RegisterTLS(fmt.Sprintf("%s:%d", n.HTTP.Host, n.HTTP.Port), tlsCfg)
+		}
+		list = append(list, n)
 	}
+	ElysianCluster = NewCluster(list)
 
 	if cfg.Gateway.StartsNodes {
-		logger.Info(fmt.Sprintf("Starting %d ElysianDB nodes...\n", len(cfg.Nodes)))
-		for _, n := range ElysianCluster.Nodes {
+		if _, err := nodeconfig.Generate(cfg, true); err != nil {
+			return fmt.Errorf("generating ElysianDB configs: %w", err)
+		}
+		logger.Info(fmt.Sprintf("Starting %d ElysianDB nodes...", len(cfg.Nodes)))
+		for _, n := range list {
 			bin := filepath.Join("elysiandb", "bin", "elysiandb")
-			cmd := exec.Command(bin, "--http", fmt.Sprintf("%s:%d", n.HTTP.Host, n.HTTP.Port), "--tcp", fmt.Sprintf("%s:%d", n.TCP.Host, n.TCP.Port))
+			cmd := exec.Command(bin, "--config", nodeconfig.PathFor(cfg, n.Name))
 			cmd.Stdout = os.Stdout
 			cmd.Stderr = os.Stderr
 			if err := cmd.Start(); err != nil {
-				logger.Error(fmt.Sprintf("Failed to start node %s: %v\n", n.Name, err))
+				logger.Error(fmt.Sprintf("Failed to start node %s: %v", n.Name, err))
 				continue
 			}
-			logger.Info(fmt.Sprintf(" → Node %s started on HTTP %s:%d | TCP %s:%d\n", n.Name, n.HTTP.Host, n.HTTP.Port, n.TCP.Host, n.TCP.Port))
+			logger.Info(fmt.Sprintf(" → Node %s started on HTTP %s:%d | TCP %s:%d", n.Name, n.HTTP.Host, n.HTTP.Port, n.TCP.Host, n.TCP.Port))
 			time.Sleep(200 * time.Millisecond)
 		}
-		logger.Info("\nAll nodes are up and running!")
+		logger.Info("All nodes are up and running!")
 	}
+	return nil
 }
 
 func (c *Cluster) monitor() {
-	ticker := time.NewTicker(2 * time.Second)
+	ticker := time.NewTicker(c.healthTick())
 	defer ticker.Stop()
 
-	prevSnapshot := ""
-
 	for range ticker.C {
-		changed := c.refreshStatuses()
-		snapshot := c.clusterSnapshot()
-
-		if changed || snapshot != prevSnapshot {
-			clearScreen()
-			fmt.Println("\033[1;36m╔══════════════════════════════════════════════╗")
-			fmt.Println("║               ElysianGate Launcher           ║")
-			fmt.Println("╚══════════════════════════════════════════════╝\033[0m")
-			fmt.Println(time.Now().Format("15:04:05"))
-			fmt.Print(snapshot)
-			prevSnapshot = snapshot
-		}
+		c.refreshStatuses(false)
 	}
 }
 
-func (c *Cluster) refreshStatuses() bool {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+func (c *Cluster) CheckHealth() bool {
+	return c.refreshStatuses(true)
+}
+
+func (c *Cluster) refreshStatuses(force bool) bool {
+	results := probeAll(c.dueProbes(force))
+	if len(results) == 0 {
+		return false
+	}
 
 	changed := false
-	for i := range c.Nodes {
-		httpUp := pingHTTP(c.Nodes[i].HTTP.Host, c.Nodes[i].HTTP.Port)
-		tcpUp := pingTCP(c.Nodes[i].TCP.Host, c.Nodes[i].TCP.Port)
+	resync := []string{}
+	published := []events.Event{}
+	c.update(func(list []global.Node) error {
+		for _, r := range results {
+			n := find(list, r.node.Name)
+			if n == nil {
+				continue
+			}
+			h := healthOf(n.Name)
+
+			httpUp := h.http.observe(r.httpOK, r.check.Rise, r.check.Fall)
+			tcpUp := h.tcp.observe(r.tcpOK, r.check.Rise, r.check.Fall)
+			live := httpUp && tcpUp
+
+			unhealthy := false
+			switch {
+			case live && r.deepRan:
+				unhealthy = !h.deep.observe(r.deepOK, r.check.Rise, r.check.Fall)
+			case live && r.check.DeepCheck:
+				unhealthy = n.Unhealthy
+			}
 
-		prevHTTP := c.Nodes[i].HTTP.Up
-		prevTCP := c.Nodes[i].TCP.Up
-		prevReady := c.Nodes[i].Ready
+			prevReady := n.Ready
+			switch wasLive := n.HTTP.Up && n.TCP.Up; {
+			case live && !wasLive:
+				published = append(published, events.Event{Type: events.NodeUp, Node: n.Name, Group: n.ShardGroup()})
+			case !live && wasLive:
+				published = append(published, events.Event{Type: events.NodeDown, Node: n.Name, Group: n.ShardGroup()})
+			}
+			switch {
+			case unhealthy && !n.Unhealthy:
+				published = append(published, events.Event{Type: events.NodeUnhealthy, Node: n.Name, Group: n.ShardGroup()})
+			case !unhealthy && n.Unhealthy:
+				published = append(published, events.Event{Type: events.NodeHealthy, Node: n.Name, Group: n.ShardGroup()})
+			}
+			if n.HTTP.Up != httpUp || n.TCP.Up != tcpUp || n.Unhealthy != unhealthy {
+				n.HTTP.Up, n.TCP.Up, n.Unhealthy = httpUp, tcpUp, unhealthy
+				changed = true
+			}
 
-		if prevHTTP != httpUp {
-			c.Nodes[i].HTTP.Up = httpUp
-			changed = true
-		}
-		if prevTCP != tcpUp {
-			c.Nodes[i].TCP.Up = tcpUp
-			changed = true
-		}
+			if !live {
+				breaker.Trip(n.Name)
+				if n.Role == "slave" && n.Ready {
+					n.Ready = false
+					n.Fresh = false
+				}
+			} else {
+				if breaker.GetState(n.Name) == breaker.Open {
+					breaker.Probe(n.Name)
+					changed = true
+				}
+				if n.Role == "slave" && !prevReady && !n.Resyncing {
+					resync = append(resync, n.Name)
+				}
+			}
 
-		if !httpUp || !tcpUp {
-			if c.Nodes[i].Role == "slave" && c.Nodes[i].Ready {
-				c.Nodes[i].Ready = false
+			if prevReady != n.Ready {
 				changed = true
 			}
-		} else {
-			if c.Nodes[i].Role == "slave" && !prevReady {
-				go resyncSlaveFromMaster(&c.Nodes[i])
-			}
 		}
+		return nil
+	})
 
-		if prevReady != c.Nodes[i].Ready {
-			changed = true
-		}
+	for _, e := range published {
+		events.Publish(e.Type, e.Node, e.Group, nil)
+	}
+	for _, name := range resync {
+		go c.ResyncSlave(name)
 	}
 	return changed
 }
 
-func (c *Cluster) clusterSnapshot() string {
+func (c *Cluster) dueProbes(force bool) []probe {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	var out strings.Builder
-	for _, n := range c.Nodes {
-		httpState := "🔴 HTTP down"
-		if n.HTTP.Up {
-			httpState = "🟢 HTTP up"
-		}
-		tcpState := "🔴 TCP down"
-		if n.TCP.Up {
-			tcpState = "🟢 TCP up"
-		}
-		readyState := "🔴 NotReady"
-		if n.Ready {
-			readyState = "🟢 Ready"
+	now := time.Now()
+	due := []probe{}
+	for _, n := range c.Snapshot().Nodes {
+		hc := HealthCheckFor(n.Name)
+		h := healthOf(n.Name)
+		if !force && now.Before(h.next) {
+			continue
 		}
-		out.WriteString(fmt.Sprintf(
-			"Node %s (%s) [HTTP %s:%d | TCP %s:%d] : %s | %s | %s\n",
-			n.Name, n.Role,
-			n.HTTP.Host, n.HTTP.Port,
-			n.TCP.Host, n.TCP.Port,
-			httpState, tcpState, readyState,
-		))
+		h.next = now.Add(hc.Interval)
+		due = append(due, probe{node: n, check: hc})
 	}
-	return out.String()
-}
-
-func clearScreen() {
-	fmt.Print("\033[H\033[2J")
+	return due
 }
 
-func resyncSlaveFromMaster(n *global.Node) {
-	master := GetMasterNode()
-	if master == nil {
-		logger.Error(fmt.Sprintf("No master found, cannot replicate to %s", n.Name))
-		return
+func (c *Cluster) ResyncSlave(name string) error {
+	n, master, err := c.beginResync(name)
+	if err == errResyncRunning {
+		return nil
 	}
-	if n.Ready {
-		return
+	if err != nil {
+		logger.Error("Cannot replicate node", logger.F("node", name), logger.F("error", err))
+		return err
 	}
+	return c.resync(n, master)
+}
 
-	logger.Info(fmt.Sprintf("Replicating master → %s ...", n.Name))
-	if err := replication.ReplicateMasterToNode(master, n); err != nil {
-		logger.Error(fmt.Sprintf("Replication failed for %s: %v", n.Name, err))
-		return
+func (c *Cluster) resync(n global.Node, master global.Node) error {
+	logger.Info("Replicating master", logger.F("node", n.Name), logger.F("master", master.Name))
+	events.Publish(events.ResyncStarted, n.Name, n.ShardGroup(), map[string]any{"master": master.Name})
+	start := time.Now()
+	err := replication.ReplicateMasterToNode(&master, &n)
+	c.endResync(n.Name, err)
+	if err != nil {
+		logger.Error("Replication failed", logger.F("node", n.Name), logger.F("error", err))
+		events.Publish(events.ResyncFailed, n.Name, n.ShardGroup(), map[string]any{"master": master.Name, "error": err.Error()})
+		return err
 	}
-	n.Ready = true
+	events.Publish(events.ResyncFinished, n.Name, n.ShardGroup(), map[string]any{"master": master.Name, "duration_ms": time.Since(start).Milliseconds()})
 
-	state.SetSlaveAsFresh(n)
-
-	logger.Info(fmt.Sprintf("Node %s replication complete, now marked as Ready & Fresh", n.Name))
+	logger.Info("Node replication complete, now marked as Ready & Fresh", logger.F("node", n.Name))
+	return nil
 }
 
 func GetMasterNode() *global.Node {
-	for i := range ElysianCluster.Nodes {
-		if ElysianCluster.Nodes[i].Role == "master" {
-			return &ElysianCluster.Nodes[i]
+	for _, n := range ElysianCluster.Snapshot().Nodes {
+		if n.Role == "master" {
+			return &n
 		}
 	}
 	return nil
 }
 
-func pingTCP(host string, port int) bool {
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", host, port), 2*time.Second)
-	if err != nil {
-		return false
+func GetGroupMasterNode(group string) *global.Node {
+	if n, ok := ElysianCluster.Snapshot().Master(group); ok {
+		return &n
 	}
-	defer conn.Close()
-	conn.Write([]byte("PING\n"))
-	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
-	resp, _ := bufio.NewReader(conn).ReadString('\n')
-	return strings.TrimSpace(resp) == "PONG"
+	return nil
 }
 
-func pingHTTP(host string, port int) bool {
-	client := http.Client{Timeout: 2 * time.Second}
-	resp, err := client.Get(fmt.Sprintf("http://%s:%d/health", host, port))
-	if err != nil {
-		return false
-	}
-	defer resp.Body.Close()
-	return resp.StatusCode == 200
+func (c *Cluster) Groups() []string {
+	return c.Snapshot().Groups()
 }
 
 func (c *Cluster) StartMonitoring() {
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/logger"
+)
+
+const defaultPersistInterval = 30 * time.Second
+
+type quotaSnapshot struct {
+	Day    string           `json:"day"`
+	Counts map[string]int64 `json:"counts"`
+}
+
+func Init() error {
+	cfg := configuration.Config.Gateway.RateLimit
+	if !cfg.Enabled || cfg.PersistFile == "" {
+		return nil
+	}
+	if err := LoadQuotas(cfg.PersistFile); err != nil {
+		return err
+	}
+
+	interval := defaultPersistInterval
+	if cfg.PersistIntervalSeconds > 0 {
+		interval = time.Duration(cfg.PersistIntervalSeconds) * time.Second
+	}
+	go func() {
+		for range time.Tick(interval) {
+			if err := SaveQuotas(cfg.PersistFile); err != nil {
+				logger.Error(fmt.Sprintf("failed to persist quotas: %v", err))
+			}
+		}
+	}()
+	return nil
+}
+
+func LoadQuotas(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var snap quotaSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("invalid quota file %s: %w", path, err)
+	}
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	limiter.rollDay(now())
+	if snap.Day == limiter.day && snap.Counts != nil {
+		limiter.counts = snap.Counts
+	}
+	return nil
+}
+
+func SaveQuotas(path string) error {
+	limiter.mu.Lock()
+	limiter.rollDay(now())
+	snap := quotaSnapshot{Day: limiter.day, Counts: make(map[string]int64, len(limiter.counts))}
+	for k, v := range limiter.counts {
+		snap.Counts[k] = v
+	}
+	limiter.mu.Unlock()
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
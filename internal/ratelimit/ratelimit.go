@@ -0,0 +1,213 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/auth"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/logger"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	ClassRead  = "read"
+	ClassWrite = "write"
+
+	perClient = "*"
+
+	sweepInterval = time.Minute
+	idleRefills   = 3
+)
+
+type Decision struct {
+	Allowed    bool
+	RetryAfter time.Duration
+	Reason     string
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	refill time.Duration
+}
+
+type limiterState struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+	day     string
+	counts  map[string]int64
+}
+
+var limiter = &limiterState{
+	buckets: map[string]*bucket{},
+	counts:  map[string]int64{},
+}
+
+var now = time.Now
+
+// SetClock replaces the limiter's clock; nil restores time.Now.
+func SetClock(clock func() time.Time) {
+	if clock == nil {
+		clock = time.Now
+	}
+	now = clock
+}
+
+func Enabled() bool {
+	return configuration.Config.Gateway.RateLimit.Enabled
+}
+
+func Reset() {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	limiter.buckets = map[string]*bucket{}
+	limiter.counts = map[string]int64{}
+	limiter.day = ""
+	limiter.swept = time.Time{}
+}
+
+func Buckets() int {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	return len(limiter.buckets)
+}
+
+func Admit(ctx *fasthttp.RequestCtx, entity string, class string) bool {
+	if !Enabled() {
+		return true
+	}
+
+	client := ClientID(ctx)
+	d := Check(client, entity, class)
+	if d.Allowed {
+		return true
+	}
+
+	seconds := int(math.Ceil(d.RetryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	logger.Warn("rate limited", logger.F("client", client), logger.F("entity", entity), logger.F("class", class), logger.F("reason", d.Reason))
+
+	data, _ := json.Marshal(map[string]string{"error": "rate limited", "detail": d.Reason})
+	ctx.Response.Header.Set("Retry-After", strconv.Itoa(seconds))
+	ctx.SetContentType("application/json")
+	ctx.SetStatusCode(fasthttp.StatusTooManyRequests)
+	ctx.SetBody(data)
+	return false
+}
+
+func ClientID(ctx *fasthttp.RequestCtx) string {
+	if p := auth.FromContext(ctx); p != nil && p.Name != "" {
+		return p.Name
+	}
+	return ctx.RemoteIP().String()
+}
+
+func Check(client string, entity string, class string) Decision {
+	rules := configuration.Config.Gateway.RateLimit.Rules
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	t := now()
+	limiter.rollDay(t)
+	limiter.sweep(t)
+
+	type hit struct {
+		key  string
+		rule configuration.RateLimitRule
+	}
+	hits := []hit{}
+	for _, rule := range rules {
+		if !matches(rule.Client, client) || !matches(rule.Entity, entity) || (rule.Class != "" && rule.Class != class) {
+			continue
+		}
+		key := fmt.Sprintf("%s|%s|%s", rule.Key(), bucketPart(rule.Client, client), bucketPart(rule.Entity, entity))
+
+		if rule.Rate > 0 {
+			b := limiter.refill(key, rule, t)
+			if b.tokens < 1 {
+				wait := time.Duration((1 - b.tokens) / rule.Rate * float64(time.Second))
+				return Decision{RetryAfter: wait, Reason: fmt.Sprintf("rate of %g %s requests per second exceeded", rule.Rate, describe(rule.Class))}
+			}
+		}
+		if rule.Daily > 0 && limiter.counts[key] >= rule.Daily {
+			midnight := time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			return Decision{RetryAfter: midnight.Sub(t), Reason: fmt.Sprintf("daily quota of %d %s requests exhausted", rule.Daily, describe(rule.Class))}
+		}
+		hits = append(hits, hit{key: key, rule: rule})
+	}
+
+	for _, h := range hits {
+		if h.rule.Rate > 0 {
+			limiter.buckets[h.key].tokens--
+		}
+		if h.rule.Daily > 0 {
+			limiter.counts[h.key]++
+		}
+	}
+	return Decision{Allowed: true}
+}
+
+func (l *limiterState) refill(key string, rule configuration.RateLimitRule, t time.Time) *bucket {
+	burst := float64(rule.Burst)
+	if burst < 1 {
+		burst = math.Max(1, rule.Rate)
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: t, refill: time.Duration(burst / rule.Rate * float64(time.Second))}
+		l.buckets[key] = b
+		return b
+	}
+	b.tokens = math.Min(burst, b.tokens+t.Sub(b.last).Seconds()*rule.Rate)
+	b.last = t
+	return b
+}
+
+// sweep drops buckets that have been idle for a few refill intervals. They are
+// full by then, so a fresh bucket behaves the same.
+func (l *limiterState) sweep(t time.Time) {
+	if t.Sub(l.swept) < sweepInterval {
+		return
+	}
+	l.swept = t
+	for key, b := range l.buckets {
+		if t.Sub(b.last) >= idleRefills*b.refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func (l *limiterState) rollDay(t time.Time) {
+	day := t.UTC().Format("2006-01-02")
+	if l.day != day {
+		l.day = day
+		l.counts = map[string]int64{}
+	}
+}
+
+func matches(pattern string, value string) bool {
+	return pattern == "" || pattern == perClient || pattern == value
+}
+
+func bucketPart(pattern string, value string) string {
+	if pattern == "" {
+		return ""
+	}
+	return value
+}
+
+func describe(class string) string {
+	if class == "" {
+		return "total"
+	}
+	return class
+}
@@ -1,6 +1,7 @@
 package replication
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -9,12 +10,20 @@ import (
 	"github.com/elysiandb/elysian-gate/internal/forward"
 	"github.com/elysiandb/elysian-gate/internal/global"
 	"github.com/elysiandb/elysian-gate/internal/logger"
+	"github.com/elysiandb/elysian-gate/internal/tracing"
 )
 
 func ReplicateMasterToNode(master *global.Node, node *global.Node) error {
-	types, err := listNodeEntityTypes(master)
-	logger.Info(fmt.Sprintf("Replicating the following entity types from master to node: %v\n", types))
+	ctx, span := tracing.Start(context.Background(), "replication.ReplicateMasterToNode",
+		tracing.Attr("elysian.master", master.Name),
+		tracing.Attr("elysian.node", node.Name),
+	)
+	defer span.End()
+
+	types, err := ListEntityTypes(ctx, master)
+	logger.Info("Replicating entity types from master to node", logger.F("node", node.Name), logger.F("entities", strings.Join(types, ",")))
 	if err != nil {
+		span.SetError(err)
 		return err
 	}
 	for _, raw := range types {
@@ -22,30 +31,61 @@ func ReplicateMasterToNode(master *global.Node, node *global.Node) error {
 		if t == "" {
 			continue
 		}
-		if err := resetNodeEntity(node, t); err != nil {
+		if err := replicateEntity(ctx, master, node, t); err != nil {
+			span.SetError(err)
 			return err
 		}
-		entities, err := listNodeEntities(master, t)
-		if err != nil {
+	}
+	return nil
+}
+
+func replicateEntity(ctx context.Context, master *global.Node, node *global.Node, entityType string) error {
+	ctx, span := tracing.Start(ctx, "replication.ReplicateEntity", tracing.Attr("elysian.entity", entityType))
+	defer span.End()
+
+	entities, err := ListEntities(ctx, master, entityType)
+	if err != nil {
+		span.SetError(err)
+		return err
+	}
+	span.SetAttributes(tracing.Attr("elysian.documents", len(entities)))
+	if err := ImportEntities(ctx, node, entityType, entities); err != nil {
+		span.SetError(err)
+		return err
+	}
+	return nil
+}
+
+func ImportEntities(ctx context.Context, node *global.Node, entityType string, entities []map[string]interface{}) error {
+	if err := resetNodeEntity(ctx, node, entityType); err != nil {
+		return err
+	}
+	for _, entity := range entities {
+		if err := sendEntityToNode(ctx, entity, node, entityType); err != nil {
 			return err
 		}
-		for _, entity := range entities {
-			if err := sendEntityToNode(entity, node, t); err != nil {
-				return err
-			}
-		}
 	}
 	return nil
 }
 
-func listNodeEntityTypes(node *global.Node) ([]string, error) {
-	urlStr := fmt.Sprintf("http://%s:%d/kv/api:entity:types:list", node.HTTP.Host, node.HTTP.Port)
-	logger.Info("Listing entity types from " + urlStr)
+func ImportEntity(ctx context.Context, node *global.Node, entityType string, entity map[string]interface{}) error {
+	return sendEntityToNode(ctx, entity, node, entityType)
+}
+
+func ListEntityTypes(ctx context.Context, node *global.Node) ([]string, error) {
+	urlStr := node.URL("/kv/api:entity:types:list")
+	logger.Debug("Listing entity types", logger.F("url", urlStr))
 
-	status, body, err := forward.ForwardRequest("GET", urlStr, "")
-	if err != nil || status >= 300 {
+	status, body, err := forward.ForwardRequestContext(ctx, "GET", urlStr, "")
+	if err != nil {
 		return nil, err
 	}
+	if status == 404 {
+		return []string{}, nil
+	}
+	if status >= 300 {
+		return nil, fmt.Errorf("node %s answered %d when listing entity types", node.Name, status)
+	}
 
 	var data struct {
 		Key   string `json:"key"`
@@ -69,13 +109,19 @@ func listNodeEntityTypes(node *global.Node) ([]string, error) {
 	return out, nil
 }
 
-func listNodeEntities(node *global.Node, entity string) ([]map[string]interface{}, error) {
+func ListEntities(ctx context.Context, node *global.Node, entity string) ([]map[string]interface{}, error) {
 	e := url.PathEscape(sanitizeType(entity))
-	urlStr := fmt.Sprintf("http://%s:%d/api/%s", node.HTTP.Host, node.HTTP.Port, e)
-	status, body, err := forward.ForwardRequest("GET", urlStr, "")
-	if err != nil || status >= 300 {
+	urlStr := node.URL("/api/" + e)
+	status, body, err := forward.ForwardRequestContext(ctx, "GET", urlStr, "")
+	if err != nil {
 		return nil, err
 	}
+	if status == 404 {
+		return nil, nil
+	}
+	if status >= 300 {
+		return nil, fmt.Errorf("node %s answered %d when listing %s", node.Name, status, entity)
+	}
 
 	var entities []map[string]interface{}
 	if err := json.Unmarshal([]byte(body), &entities); err != nil {
@@ -85,21 +131,32 @@ func listNodeEntities(node *global.Node, entity string) ([]map[string]interface{
 	return entities, nil
 }
 
-func sendEntityToNode(entity map[string]interface{}, node *global.Node, entityType string) error {
+func sendEntityToNode(ctx context.Context, entity map[string]interface{}, node *global.Node, entityType string) error {
 	payload, err := json.Marshal(entity)
 	if err != nil {
 		return err
 	}
 	e := url.PathEscape(sanitizeType(entityType))
-	urlStr := fmt.Sprintf("http://%s:%d/api/%s", node.HTTP.Host, node.HTTP.Port, e)
-	_, _, err = forward.ForwardRequest("POST", urlStr, string(payload))
+	urlStr := node.URL("/api/" + e)
+	status, _, err := forward.ForwardRequestContext(ctx, "POST", urlStr, string(payload))
+	if err == nil && status >= 300 {
+		err = fmt.Errorf("node %s answered %d when importing %s", node.Name, status, entityType)
+	}
+	return err
+}
+
+func DeleteEntityType(ctx context.Context, node *global.Node, entityType string) error {
+	status, _, err := forward.ForwardRequestContext(ctx, "DELETE", node.URL("/api/"+url.PathEscape(sanitizeType(entityType))), "")
+	if err == nil && status >= 300 && status != 404 {
+		err = fmt.Errorf("node %s answered %d when deleting %s", node.Name, status, entityType)
+	}
 	return err
 }
 
-func resetNodeEntity(node *global.Node, entity string) error {
+func resetNodeEntity(ctx context.Context, node *global.Node, entity string) error {
 	e := url.PathEscape(sanitizeType(entity))
-	urlStr := fmt.Sprintf("http://%s:%d/api/%s", node.HTTP.Host, node.HTTP.Port, e)
-	_, _, err := forward.ForwardRequest("DELETE", urlStr, "")
+	urlStr := node.URL("/api/" + e)
+	_, _, err := forward.ForwardRequestContext(ctx, "DELETE", urlStr, "")
 	return err
 }
 
@@ -0,0 +1,44 @@
+package routing
+
+import (
+	"io"
+
+	"github.com/valyala/fasthttp"
+)
+
+const restorePath = "/admin/restore"
+
+// LimitBodies enforces limit on every request body except restore uploads,
+// which the restore controller streams to disk under its own limit. The server
+// must run with StreamRequestBody so large uploads reach the handler unread.
+func LimitBodies(next fasthttp.RequestHandler, limit int) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		if string(ctx.Path()) == restorePath {
+			next(ctx)
+			return
+		}
+		n := ctx.Request.Header.ContentLength()
+		if n > limit {
+			tooLarge(ctx)
+			return
+		}
+		if n == -1 && ctx.Request.IsBodyStream() {
+			body, err := io.ReadAll(io.LimitReader(ctx.RequestBodyStream(), int64(limit)+1))
+			if err != nil {
+				ctx.Error(err.Error(), fasthttp.StatusBadRequest)
+				return
+			}
+			if len(body) > limit {
+				tooLarge(ctx)
+				return
+			}
+			ctx.Request.SetBody(body)
+		}
+		next(ctx)
+	}
+}
+
+func tooLarge(ctx *fasthttp.RequestCtx) {
+	ctx.Error("request body too large", fasthttp.StatusRequestEntityTooLarge)
+	ctx.SetConnectionClose()
+}
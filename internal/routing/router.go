@@ -1,6 +1,7 @@
 package routing
 
 import (
+	"github.com/elysiandb/elysian-gate/internal/transport/http/admin"
 	"github.com/elysiandb/elysian-gate/internal/transport/http/api"
 	"github.com/fasthttp/router"
 )
@@ -12,4 +13,12 @@ func RegisterRoutes(r *router.Router) {
 	r.DELETE("/api/{entity}/{id}", api.DeleteByIdController)
 	r.PUT("/api/{entity}/{id}", api.UpdateByIdController)
 	r.DELETE("/api/{entity}", api.DestroyController)
+	r.GET("/changes", api.ChangesController)
+
+	r.GET("/admin/cache/stats", admin.CacheStatsController)
+	r.GET("/admin/events", admin.EventsController)
+	r.GET("/admin/backup", admin.BackupController)
+	r.POST("/admin/restore", admin.RestoreController)
+	r.GET("/admin/cluster", admin.ClusterController)
+	r.POST("/admin/nodes/{name}/{action}", admin.NodeActionController)
 }
@@ -0,0 +1,346 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+)
+
+type Violation struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+type Schema struct {
+	Type                 typeList           `json:"type"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *additional        `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	Enum                 []any              `json:"enum"`
+	Const                *any               `json:"const"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	Pattern              string             `json:"pattern"`
+	Format               string             `json:"format"`
+	MinItems             *int               `json:"minItems"`
+	MaxItems             *int               `json:"maxItems"`
+	UniqueItems          bool               `json:"uniqueItems"`
+
+	pattern *regexp.Regexp
+}
+
+// annotations are accepted and ignored. Any other keyword that Schema does not
+// implement is rejected, so a schema never silently validates less than it says.
+var annotations = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "title": true, "description": true,
+	"default": true, "examples": true, "deprecated": true, "readOnly": true, "writeOnly": true,
+}
+
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	unsupported := []string{}
+	for key := range raw {
+		if !annotations[key] && !keywords[key] {
+			unsupported = append(unsupported, key)
+		}
+	}
+	if len(unsupported) > 0 {
+		sort.Strings(unsupported)
+		return fmt.Errorf("unsupported schema keyword(s) %s", strings.Join(unsupported, ", "))
+	}
+	type plain Schema
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+var keywords = func() map[string]bool {
+	out := map[string]bool{}
+	t := reflect.TypeOf(Schema{})
+	for i := 0; i < t.NumField(); i++ {
+		if tag := t.Field(i).Tag.Get("json"); tag != "" {
+			out[tag] = true
+		}
+	}
+	return out
+}()
+
+type typeList []string
+
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = typeList{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+type additional struct {
+	allowed bool
+	schema  *Schema
+}
+
+func (a *additional) UnmarshalJSON(data []byte) error {
+	var b bool
+	if err := json.Unmarshal(data, &b); err == nil {
+		a.allowed = b
+		return nil
+	}
+	a.allowed = true
+	a.schema = &Schema{}
+	return json.Unmarshal(data, a.schema)
+}
+
+var registry = struct {
+	sync.RWMutex
+	schemas map[string]*Schema
+}{schemas: map[string]*Schema{}}
+
+func Init() error {
+	schemas := map[string]*Schema{}
+	for entity, cfg := range configuration.Config.Gateway.Validation.Entities {
+		if cfg.SchemaFile == "" {
+			continue
+		}
+		s, err := LoadFile(cfg.SchemaFile)
+		if err != nil {
+			return fmt.Errorf("schema for %s: %w", entity, err)
+		}
+		schemas[entity] = s
+	}
+	registry.Lock()
+	registry.schemas = schemas
+	registry.Unlock()
+	return nil
+}
+
+func ForEntity(entity string) *Schema {
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.schemas[entity]
+}
+
+func LoadFile(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func Parse(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *Schema) compile() error {
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	for _, p := range s.Properties {
+		if err := p.compile(); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		if err := s.Items.compile(); err != nil {
+			return err
+		}
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.schema != nil {
+		return s.AdditionalProperties.schema.compile()
+	}
+	return nil
+}
+
+func (s *Schema) Validate(doc any, partial bool) []Violation {
+	out := []Violation{}
+	s.validate(doc, "", partial, &out)
+	return out
+}
+
+func (s *Schema) validate(v any, path string, partial bool, out *[]Violation) {
+	add := func(format string, args ...any) {
+		*out = append(*out, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.Type) > 0 && !s.Type.matches(v) {
+		add("expected %s, got %s", strings.Join(s.Type, " or "), typeOf(v))
+		return
+	}
+	if s.Const != nil && !equal(v, *s.Const) {
+		add("must be %s", render(*s.Const))
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if equal(v, e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add("must be one of %s", render(s.Enum))
+		}
+	}
+
+	switch val := v.(type) {
+	case string:
+		n := utf8.RuneCountInString(val)
+		if s.MinLength != nil && n < *s.MinLength {
+			add("must be at least %d characters long", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			add("must be at most %d characters long", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(val) {
+			add("must match pattern %s", s.Pattern)
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, val); err != nil {
+				add("must be an RFC 3339 date-time")
+			}
+		}
+	case float64:
+		if s.Minimum != nil && val < *s.Minimum {
+			add("must be >= %g", *s.Minimum)
+		}
+		if s.Maximum != nil && val > *s.Maximum {
+			add("must be <= %g", *s.Maximum)
+		}
+		if s.ExclusiveMinimum != nil && val <= *s.ExclusiveMinimum {
+			add("must be > %g", *s.ExclusiveMinimum)
+		}
+		if s.ExclusiveMaximum != nil && val >= *s.ExclusiveMaximum {
+			add("must be < %g", *s.ExclusiveMaximum)
+		}
+	case []any:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			add("must contain at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(val) > *s.MaxItems {
+			add("must contain at most %d items", *s.MaxItems)
+		}
+		if s.UniqueItems {
+			seen := map[string]bool{}
+			for _, item := range val {
+				key := render(item)
+				if seen[key] {
+					add("must contain unique items")
+					break
+				}
+				seen[key] = true
+			}
+		}
+		if s.Items != nil {
+			for i, item := range val {
+				s.Items.validate(item, path+"/"+strconv.Itoa(i), false, out)
+			}
+		}
+	case map[string]any:
+		if !partial {
+			for _, req := range s.Required {
+				if _, ok := val[req]; !ok {
+					*out = append(*out, Violation{Path: path + "/" + escape(req), Message: "is required"})
+				}
+			}
+		}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := path + "/" + escape(k)
+			if prop, ok := s.Properties[k]; ok {
+				prop.validate(val[k], child, false, out)
+				continue
+			}
+			if s.AdditionalProperties == nil {
+				continue
+			}
+			if !s.AdditionalProperties.allowed {
+				*out = append(*out, Violation{Path: child, Message: "is not allowed"})
+			} else if s.AdditionalProperties.schema != nil {
+				s.AdditionalProperties.schema.validate(val[k], child, false, out)
+			}
+		}
+	}
+}
+
+func (t typeList) matches(v any) bool {
+	actual := typeOf(v)
+	for _, want := range t {
+		if want == actual || (want == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if val == math.Trunc(val) && !math.IsInf(val, 0) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "unknown"
+}
+
+func equal(a any, b any) bool {
+	return render(a) == render(b)
+}
+
+func render(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func escape(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
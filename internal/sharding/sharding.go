@@ -0,0 +1,151 @@
+package sharding
+
+import (
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/global"
+)
+
+const defaultVirtualNodes = 64
+
+type ring struct {
+	hashes []uint32
+	owners map[uint32]string
+}
+
+var (
+	mu           sync.RWMutex
+	groups       []string
+	defaultGroup = global.DefaultGroup
+	entityGroups = map[string]string{}
+	hashed       = map[string]bool{}
+	hashRing     = ring{owners: map[uint32]string{}}
+)
+
+func Init(groupNames []string) {
+	cfg := configuration.Config.Gateway.Sharding
+
+	names := uniqueSorted(groupNames)
+	if len(names) == 0 {
+		names = []string{global.DefaultGroup}
+	}
+
+	def := cfg.DefaultGroup
+	if def == "" {
+		def = names[0]
+	}
+
+	entities := map[string]string{}
+	for entity, group := range cfg.Entities {
+		entities[entity] = group
+	}
+
+	hashedSet := map[string]bool{}
+	for _, entity := range cfg.HashedEntities {
+		hashedSet[entity] = true
+	}
+
+	vnodes := cfg.VirtualNodes
+	if vnodes <= 0 {
+		vnodes = defaultVirtualNodes
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	groups = names
+	defaultGroup = def
+	entityGroups = entities
+	hashed = hashedSet
+	hashRing = buildRing(names, vnodes)
+}
+
+func Groups() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if len(groups) == 0 {
+		return []string{defaultGroup}
+	}
+	return append([]string(nil), groups...)
+}
+
+func DefaultGroup() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultGroup
+}
+
+func IsHashed(entity string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return hashed[entity] && len(hashRing.hashes) > 0
+}
+
+func GroupFor(entity string, id string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if hashed[entity] && id != "" && len(hashRing.hashes) > 0 {
+		return hashRing.lookup(id)
+	}
+	if g, ok := entityGroups[entity]; ok {
+		return g
+	}
+	return defaultGroup
+}
+
+func ParseAPIPath(path string) (string, string) {
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "api" {
+		return "", ""
+	}
+	if len(parts) == 2 {
+		return parts[1], ""
+	}
+	return parts[1], parts[2]
+}
+
+func buildRing(names []string, vnodes int) ring {
+	r := ring{owners: map[uint32]string{}}
+	for _, g := range names {
+		for i := 0; i < vnodes; i++ {
+			h := crc32.ChecksumIEEE([]byte(fmt.Sprintf("%s#%d", g, i)))
+			if _, taken := r.owners[h]; taken {
+				continue
+			}
+			r.owners[h] = g
+			r.hashes = append(r.hashes, h)
+		}
+	}
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+	return r
+}
+
+func (r ring) lookup(key string) string {
+	h := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if i == len(r.hashes) {
+		i = 0
+	}
+	return r.owners[r.hashes[i]]
+}
+
+func uniqueSorted(in []string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, s := range in {
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,56 @@
+package sse
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+type Stream struct {
+	w         *bufio.Writer
+	conn      net.Conn
+	Heartbeat <-chan time.Time
+	timeout   time.Duration
+}
+
+func Serve(ctx *fasthttp.RequestCtx, heartbeat time.Duration, fn func(s *Stream)) {
+	conn := ctx.Conn()
+	ctx.SetContentType("text/event-stream")
+	ctx.Response.Header.Set("Cache-Control", "no-cache")
+	ctx.Response.Header.Set("X-Accel-Buffering", "no")
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		s := &Stream{w: w, conn: conn, Heartbeat: ticker.C, timeout: 2 * heartbeat}
+		s.Comment("connected")
+		if s.Flush() {
+			fn(s)
+		}
+	})
+}
+
+func Accepts(ctx *fasthttp.RequestCtx) bool {
+	return string(ctx.Request.Header.Peek("Accept")) == "text/event-stream" || string(ctx.QueryArgs().Peek("stream")) == "sse"
+}
+
+func (s *Stream) Event(id string, event string, data []byte) {
+	if id != "" {
+		fmt.Fprintf(s.w, "id: %s\n", id)
+	}
+	fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, data)
+}
+
+func (s *Stream) Comment(text string) {
+	fmt.Fprintf(s.w, ": %s\n\n", text)
+}
+
+func (s *Stream) Flush() bool {
+	if s.conn != nil {
+		s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	}
+	return s.w.Flush() == nil
+}
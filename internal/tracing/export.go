@@ -0,0 +1,283 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/logger"
+)
+
+const (
+	defaultServiceName = "elysian-gate"
+	defaultTraceFile   = "elysianGate.trace.jsonl"
+	queueSize          = 4096
+	batchSize          = 256
+	flushInterval      = time.Second
+)
+
+type exporter func(body []byte) error
+
+var (
+	queue     chan *Span
+	flushes   chan chan struct{}
+	stop      chan struct{}
+	running   sync.WaitGroup
+	lifecycle sync.Mutex
+)
+
+func Init() error {
+	Shutdown()
+
+	cfg := configuration.Config.Gateway.Tracing
+	if !cfg.Enabled {
+		return nil
+	}
+
+	var exp exporter
+	switch cfg.Exporter {
+	case "", "file":
+		path := cfg.File
+		if path == "" {
+			path = defaultTraceFile
+		}
+		exp = fileExporter(path)
+	case "otlp":
+		if cfg.Endpoint == "" {
+			return fmt.Errorf("tracing exporter otlp requires an endpoint")
+		}
+		exp = otlpExporter(strings.TrimRight(cfg.Endpoint, "/") + "/v1/traces")
+	default:
+		return fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+
+	service := cfg.ServiceName
+	if service == "" {
+		service = defaultServiceName
+	}
+
+	lifecycle.Lock()
+	queue = make(chan *Span, queueSize)
+	flushes = make(chan chan struct{})
+	stop = make(chan struct{})
+	running.Add(1)
+	go run(queue, flushes, stop, service, exp)
+	lifecycle.Unlock()
+
+	enabled.Store(true)
+	return nil
+}
+
+func Flush() {
+	lifecycle.Lock()
+	defer lifecycle.Unlock()
+	if flushes == nil {
+		return
+	}
+	done := make(chan struct{})
+	flushes <- done
+	<-done
+}
+
+func Shutdown() {
+	enabled.Store(false)
+	lifecycle.Lock()
+	defer lifecycle.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	running.Wait()
+	queue, flushes, stop = nil, nil, nil
+}
+
+func export(s *Span) {
+	lifecycle.Lock()
+	q := queue
+	lifecycle.Unlock()
+	if q == nil {
+		return
+	}
+	select {
+	case q <- s:
+	default:
+		logger.Warn("trace queue full, dropping span", logger.F("span", s.name))
+	}
+}
+
+func run(q chan *Span, f chan chan struct{}, stop chan struct{}, service string, exp exporter) {
+	defer running.Done()
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := []*Span{}
+	send := func() {
+	drain:
+		for {
+			select {
+			case s := <-q:
+				batch = append(batch, s)
+			default:
+				break drain
+			}
+		}
+		if len(batch) == 0 {
+			return
+		}
+		if err := exp(encode(service, batch)); err != nil {
+			logger.Error("trace export failed", logger.F("spans", len(batch)), logger.F("error", err))
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case s := <-q:
+			batch = append(batch, s)
+			if len(batch) >= batchSize {
+				send()
+			}
+		case done := <-f:
+			send()
+			close(done)
+		case <-ticker.C:
+			send()
+		case <-stop:
+			send()
+			return
+		}
+	}
+}
+
+func fileExporter(path string) exporter {
+	return func(body []byte) error {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = f.Write(append(body, '\n'))
+		return err
+	}
+}
+
+func otlpExporter(url string) exporter {
+	client := &http.Client{Timeout: 5 * time.Second}
+	return func(body []byte) error {
+		resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("collector answered %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpLink struct {
+	TraceID string `json:"traceId"`
+	SpanID  string `json:"spanId"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Links             []otlpLink      `json:"links,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+func encode(service string, spans []*Span) []byte {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		s.mu.Lock()
+		span := otlpSpan{
+			TraceID:           hex.EncodeToString(s.ctx.TraceID[:]),
+			SpanID:            hex.EncodeToString(s.ctx.SpanID[:]),
+			Name:              s.name,
+			Kind:              int(s.kind),
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Status:            otlpStatus{Code: 1},
+		}
+		if s.parent != (SpanID{}) {
+			span.ParentSpanID = hex.EncodeToString(s.parent[:])
+		}
+		for _, a := range s.attrs {
+			span.Attributes = append(span.Attributes, otlpAttribute{Key: a.Key, Value: valueOf(a.Value)})
+		}
+		for _, l := range s.links {
+			span.Links = append(span.Links, otlpLink{TraceID: hex.EncodeToString(l.TraceID[:]), SpanID: hex.EncodeToString(l.SpanID[:])})
+		}
+		if s.failed {
+			span.Status = otlpStatus{Code: 2, Message: s.errorMsg}
+		}
+		s.mu.Unlock()
+		out = append(out, span)
+	}
+
+	body := map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": []otlpAttribute{{Key: "service.name", Value: valueOf(service)}},
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]string{"name": defaultServiceName},
+				"spans": out,
+			}},
+		}},
+	}
+	data, _ := json.Marshal(body)
+	return data
+}
+
+func valueOf(v any) otlpValue {
+	switch val := v.(type) {
+	case string:
+		return otlpValue{StringValue: &val}
+	case bool:
+		return otlpValue{BoolValue: &val}
+	case int:
+		s := strconv.Itoa(val)
+		return otlpValue{IntValue: &s}
+	case int64:
+		s := strconv.FormatInt(val, 10)
+		return otlpValue{IntValue: &s}
+	case float64:
+		return otlpValue{DoubleValue: &val}
+	}
+	s := fmt.Sprint(v)
+	return otlpValue{StringValue: &s}
+}
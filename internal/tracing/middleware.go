@@ -0,0 +1,45 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/valyala/fasthttp"
+)
+
+const spanKey = "span"
+
+func Middleware(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		if !Enabled() {
+			next(ctx)
+			return
+		}
+
+		method := string(ctx.Method())
+		path := string(ctx.Path())
+		remote, _ := ParseTraceparent(string(ctx.Request.Header.Peek(TraceparentHeader)))
+		_, span := StartRemote(context.Background(), "HTTP "+method, KindServer, remote,
+			Attr("http.method", method),
+			Attr("http.target", path),
+		)
+		ctx.SetUserValue(spanKey, span)
+
+		next(ctx)
+
+		status := ctx.Response.StatusCode()
+		span.SetAttributes(Attr("http.status_code", status))
+		if entity, _ := ctx.UserValue("entity").(string); entity != "" {
+			span.SetAttributes(Attr("elysian.entity", entity))
+		}
+		if status >= 500 {
+			span.SetError(fmt.Errorf("status %d", status))
+		}
+		span.End()
+	}
+}
+
+func FromRequest(ctx *fasthttp.RequestCtx) *Span {
+	s, _ := ctx.UserValue(spanKey).(*Span)
+	return s
+}
@@ -0,0 +1,221 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	mrand "math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+)
+
+const TraceparentHeader = "traceparent"
+
+type Kind int
+
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindClient   Kind = 3
+)
+
+type TraceID [16]byte
+type SpanID [8]byte
+
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+func (sc SpanContext) Valid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]), flags)
+}
+
+func ParseTraceparent(s string) (SpanContext, bool) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return sc, false
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, sc.Valid()
+}
+
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+func Attr(key string, value any) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+type Span struct {
+	ctx    SpanContext
+	parent SpanID
+	name   string
+	kind   Kind
+	start  time.Time
+
+	mu       sync.Mutex
+	end      time.Time
+	attrs    []Attribute
+	links    []SpanContext
+	errorMsg string
+	failed   bool
+	ended    bool
+}
+
+type contextKey struct{}
+
+var enabled atomic.Bool
+
+func Enabled() bool {
+	return enabled.Load()
+}
+
+func NewContext(ctx context.Context, s *Span) context.Context {
+	if s == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+func FromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(contextKey{}).(*Span)
+	return s
+}
+
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return StartKind(ctx, name, KindInternal, attrs...)
+}
+
+func StartKind(ctx context.Context, name string, kind Kind, attrs ...Attribute) (context.Context, *Span) {
+	if !Enabled() {
+		return ctx, nil
+	}
+	var parent SpanContext
+	if p := FromContext(ctx); p != nil {
+		parent = p.ctx
+	}
+	return startSpan(ctx, name, kind, parent, attrs)
+}
+
+func StartRemote(ctx context.Context, name string, kind Kind, remote SpanContext, attrs ...Attribute) (context.Context, *Span) {
+	if !Enabled() {
+		return ctx, nil
+	}
+	return startSpan(ctx, name, kind, remote, attrs)
+}
+
+func startSpan(ctx context.Context, name string, kind Kind, parent SpanContext, attrs []Attribute) (context.Context, *Span) {
+	s := &Span{name: name, kind: kind, start: time.Now(), attrs: attrs}
+	if parent.Valid() {
+		s.ctx.TraceID = parent.TraceID
+		s.ctx.Sampled = parent.Sampled
+		s.parent = parent.SpanID
+	} else {
+		rand.Read(s.ctx.TraceID[:])
+		s.ctx.Sampled = sampled()
+	}
+	rand.Read(s.ctx.SpanID[:])
+	return NewContext(ctx, s), s
+}
+
+func sampled() bool {
+	ratio := configuration.Config.Gateway.Tracing.SampleRatio
+	if ratio <= 0 || ratio >= 1 {
+		return true
+	}
+	return mrand.Float64() < ratio
+}
+
+func Inject(ctx context.Context, h http.Header) {
+	if s := FromContext(ctx); s != nil {
+		h.Set(TraceparentHeader, s.ctx.Traceparent())
+	}
+}
+
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.ctx
+}
+
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.attrs = append(s.attrs, attrs...)
+	s.mu.Unlock()
+}
+
+func (s *Span) AddLink(sc SpanContext) {
+	if s == nil || !sc.Valid() {
+		return
+	}
+	s.mu.Lock()
+	s.links = append(s.links, sc)
+	s.mu.Unlock()
+}
+
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.failed = true
+	s.errorMsg = err.Error()
+	s.mu.Unlock()
+}
+
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+	if s.ctx.Sampled {
+		export(s)
+	}
+}
@@ -0,0 +1,142 @@
+package admin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/accesslog"
+	"github.com/elysiandb/elysian-gate/internal/authz"
+	"github.com/elysiandb/elysian-gate/internal/backup"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/logger"
+	"github.com/valyala/fasthttp"
+)
+
+func BackupController(ctx *fasthttp.RequestCtx) {
+	if !authz.Authorize(ctx, "", authz.ActionAdmin) {
+		return
+	}
+
+	a, err := backup.Export(accesslog.Context(ctx))
+	if err != nil {
+		writeError(ctx, fasthttp.StatusBadGateway, err)
+		return
+	}
+	m := a.Manifest
+
+	name := fmt.Sprintf("elysian-backup-%s-seq%d.tar.gz", m.CreatedAt.Format("20060102-150405"), m.Seq)
+	ctx.SetContentType("application/gzip")
+	ctx.Response.Header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
+	ctx.Response.Header.Set("X-Elysian-Backup-Seq", fmt.Sprint(m.Seq))
+	ctx.Response.Header.Set("X-Elysian-Backup-Created", m.CreatedAt.Format(time.RFC3339))
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	conn := ctx.Conn()
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer a.Close()
+		if conn != nil {
+			conn.SetWriteDeadline(time.Now().Add(transferTimeout))
+		}
+		if err := a.Write(w); err != nil {
+			logger.Error("backup download failed", logger.F("seq", m.Seq), logger.F("error", err))
+		}
+	})
+}
+
+func RestoreController(ctx *fasthttp.RequestCtx) {
+	if !authz.Authorize(ctx, "", authz.ActionAdmin) {
+		return
+	}
+
+	limit := maxRestoreBytes()
+	if n := ctx.Request.Header.ContentLength(); n > 0 && int64(n) > limit {
+		ctx.SetConnectionClose()
+		writeError(ctx, fasthttp.StatusRequestEntityTooLarge, errArchiveTooLarge)
+		return
+	}
+	if c := ctx.Conn(); c != nil {
+		c.SetReadDeadline(time.Now().Add(transferTimeout))
+	}
+	f, err := spool(ctx, limit)
+	switch {
+	case errors.Is(err, errArchiveTooLarge):
+		ctx.SetConnectionClose()
+		writeError(ctx, fasthttp.StatusRequestEntityTooLarge, err)
+		return
+	case err != nil:
+		ctx.SetConnectionClose()
+		writeError(ctx, fasthttp.StatusInternalServerError, err)
+		return
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	report, err := backup.Restore(accesslog.Context(ctx), f)
+	status := fasthttp.StatusOK
+	switch {
+	case errors.Is(err, backup.ErrInvalidArchive):
+		writeError(ctx, fasthttp.StatusBadRequest, err)
+		return
+	case err != nil:
+		status = fasthttp.StatusBadGateway
+	}
+
+	body := map[string]any{"report": report}
+	if err != nil {
+		body["error"] = err.Error()
+	}
+	data, _ := json.MarshalIndent(body, "", "  ")
+	ctx.SetContentType("application/json")
+	ctx.SetStatusCode(status)
+	ctx.SetBody(data)
+}
+
+const transferTimeout = 30 * time.Minute
+
+var errArchiveTooLarge = errors.New("archive exceeds gateway.backup.maxRestoreMB")
+
+func maxRestoreBytes() int64 {
+	mb := configuration.Config.Gateway.Backup.MaxRestoreMB
+	if mb <= 0 {
+		mb = 1024
+	}
+	return int64(mb) << 20
+}
+
+// spool copies the upload to a temporary file so the archive can be checked
+// and then loaded without holding it in memory.
+func spool(ctx *fasthttp.RequestCtx, limit int64) (*os.File, error) {
+	body := ctx.RequestBodyStream()
+	if body == nil {
+		body = bytes.NewReader(ctx.PostBody())
+	}
+	f, err := os.CreateTemp(configuration.Config.Gateway.Backup.SpoolDir, "elysian-restore-*.tar.gz")
+	if err != nil {
+		return nil, err
+	}
+	n, err := io.Copy(f, io.LimitReader(body, limit+1))
+	if err == nil && n > limit {
+		err = errArchiveTooLarge
+	}
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return f, nil
+}
+
+func writeError(ctx *fasthttp.RequestCtx, status int, err error) {
+	data, _ := json.Marshal(map[string]string{"error": err.Error()})
+	ctx.SetContentType("application/json")
+	ctx.SetStatusCode(status)
+	ctx.SetBody(data)
+}
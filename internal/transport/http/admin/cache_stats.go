@@ -0,0 +1,20 @@
+package admin
+
+import (
+	"encoding/json"
+
+	"github.com/elysiandb/elysian-gate/internal/authz"
+	"github.com/elysiandb/elysian-gate/internal/cache"
+	"github.com/valyala/fasthttp"
+)
+
+func CacheStatsController(ctx *fasthttp.RequestCtx) {
+	if !authz.Authorize(ctx, "", authz.ActionAdmin) {
+		return
+	}
+
+	data, _ := json.MarshalIndent(cache.GetStats(), "", "  ")
+	ctx.SetContentType("application/json")
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetBody(data)
+}
@@ -0,0 +1,59 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/elysiandb/elysian-gate/internal/authz"
+	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/dashboard"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+	"github.com/valyala/fasthttp"
+)
+
+func ClusterController(ctx *fasthttp.RequestCtx) {
+	if !authz.Authorize(ctx, "", authz.ActionAdmin) {
+		return
+	}
+
+	data, _ := json.MarshalIndent(dashboard.Collect(), "", "  ")
+	ctx.SetContentType("application/json")
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetBody(data)
+}
+
+func NodeActionController(ctx *fasthttp.RequestCtx) {
+	if !authz.Authorize(ctx, "", authz.ActionAdmin) {
+		return
+	}
+
+	name, _ := ctx.UserValue("name").(string)
+	action, _ := ctx.UserValue("action").(string)
+
+	var err error
+	switch action {
+	case "resync":
+		err = nodes.ElysianCluster.Resync(name)
+	case "promote":
+		err = balancer.PromoteNode(name)
+	case "drain":
+		err = nodes.ElysianCluster.Drain(name, true)
+	case "undrain":
+		err = nodes.ElysianCluster.Drain(name, false)
+	default:
+		writeError(ctx, fasthttp.StatusNotFound, errors.New("unknown node action "+action))
+		return
+	}
+
+	switch {
+	case errors.Is(err, nodes.ErrUnknownNode):
+		writeError(ctx, fasthttp.StatusNotFound, err)
+	case err != nil:
+		writeError(ctx, fasthttp.StatusConflict, err)
+	default:
+		data, _ := json.Marshal(map[string]string{"node": name, "action": action, "status": "ok"})
+		ctx.SetContentType("application/json")
+		ctx.SetStatusCode(fasthttp.StatusOK)
+		ctx.SetBody(data)
+	}
+}
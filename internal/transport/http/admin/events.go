@@ -0,0 +1,71 @@
+package admin
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/authz"
+	"github.com/elysiandb/elysian-gate/internal/events"
+	"github.com/elysiandb/elysian-gate/internal/sse"
+	"github.com/valyala/fasthttp"
+)
+
+var EventsHeartbeat = 15 * time.Second
+
+func EventsController(ctx *fasthttp.RequestCtx) {
+	if !authz.Authorize(ctx, "", authz.ActionAdmin) {
+		return
+	}
+
+	types := []string{}
+	if raw := string(ctx.QueryArgs().Peek("types")); raw != "" {
+		types = strings.Split(raw, ",")
+	}
+
+	var sub *events.Subscription
+	lastID := string(ctx.Request.Header.Peek("Last-Event-ID"))
+	if lastID == "" {
+		lastID = string(ctx.QueryArgs().Peek("since"))
+	}
+	if lastID != "" {
+		since, err := strconv.ParseUint(lastID, 10, 64)
+		if err != nil {
+			ctx.Error(`{"error":"invalid event id"}`, fasthttp.StatusBadRequest)
+			return
+		}
+		sub = events.SubscribeFrom(since, types...)
+	} else {
+		sub = events.Subscribe(types...)
+	}
+
+	sse.Serve(ctx, EventsHeartbeat, func(s *sse.Stream) {
+		defer sub.Close()
+		for _, e := range sub.Backlog {
+			writeEvent(s, e)
+		}
+		if !s.Flush() {
+			return
+		}
+		for {
+			select {
+			case e, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				writeEvent(s, e)
+			case <-s.Heartbeat:
+				s.Comment("ping")
+			}
+			if !s.Flush() {
+				return
+			}
+		}
+	})
+}
+
+func writeEvent(s *sse.Stream, e events.Event) {
+	data, _ := json.Marshal(e)
+	s.Event(strconv.FormatUint(e.ID, 10), e.Type, data)
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/auth"
+	"github.com/elysiandb/elysian-gate/internal/authz"
+	"github.com/elysiandb/elysian-gate/internal/changelog"
+	"github.com/elysiandb/elysian-gate/internal/ratelimit"
+	"github.com/elysiandb/elysian-gate/internal/sse"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	defaultChangesLimit = 100
+	maxChangesLimit     = 1000
+	maxChangesWait      = 60
+)
+
+var ChangesHeartbeat = 15 * time.Second
+
+func ChangesController(ctx *fasthttp.RequestCtx) {
+	if !changelog.Enabled() {
+		writeJSON(ctx, fasthttp.StatusNotFound, map[string]any{"error": changelog.ErrDisabled.Error()})
+		return
+	}
+
+	entities := []string{}
+	if raw := string(ctx.QueryArgs().Peek("entity")); raw != "" {
+		entities = strings.Split(raw, ",")
+	}
+	for _, entity := range entities {
+		if !authz.Authorize(ctx, entity, authz.ActionRead) {
+			return
+		}
+	}
+	if !ratelimit.Admit(ctx, "", ratelimit.ClassRead) {
+		return
+	}
+
+	after, err := changesCursor(ctx)
+	if err != nil {
+		writeJSON(ctx, fasthttp.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	limit := min(intArg(ctx, "limit", defaultChangesLimit), maxChangesLimit)
+
+	p := auth.FromContext(ctx)
+	keep := func(c changelog.Change) bool {
+		if len(entities) > 0 && !slices.Contains(entities, c.Entity) {
+			return false
+		}
+		return authz.Allowed(p, c.Entity, authz.ActionRead)
+	}
+
+	if sse.Accepts(ctx) {
+		streamChanges(ctx, after, limit, keep)
+		return
+	}
+
+	deadline := time.Now().Add(time.Duration(min(intArg(ctx, "wait", 0), maxChangesWait)) * time.Second)
+	for {
+		changed := changelog.Changed()
+		changes, cursor, err := changelog.Read(after, limit, keep)
+		if err != nil {
+			writeChangesError(ctx, err)
+			return
+		}
+		if len(changes) > 0 || !time.Now().Before(deadline) {
+			writeJSON(ctx, fasthttp.StatusOK, map[string]any{"changes": changes, "last_seq": cursor})
+			return
+		}
+		after = cursor
+		select {
+		case <-changed:
+		case <-time.After(time.Until(deadline)):
+		}
+	}
+}
+
+func streamChanges(ctx *fasthttp.RequestCtx, after int64, limit int, keep func(changelog.Change) bool) {
+	sse.Serve(ctx, ChangesHeartbeat, func(s *sse.Stream) {
+		for {
+			changed := changelog.Changed()
+			changes, cursor, err := changelog.Read(after, limit, keep)
+			if err != nil {
+				data, _ := json.Marshal(map[string]any{"error": err.Error()})
+				s.Event("", "error", data)
+				s.Flush()
+				return
+			}
+			for _, c := range changes {
+				data, _ := json.Marshal(c)
+				s.Event(strconv.FormatInt(c.Seq, 10), c.Op, data)
+			}
+			after = cursor
+			if !s.Flush() {
+				return
+			}
+			if after < changelog.LastSeq() {
+				continue
+			}
+			select {
+			case <-changed:
+			case <-s.Heartbeat:
+				s.Comment("ping")
+				if !s.Flush() {
+					return
+				}
+			}
+		}
+	})
+}
+
+func changesCursor(ctx *fasthttp.RequestCtx) (int64, error) {
+	args := ctx.QueryArgs()
+	if raw := string(args.Peek("from")); raw != "" {
+		from, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || from < 1 {
+			return 0, errors.New("invalid from sequence")
+		}
+		return from - 1, nil
+	}
+
+	raw := string(ctx.Request.Header.Peek("Last-Event-ID"))
+	if raw == "" {
+		raw = string(args.Peek("since"))
+	}
+	if raw != "" {
+		since, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || since < 0 {
+			return 0, errors.New("invalid since sequence")
+		}
+		return since, nil
+	}
+
+	if first := changelog.FirstSeq(); first > 0 {
+		return first - 1, nil
+	}
+	return 0, nil
+}
+
+func writeChangesError(ctx *fasthttp.RequestCtx, err error) {
+	switch {
+	case errors.Is(err, changelog.ErrTruncated):
+		writeJSON(ctx, fasthttp.StatusGone, map[string]any{"error": err.Error(), "first_seq": changelog.FirstSeq()})
+	case errors.Is(err, changelog.ErrDisabled):
+		writeJSON(ctx, fasthttp.StatusNotFound, map[string]any{"error": err.Error()})
+	default:
+		writeJSON(ctx, fasthttp.StatusInternalServerError, map[string]any{"error": err.Error()})
+	}
+}
+
+func intArg(ctx *fasthttp.RequestCtx, name string, def int) int {
+	v, err := ctx.QueryArgs().GetUint(name)
+	if err != nil || v <= 0 {
+		return def
+	}
+	return v
+}
@@ -1,18 +1,33 @@
 package api
 
 import (
+	"errors"
+
+	"github.com/elysiandb/elysian-gate/internal/accesslog"
+	"github.com/elysiandb/elysian-gate/internal/authz"
 	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/breaker"
 	"github.com/valyala/fasthttp"
 )
 
 func CreateController(ctx *fasthttp.RequestCtx) {
+	if !admit(ctx, authz.ActionCreate) || !validBody(ctx, false) {
+		return
+	}
+
 	path := string(ctx.Path())
 	if q := ctx.URI().QueryString(); len(q) > 0 {
 		path += "?" + string(q)
 	}
 
-	status, body, err := balancer.SendWriteRequestToMaster("POST", path, string(ctx.PostBody()))
+	status, body, err := balancer.SendWriteRequestToMasterContext(accesslog.Context(ctx), "POST", path, string(ctx.PostBody()))
 	if err != nil {
+		if errors.Is(err, breaker.ErrOpen) {
+			ctx.SetContentType("application/json")
+			ctx.SetStatusCode(status)
+			ctx.SetBody([]byte(body))
+			return
+		}
 		ctx.SetStatusCode(fasthttp.StatusBadGateway)
 		ctx.SetBody([]byte(err.Error()))
 		return
@@ -1,20 +1,34 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 
+	"github.com/elysiandb/elysian-gate/internal/accesslog"
+	"github.com/elysiandb/elysian-gate/internal/authz"
 	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/breaker"
 	"github.com/valyala/fasthttp"
 )
 
 func DeleteByIdController(ctx *fasthttp.RequestCtx) {
+	if !admit(ctx, authz.ActionDelete) {
+		return
+	}
+
 	path := string(ctx.Path())
 	if q := ctx.URI().QueryString(); len(q) > 0 {
 		path += "?" + string(q)
 	}
 
-	status, body, err := balancer.SendWriteRequestToMaster("DELETE", path, "")
+	status, body, err := balancer.SendWriteRequestToMasterContext(accesslog.Context(ctx), "DELETE", path, "")
 	if err != nil {
+		if errors.Is(err, breaker.ErrOpen) {
+			ctx.SetContentType("application/json")
+			ctx.SetStatusCode(status)
+			ctx.SetBody([]byte(body))
+			return
+		}
 		ctx.SetStatusCode(fasthttp.StatusBadGateway)
 		ctx.SetBody([]byte(fmt.Sprintf("Error forwarding delete: %v", err)))
 		return
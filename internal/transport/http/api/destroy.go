@@ -1,15 +1,29 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 
+	"github.com/elysiandb/elysian-gate/internal/accesslog"
+	"github.com/elysiandb/elysian-gate/internal/authz"
 	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/breaker"
 	"github.com/valyala/fasthttp"
 )
 
 func DestroyController(ctx *fasthttp.RequestCtx) {
-	status, body, err := balancer.SendWriteRequestToMaster("DELETE", string(ctx.Path()), "")
+	if !admit(ctx, authz.ActionDestroy) {
+		return
+	}
+
+	status, body, err := balancer.SendWriteRequestToMasterContext(accesslog.Context(ctx), "DELETE", string(ctx.Path()), "")
 	if err != nil {
+		if errors.Is(err, breaker.ErrOpen) {
+			ctx.SetContentType("application/json")
+			ctx.SetStatusCode(status)
+			ctx.SetBody([]byte(body))
+			return
+		}
 		ctx.SetStatusCode(fasthttp.StatusBadGateway)
 		ctx.SetBody([]byte(fmt.Sprintf("Error forwarding delete: %v", err)))
 		return
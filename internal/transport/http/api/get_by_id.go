@@ -2,15 +2,20 @@
 package api
 
 import (
+	"github.com/elysiandb/elysian-gate/internal/authz"
 	"github.com/elysiandb/elysian-gate/internal/balancer"
 	"github.com/valyala/fasthttp"
 )
 
 func GetByIdController(ctx *fasthttp.RequestCtx) {
+	if !admit(ctx, authz.ActionRead) {
+		return
+	}
+
 	path := string(ctx.Path())
 	query := string(ctx.URI().QueryString())
 
-	status, body, _ := balancer.SendReadRequest(path, query)
+	status, body := cachedRead(ctx, path, query, balancer.SendReadRequestContext)
 	ctx.SetContentType("application/json")
 	ctx.SetStatusCode(status)
 	ctx.SetBody(body)
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/elysiandb/elysian-gate/internal/authz"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/ratelimit"
+	"github.com/elysiandb/elysian-gate/internal/schema"
+	"github.com/valyala/fasthttp"
+)
+
+func admit(ctx *fasthttp.RequestCtx, action authz.Action) bool {
+	entity := entityParam(ctx)
+	if !authz.Authorize(ctx, entity, action) {
+		return false
+	}
+
+	class := ratelimit.ClassWrite
+	if action == authz.ActionRead {
+		class = ratelimit.ClassRead
+	}
+	return ratelimit.Admit(ctx, entity, class)
+}
+
+func entityParam(ctx *fasthttp.RequestCtx) string {
+	entity, _ := ctx.UserValue("entity").(string)
+	return entity
+}
+
+func validBody(ctx *fasthttp.RequestCtx, partial bool) bool {
+	entity := entityParam(ctx)
+	body := ctx.PostBody()
+
+	if limit := maxBodyBytes(entity); limit > 0 && len(body) > limit {
+		writeJSON(ctx, fasthttp.StatusRequestEntityTooLarge, map[string]any{
+			"error": "payload too large",
+			"limit": limit,
+		})
+		return false
+	}
+
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		writeJSON(ctx, fasthttp.StatusBadRequest, map[string]any{
+			"error":      "invalid JSON",
+			"violations": []schema.Violation{{Path: "", Message: err.Error()}},
+		})
+		return false
+	}
+
+	s := schema.ForEntity(entity)
+	if s == nil {
+		return true
+	}
+	if violations := s.Validate(doc, partial); len(violations) > 0 {
+		writeJSON(ctx, fasthttp.StatusBadRequest, map[string]any{
+			"error":      "validation failed",
+			"violations": violations,
+		})
+		return false
+	}
+	return true
+}
+
+func maxBodyBytes(entity string) int {
+	cfg := configuration.Config.Gateway.Validation
+	if e, ok := cfg.Entities[entity]; ok && e.MaxBodyBytes > 0 {
+		return e.MaxBodyBytes
+	}
+	return cfg.MaxBodyBytes
+}
+
+func writeJSON(ctx *fasthttp.RequestCtx, status int, v any) {
+	data, _ := json.Marshal(v)
+	ctx.SetContentType("application/json")
+	ctx.SetStatusCode(status)
+	ctx.SetBody(data)
+}
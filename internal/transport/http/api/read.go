@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+
+	"github.com/elysiandb/elysian-gate/internal/accesslog"
+	"github.com/elysiandb/elysian-gate/internal/cache"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/sharding"
+	"github.com/valyala/fasthttp"
+)
+
+type readFunc func(ctx context.Context, path string, query string, vary ...string) (int, []byte, error)
+
+func cachedRead(ctx *fasthttp.RequestCtx, path string, query string, read readFunc) (int, []byte) {
+	vary := varyValues(ctx)
+
+	entity, id := sharding.ParseAPIPath(path)
+	if !cache.Enabled(entity) {
+		status, body, _ := read(accesslog.Context(ctx), path, query, vary...)
+		return status, body
+	}
+
+	key := cache.Key(path, query, vary...)
+	if entry, ok := cache.Get(key); ok {
+		ctx.Response.Header.Set("X-Cache", "HIT")
+		return entry.Status, entry.Body
+	}
+
+	generation := cache.Generation(entity)
+	status, body, err := read(accesslog.Context(ctx), path, query, vary...)
+	if err == nil && status == fasthttp.StatusOK {
+		cache.Put(key, entity, id, generation, cache.Entry{Status: status, Body: body})
+	}
+	ctx.Response.Header.Set("X-Cache", "MISS")
+	return status, body
+}
+
+func varyValues(ctx *fasthttp.RequestCtx) []string {
+	headers := configuration.Config.Gateway.Coalescing.VaryHeaders
+	if len(headers) == 0 {
+		return nil
+	}
+	values := make([]string, len(headers))
+	for i, h := range headers {
+		values[i] = string(ctx.Request.Header.Peek(h))
+	}
+	return values
+}
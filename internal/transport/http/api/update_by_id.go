@@ -1,20 +1,34 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 
+	"github.com/elysiandb/elysian-gate/internal/accesslog"
+	"github.com/elysiandb/elysian-gate/internal/authz"
 	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/breaker"
 	"github.com/valyala/fasthttp"
 )
 
 func UpdateByIdController(ctx *fasthttp.RequestCtx) {
+	if !admit(ctx, authz.ActionUpdate) || !validBody(ctx, true) {
+		return
+	}
+
 	path := string(ctx.Path())
 	if q := ctx.URI().QueryString(); len(q) > 0 {
 		path += "?" + string(q)
 	}
 
-	status, body, err := balancer.SendWriteRequestToMaster("PUT", path, string(ctx.PostBody()))
+	status, body, err := balancer.SendWriteRequestToMasterContext(accesslog.Context(ctx), "PUT", path, string(ctx.PostBody()))
 	if err != nil {
+		if errors.Is(err, breaker.ErrOpen) {
+			ctx.SetContentType("application/json")
+			ctx.SetStatusCode(status)
+			ctx.SetBody([]byte(body))
+			return
+		}
 		ctx.SetStatusCode(fasthttp.StatusBadGateway)
 		ctx.SetBody([]byte(fmt.Sprintf(`{"error":"%v"}`, err)))
 		return
@@ -0,0 +1,151 @@
+package accesslog_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/elysiandb/elysian-gate/internal/accesslog"
+	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/global"
+	"github.com/elysiandb/elysian-gate/internal/logger"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+	"github.com/elysiandb/elysian-gate/internal/requestid"
+	"github.com/elysiandb/elysian-gate/internal/transport/http/api"
+	"github.com/valyala/fasthttp"
+)
+
+type recorder struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func (r *recorder) server() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		r.ids = append(r.ids, req.Header.Get(requestid.Header))
+		r.mu.Unlock()
+		w.Write([]byte(`{"id":"1"}`))
+	}))
+}
+
+func (r *recorder) last() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.ids) == 0 {
+		return ""
+	}
+	return r.ids[len(r.ids)-1]
+}
+
+func node(name, role string, s *httptest.Server) global.Node {
+	addr := s.Listener.Addr().(*net.TCPAddr)
+	return global.Node{Name: name, Role: role, Ready: true, HTTP: global.Transport{Host: addr.IP.String(), Port: addr.Port}}
+}
+
+func setup(t *testing.T) (*recorder, string) {
+	configuration.Config = configuration.ElysianGateConfig{}
+	rec := &recorder{}
+	s := rec.server()
+	t.Cleanup(s.Close)
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{node("m", "master", s)})
+
+	logFile := filepath.Join(t.TempDir(), "access.log")
+	logger.Configure(logger.Options{Output: logFile, Format: "json"})
+	t.Cleanup(func() { logger.Configure(logger.Options{}) })
+	return rec, logFile
+}
+
+func serve(method, uri, requestID string, handler fasthttp.RequestHandler) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(method)
+	ctx.Request.SetRequestURI(uri)
+	if requestID != "" {
+		ctx.Request.Header.Set(requestid.Header, requestID)
+	}
+	if method != "GET" {
+		ctx.Request.SetBodyString(`{"title":"a"}`)
+	}
+	ctx.SetUserValue("entity", "articles")
+	accesslog.Middleware(handler)(ctx)
+	return ctx
+}
+
+func accessEntries(t *testing.T, path string) []map[string]any {
+	logger.Sync()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open log: %v", err)
+	}
+	defer f.Close()
+	var entries []map[string]any
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var m map[string]any
+		if json.Unmarshal(sc.Bytes(), &m) == nil && m["msg"] == "access" {
+			entries = append(entries, m)
+		}
+	}
+	return entries
+}
+
+func TestGeneratesAndForwardsRequestID(t *testing.T) {
+	rec, logFile := setup(t)
+	ctx := serve("GET", "/api/articles/1", "", api.GetByIdController)
+
+	id := string(ctx.Response.Header.Peek(requestid.Header))
+	if !requestid.Valid(id) {
+		t.Fatalf("expected a generated request id, got %q", id)
+	}
+	if rec.last() != id {
+		t.Fatalf("expected node to receive %q, got %q", id, rec.last())
+	}
+
+	entries := accessEntries(t, logFile)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 access entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e["request_id"] != id || e["method"] != "GET" || e["path"] != "/api/articles/1" || e["entity"] != "articles" ||
+		e["status"] != float64(200) || e["node"] != "m" || e["bytes"] == float64(0) || e["client"] == "" {
+		t.Fatalf("unexpected access entry: %v", e)
+	}
+}
+
+func TestKeepsValidInboundRequestID(t *testing.T) {
+	rec, _ := setup(t)
+	ctx := serve("POST", "/api/articles", "client-abc-123", api.CreateController)
+	if got := string(ctx.Response.Header.Peek(requestid.Header)); got != "client-abc-123" {
+		t.Fatalf("expected inbound id to be echoed, got %q", got)
+	}
+	if rec.last() != "client-abc-123" {
+		t.Fatalf("expected inbound id to be forwarded, got %q", rec.last())
+	}
+
+	ctx = serve("GET", "/api/articles/1", "bad id\n", api.GetByIdController)
+	if got := string(ctx.Response.Header.Peek(requestid.Header)); got == "bad id\n" || !requestid.Valid(got) {
+		t.Fatalf("expected invalid inbound id to be replaced, got %q", got)
+	}
+}
+
+func TestReplaysWritesWithRequestID(t *testing.T) {
+	setup(t)
+	slaveRec := &recorder{}
+	s := slaveRec.server()
+	defer s.Close()
+	nodes.ElysianCluster = nodes.NewCluster(append(nodes.ElysianCluster.Snapshot().Nodes, node("s", "slave", s)))
+
+	serve("PUT", "/api/articles/1", "write-42", api.UpdateByIdController)
+	balancer.SyncSlaves()
+
+	if slaveRec.last() != "write-42" {
+		t.Fatalf("expected slave replay to carry request id, got %q", slaveRec.last())
+	}
+}
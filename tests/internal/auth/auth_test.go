@@ -0,0 +1,167 @@
+package auth_test
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/auth"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/valyala/fasthttp"
+)
+
+func b64(v any) string {
+	data, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signHS256(secret string, claims map[string]any) string {
+	signed := b64(map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + b64(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	signed := b64(map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid}) + "." + b64(claims)
+	h := sha256.Sum256([]byte(signed))
+	sig, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, h[:])
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func setup(t *testing.T, cfg configuration.Auth) {
+	configuration.Config = configuration.ElysianGateConfig{}
+	cfg.Enabled = true
+	configuration.Config.Gateway.Auth = cfg
+	if err := auth.Init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+}
+
+func header(name, value string) *fasthttp.RequestHeader {
+	h := &fasthttp.RequestHeader{}
+	h.Set(name, value)
+	return h
+}
+
+func TestAPIKey(t *testing.T) {
+	setup(t, configuration.Auth{APIKeys: []configuration.APIKey{{Name: "svc", Key: "k1", Roles: []string{"reader"}}}})
+
+	p, err := auth.Authenticate(header("X-API-Key", "k1"))
+	if err != nil || p.Name != "svc" || p.Roles[0] != "reader" {
+		t.Fatalf("unexpected result %+v %v", p, err)
+	}
+	if _, err := auth.Authenticate(header("Authorization", "ApiKey k1")); err != nil {
+		t.Fatalf("expected ApiKey scheme to work: %v", err)
+	}
+	if _, err := auth.Authenticate(header("X-API-Key", "nope")); err == nil {
+		t.Fatalf("expected invalid key to be rejected")
+	}
+	if _, err := auth.Authenticate(&fasthttp.RequestHeader{}); err != auth.ErrMissingCredentials {
+		t.Fatalf("expected missing credentials, got %v", err)
+	}
+}
+
+func TestJWT_HMAC(t *testing.T) {
+	setup(t, configuration.Auth{JWT: configuration.JWT{HMACSecret: "secret", Issuer: "idp", Audience: "gate"}})
+	exp := float64(time.Now().Add(time.Hour).Unix())
+
+	token := signHS256("secret", map[string]any{"sub": "alice", "roles": []string{"admin"}, "iss": "idp", "aud": "gate", "exp": exp})
+	p, err := auth.Authenticate(header("Authorization", "Bearer "+token))
+	if err != nil || p.Name != "alice" || len(p.Roles) != 1 || p.Roles[0] != "admin" {
+		t.Fatalf("unexpected result %+v %v", p, err)
+	}
+
+	bad := []string{
+		signHS256("other", map[string]any{"iss": "idp", "aud": "gate", "exp": exp}),
+		signHS256("secret", map[string]any{"iss": "idp", "aud": "gate", "exp": float64(time.Now().Add(-time.Hour).Unix())}),
+		signHS256("secret", map[string]any{"iss": "evil", "aud": "gate", "exp": exp}),
+		signHS256("secret", map[string]any{"iss": "idp", "aud": []string{"other"}, "exp": exp}),
+		signHS256("secret", map[string]any{"iss": "idp", "aud": "gate"}),
+		signHS256("secret", map[string]any{"iss": "idp", "aud": "gate", "exp": "never"}),
+		signHS256("secret", map[string]any{"iss": "idp", "aud": "gate", "exp": exp, "nbf": "now"}),
+		b64(map[string]string{"alg": "none"}) + "." + b64(map[string]any{"sub": "x"}) + ".",
+		"not-a-token",
+	}
+	for i, tok := range bad {
+		if _, err := auth.Authenticate(header("Authorization", "Bearer "+tok)); err == nil {
+			t.Fatalf("expected token %d to be rejected", i)
+		}
+	}
+}
+
+func TestJWT_RSAAndJWKS(t *testing.T) {
+	pemKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwksKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	dir := t.TempDir()
+
+	der, _ := x509.MarshalPKIXPublicKey(&pemKey.PublicKey)
+	pemFile := filepath.Join(dir, "key.pem")
+	os.WriteFile(pemFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644)
+
+	jwksFile := filepath.Join(dir, "jwks.json")
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": "k1",
+		"n":   base64.RawURLEncoding.EncodeToString(jwksKey.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(jwksKey.E)).Bytes()),
+	}}})
+	os.WriteFile(jwksFile, jwks, 0644)
+
+	setup(t, configuration.Auth{JWT: configuration.JWT{RSAPublicKeyFile: pemFile, JWKSFile: jwksFile, RolesClaim: "scope", AllowMissingExp: true}})
+
+	p, err := auth.Authenticate(header("Authorization", "Bearer "+signRS256(pemKey, "", map[string]any{"sub": "svc", "scope": "read write"})))
+	if err != nil || len(p.Roles) != 2 {
+		t.Fatalf("unexpected PEM result %+v %v", p, err)
+	}
+	if _, err := auth.Authenticate(header("Authorization", "Bearer "+signRS256(jwksKey, "k1", map[string]any{"sub": "svc"}))); err != nil {
+		t.Fatalf("expected JWKS key to verify: %v", err)
+	}
+	if _, err := auth.Authenticate(header("Authorization", "Bearer "+signRS256(jwksKey, "", map[string]any{"sub": "svc"}))); err == nil {
+		t.Fatalf("expected token signed with a non-default key and no kid to be rejected")
+	}
+}
+
+func TestJWT_AllowMissingExp(t *testing.T) {
+	setup(t, configuration.Auth{JWT: configuration.JWT{HMACSecret: "secret", AllowMissingExp: true}})
+
+	if _, err := auth.Authenticate(header("Authorization", "Bearer "+signHS256("secret", map[string]any{"sub": "svc"}))); err != nil {
+		t.Fatalf("expected token without exp to be accepted: %v", err)
+	}
+	if _, err := auth.Authenticate(header("Authorization", "Bearer "+signHS256("secret", map[string]any{"sub": "svc", "exp": "soon"}))); err == nil {
+		t.Fatalf("expected non-numeric exp to be rejected")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	setup(t, configuration.Auth{APIKeys: []configuration.APIKey{{Name: "svc", Key: "k1"}}})
+
+	var seen *auth.Principal
+	handler := auth.Middleware(func(ctx *fasthttp.RequestCtx) {
+		seen = auth.FromContext(ctx)
+	})
+
+	ctx := &fasthttp.RequestCtx{}
+	handler(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusUnauthorized || seen != nil {
+		t.Fatalf("expected unauthenticated call to be rejected")
+	}
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.Header.Set("X-API-Key", "k1")
+	handler(ctx)
+	if seen == nil || seen.Name != "svc" {
+		t.Fatalf("expected principal to reach the handler")
+	}
+}
@@ -0,0 +1,95 @@
+package authz_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elysiandb/elysian-gate/internal/auth"
+	"github.com/elysiandb/elysian-gate/internal/authz"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/transport/http/api"
+	"github.com/valyala/fasthttp"
+)
+
+func setup() {
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.Authorization = configuration.Authorization{
+		Enabled: true,
+		Roles: map[string]configuration.Grants{
+			"writer":    {"*": {"read", "create", "update", "delete"}, "billing": {"read"}},
+			"admin":     {"*": {"*"}},
+			"anonymous": {"public": {"read"}},
+		},
+		Principals: map[string]configuration.Grants{
+			"apikey:billing-service": {"billing": {"read", "create", "update"}},
+		},
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	setup()
+	writer := &auth.Principal{Name: "svc", Roles: []string{"writer"}}
+	admin := &auth.Principal{Name: "root", Roles: []string{"admin"}}
+	billing := &auth.Principal{Name: "billing-service", Roles: []string{"writer"}, Method: "api-key"}
+	impostor := &auth.Principal{Name: "billing-service", Roles: []string{"writer"}, Method: "jwt"}
+
+	cases := []struct {
+		p      *auth.Principal
+		entity string
+		action authz.Action
+		want   bool
+	}{
+		{writer, "articles", authz.ActionCreate, true},
+		{writer, "articles", authz.ActionDestroy, false},
+		{writer, "billing", authz.ActionRead, true},
+		{writer, "billing", authz.ActionUpdate, false},
+		{billing, "billing", authz.ActionUpdate, true},
+		{billing, "billing", authz.ActionDelete, false},
+		{impostor, "billing", authz.ActionUpdate, false},
+		{admin, "billing", authz.ActionDestroy, true},
+		{admin, "", authz.ActionAdmin, true},
+		{writer, "", authz.ActionAdmin, false},
+		{nil, "public", authz.ActionRead, true},
+		{nil, "articles", authz.ActionRead, false},
+	}
+	for _, c := range cases {
+		if got := authz.Allowed(c.p, c.entity, c.action); got != c.want {
+			t.Fatalf("%+v %s %s: got %v, want %v", c.p, c.action, c.entity, got, c.want)
+		}
+	}
+}
+
+func TestDisabledAllowsEntitiesButNotAdmin(t *testing.T) {
+	configuration.Config = configuration.ElysianGateConfig{}
+	if !authz.Allowed(nil, "billing", authz.ActionDestroy) {
+		t.Fatalf("expected disabled authorization to allow")
+	}
+	if authz.Allowed(nil, "", authz.ActionAdmin) {
+		t.Fatalf("expected admin to need an explicit grant")
+	}
+
+	configuration.Config.Gateway.Authorization.Roles = map[string]configuration.Grants{"ops": {"*": {"admin"}}}
+	if !authz.Allowed(&auth.Principal{Name: "k", Roles: []string{"ops"}}, "", authz.ActionAdmin) {
+		t.Fatalf("expected an explicit admin grant to apply while authorization is disabled")
+	}
+	if authz.Allowed(nil, "", authz.ActionAdmin) {
+		t.Fatalf("expected anonymous admin to stay denied")
+	}
+}
+
+func TestControllerReturnsForbidden(t *testing.T) {
+	setup()
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod("DELETE")
+	ctx.Request.SetRequestURI("/api/billing")
+	ctx.SetUserValue("entity", "billing")
+
+	api.DestroyController(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusForbidden {
+		t.Fatalf("expected 403, got %d", ctx.Response.StatusCode())
+	}
+	if !strings.Contains(string(ctx.Response.Body()), "anonymous may not destroy billing") {
+		t.Fatalf("unexpected body %s", ctx.Response.Body())
+	}
+}
@@ -0,0 +1,308 @@
+package backup_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/backup"
+	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/changelog"
+	"github.com/elysiandb/elysian-gate/internal/cli"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/events"
+	"github.com/elysiandb/elysian-gate/internal/fakedb"
+	"github.com/elysiandb/elysian-gate/internal/global"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+	"github.com/elysiandb/elysian-gate/internal/routing"
+	"github.com/elysiandb/elysian-gate/internal/sharding"
+	"github.com/elysiandb/elysian-gate/internal/transport/http/admin"
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+)
+
+func newNode(t *testing.T) *fakedb.Server {
+	s := fakedb.New()
+	t.Cleanup(s.Close)
+	return s
+}
+
+func setup(t *testing.T) (*fakedb.Server, *fakedb.Server) {
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.Authorization.Roles = map[string]configuration.Grants{"anonymous": {"*": {"admin"}}}
+	master, slave := newNode(t), newNode(t)
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{master.Node("m", "master"), slave.Node("s", "slave")})
+	sharding.Init(nodes.ElysianCluster.Groups())
+	master.Put("articles", map[string]any{"id": "a1", "title": "one"}, map[string]any{"id": "a2", "title": "two"})
+	master.Put("users", map[string]any{"id": "u1"})
+	return master, slave
+}
+
+func TestCreateWritesVersionedArchive(t *testing.T) {
+	setup(t)
+	balancer.ResumeSeq(41)
+
+	var buf bytes.Buffer
+	m, err := backup.Create(t.Context(), &buf)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if m.Version != backup.FormatVersion || m.Seq < 41 || len(m.Groups) != 1 || m.Groups[0].Master != "m" {
+		t.Fatalf("unexpected manifest %+v", m)
+	}
+
+	read, files, err := backup.Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	entities := read.Groups[0].Entities
+	if len(entities) != 2 || entities[0].Type != "articles" || entities[0].Records != 2 || entities[1].Records != 1 {
+		t.Fatalf("unexpected entities %+v", entities)
+	}
+	if files[entities[0].File][1]["title"] != "two" {
+		t.Fatalf("unexpected records %+v", files)
+	}
+}
+
+func TestRestoreLoadsMasterAndResyncsSlaves(t *testing.T) {
+	master, slave := setup(t)
+	var buf bytes.Buffer
+	if _, err := backup.Create(t.Context(), &buf); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	want := master.Entities("articles")
+
+	master.Put("articles", map[string]any{"id": "a3", "title": "after"})
+	master.Put("orders", map[string]any{"id": "o1"})
+	balancer.SendWriteRequestToMaster("PUT", "/api/articles/a1", `{"id":"a1","title":"edited"}`)
+
+	configuration.Config.Gateway.ChangeFeed = configuration.ChangeFeed{Enabled: true, Dir: t.TempDir()}
+	if err := changelog.Init(); err != nil {
+		t.Fatalf("change log: %v", err)
+	}
+	t.Cleanup(changelog.Close)
+	sub := events.Subscribe(events.BackupRestored)
+	defer sub.Close()
+
+	report, err := backup.Restore(t.Context(), bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	changes, _, _ := changelog.Read(changelog.FirstSeq()-1, 10, nil)
+	if len(changes) != 3 || changes[2].Seq != report.ChangeSeq {
+		t.Fatalf("expected a restore change per touched type ending at %d, got %+v", report.ChangeSeq, changes)
+	}
+	for i, entity := range []string{"articles", "orders", "users"} {
+		if changes[i].Op != changelog.OpRestore || changes[i].Entity != entity {
+			t.Fatalf("unexpected restore change %+v", changes[i])
+		}
+	}
+	select {
+	case e := <-sub.C:
+		if e.Type != events.BackupRestored || e.Data["change_seq"] != report.ChangeSeq {
+			t.Fatalf("unexpected event %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected a restore event")
+	}
+	if report.Records["default/articles"] != 2 || report.Discarded != 1 || len(report.Resynced) != 1 || report.Resynced[0] != "s" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if len(report.Removed) != 1 || report.Removed[0] != "default/orders" {
+		t.Fatalf("expected orders to be removed, got %+v", report.Removed)
+	}
+	if got := master.Entities("orders"); len(got) != 0 {
+		t.Fatalf("expected orders to be gone from the master, got %+v", got)
+	}
+	if got := master.Entities("articles"); len(got) != 2 || got[1]["title"] != want[1]["title"] {
+		t.Fatalf("expected master articles to match the backup, got %+v", got)
+	}
+	if len(slave.Entities("articles")) != 2 || len(slave.Entities("users")) != 1 {
+		t.Fatalf("expected slave to be resynced from the restored master, got %v", slave.Types())
+	}
+	if n, _ := nodes.ElysianCluster.Snapshot().Node("s"); !n.Ready || n.Resyncing {
+		t.Fatalf("expected slave back in rotation, got %+v", n)
+	}
+	if balancer.PendingOps(global.DefaultGroup) != 0 {
+		t.Fatalf("expected pending operations to be discarded")
+	}
+}
+
+func TestRestoreRejectsBadArchives(t *testing.T) {
+	setup(t)
+	if _, err := backup.Restore(t.Context(), strings.NewReader("nope")); !errors.Is(err, backup.ErrInvalidArchive) {
+		t.Fatalf("expected invalid archive error, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	backup.Create(t.Context(), &buf)
+	other := newNode(t)
+	n := other.Node("other", "master")
+	n.Group = "elsewhere"
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{n})
+	if _, err := backup.Restore(t.Context(), bytes.NewReader(buf.Bytes())); !errors.Is(err, backup.ErrInvalidArchive) {
+		t.Fatalf("expected group mismatch to be rejected, got %v", err)
+	}
+	if len(other.Types()) != 0 {
+		t.Fatalf("expected nothing to be written on a rejected restore")
+	}
+}
+
+func TestBackupPausesWrites(t *testing.T) {
+	setup(t)
+	_, resume := balancer.PauseWrites()
+	done := make(chan struct{})
+	go func() {
+		balancer.SendWriteRequestToMaster("PUT", "/api/articles/a1", `{}`)
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatalf("expected write to wait while writes are paused")
+	case <-time.After(100 * time.Millisecond):
+	}
+	resume()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected write to proceed after resume")
+	}
+}
+
+func TestExportResumesWritesBeforeWritingArchive(t *testing.T) {
+	setup(t)
+	spool := t.TempDir()
+	configuration.Config.Gateway.Backup.SpoolDir = spool
+
+	a, err := backup.Export(t.Context())
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		balancer.SendWriteRequestToMaster("PUT", "/api/articles/a1", `{"title":"later"}`)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected writes to resume once the masters were read")
+	}
+
+	var buf bytes.Buffer
+	if err := a.Write(&buf); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	a.Close()
+	_, files, err := backup.Read(bytes.NewReader(buf.Bytes()))
+	if err != nil || len(files["groups/default/articles.jsonl"]) != 2 {
+		t.Fatalf("unexpected archive %v %v", files, err)
+	}
+	for _, rec := range files["groups/default/articles.jsonl"] {
+		if rec["title"] == "later" {
+			t.Fatalf("expected the archive to hold the data at its seq, got %v", rec)
+		}
+	}
+	if left, _ := os.ReadDir(spool); len(left) != 0 {
+		t.Fatalf("expected the export spool to be removed, got %v", left)
+	}
+}
+
+func TestCLIBackupAndRestoreThroughGateway(t *testing.T) {
+	master, _ := setup(t)
+	r := router.New()
+	r.GET("/admin/backup", admin.BackupController)
+	r.POST("/admin/restore", admin.RestoreController)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := &fasthttp.Server{Handler: routing.LimitBodies(r.Handler, 64), MaxRequestBodySize: 64, StreamRequestBody: true}
+	go server.Serve(ln)
+	t.Cleanup(func() { server.Shutdown() })
+	gateway := "http://" + ln.Addr().String()
+	archive := filepath.Join(t.TempDir(), "cluster.tar.gz")
+	spool := t.TempDir()
+	configuration.Config.Gateway.Backup.SpoolDir = spool
+
+	var out, errOut bytes.Buffer
+	if code := cli.Backup([]string{"--gateway", gateway, "--out", archive}, &out, &errOut); code != 0 {
+		t.Fatalf("backup exited %d: %s", code, errOut.String())
+	}
+	if !strings.Contains(out.String(), "1 group(s), 3 record(s)") {
+		t.Fatalf("unexpected backup output %q", out.String())
+	}
+
+	master.Put("articles", map[string]any{"id": "a9"})
+	out.Reset()
+	if code := cli.Restore([]string{"--gateway", gateway, "--in", archive}, &out, &errOut); code != 0 {
+		t.Fatalf("restore exited %d: %s", code, errOut.String())
+	}
+	if !strings.Contains(out.String(), "default/articles") || !strings.Contains(out.String(), "resynced s") {
+		t.Fatalf("unexpected restore output %q", out.String())
+	}
+	if len(master.Entities("articles")) != 2 {
+		t.Fatalf("expected restore to drop the extra article")
+	}
+	if left, _ := os.ReadDir(spool); len(left) != 0 {
+		t.Fatalf("expected the spooled upload to be removed, got %v", left)
+	}
+
+	if code := cli.Backup([]string{"--gateway", gateway}, &out, &errOut); code != 2 {
+		t.Fatalf("expected missing --out to be a usage error, got %d", code)
+	}
+}
+
+func TestRestoreUploadLimits(t *testing.T) {
+	setup(t)
+	configuration.Config.Gateway.Backup = configuration.Backup{MaxRestoreMB: 1, SpoolDir: t.TempDir()}
+	r := router.New()
+	r.GET("/admin/backup", admin.BackupController)
+	r.POST("/admin/restore", admin.RestoreController)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := &fasthttp.Server{Handler: routing.LimitBodies(r.Handler, 64), MaxRequestBodySize: 64, StreamRequestBody: true}
+	go server.Serve(ln)
+	t.Cleanup(func() { server.Shutdown() })
+
+	send := func(path string, header string, body []byte) int {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		defer conn.Close()
+		fmt.Fprintf(conn, "POST %s HTTP/1.1\r\nHost: gateway\r\n%s\r\n", path, header)
+		conn.Write(body)
+		resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		if err != nil {
+			t.Fatalf("post %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	chunk := func(n int) []byte {
+		return append(append([]byte(fmt.Sprintf("%x\r\n", n)), make([]byte, n)...), "\r\n"...)
+	}
+	if code := send("/admin/restore", "Content-Length: 2097152\r\n", make([]byte, 1024)); code != fasthttp.StatusRequestEntityTooLarge {
+		t.Fatalf("expected an oversized archive to be rejected, got %d", code)
+	}
+	if code := send("/admin/restore", "Transfer-Encoding: chunked\r\n", chunk(1<<20+1)); code != fasthttp.StatusRequestEntityTooLarge {
+		t.Fatalf("expected an oversized chunked archive to be rejected, got %d", code)
+	}
+	if code := send("/admin/backup", "Content-Length: 128\r\n", make([]byte, 128)); code != fasthttp.StatusRequestEntityTooLarge {
+		t.Fatalf("expected other routes to keep the body limit, got %d", code)
+	}
+	if code := send("/admin/backup", "Transfer-Encoding: chunked\r\n", chunk(128)); code != fasthttp.StatusRequestEntityTooLarge {
+		t.Fatalf("expected other routes to keep the body limit on chunked bodies, got %d", code)
+	}
+}
@@ -0,0 +1,122 @@
+package balancer_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/global"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+)
+
+func slowCountingServer(hits *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(hits, 1)
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte(`{"id":"1"}`))
+	}))
+}
+
+func concurrentReads(n int, vary func(i int) string) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			balancer.SendReadRequest("/api/articles/1", "", vary(i))
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestSendReadRequest_Coalesced(t *testing.T) {
+	var hits int32
+	s := slowCountingServer(&hits)
+	defer s.Close()
+
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.Coalescing.Enabled = true
+	configuration.Config.Gateway.Coalescing.Routes = []string{balancer.RouteGet}
+	defer resetSharding()
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{groupNode("m", "master", "", s)})
+
+	concurrentReads(20, func(int) string { return "" })
+	if atomic.LoadInt32(&hits) != 1 {
+		t.Fatalf("expected identical reads to share one upstream call, got %d", hits)
+	}
+
+	atomic.StoreInt32(&hits, 0)
+	concurrentReads(20, func(i int) string { return []string{"a", "b"}[i%2] })
+	if atomic.LoadInt32(&hits) != 2 {
+		t.Fatalf("expected one upstream call per vary value, got %d", hits)
+	}
+}
+
+func TestSendReadRequest_CoalescingDisabledForRoute(t *testing.T) {
+	var hits int32
+	s := slowCountingServer(&hits)
+	defer s.Close()
+
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.Coalescing.Enabled = true
+	configuration.Config.Gateway.Coalescing.Routes = []string{balancer.RouteList}
+	defer resetSharding()
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{groupNode("m", "master", "", s)})
+
+	concurrentReads(5, func(int) string { return "" })
+	if atomic.LoadInt32(&hits) != 5 {
+		t.Fatalf("expected uncoalesced reads, got %d upstream calls", hits)
+	}
+}
+
+func TestSendReadRequest_WriteForgetsInFlightRead(t *testing.T) {
+	var mu sync.Mutex
+	current := `{"id":"1","v":1}`
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			current = string(body)
+			mu.Unlock()
+			w.Write(body)
+			return
+		}
+		mu.Lock()
+		seen := current
+		mu.Unlock()
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte(seen))
+	}))
+	defer s.Close()
+
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.Coalescing.Enabled = true
+	defer resetSharding()
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{groupNode("m", "master", "", s)})
+
+	stale := make(chan string, 1)
+	go func() {
+		_, body, _ := balancer.SendReadRequest("/api/articles/1", "")
+		stale <- string(body)
+	}()
+	time.Sleep(30 * time.Millisecond)
+	if status, _, err := balancer.SendWriteRequestToMaster("PUT", "/api/articles/1", `{"id":"1","v":2}`); status != 200 || err != nil {
+		t.Fatalf("unexpected write result %d %v", status, err)
+	}
+
+	_, body, _ := balancer.SendReadRequest("/api/articles/1", "")
+	var doc map[string]any
+	if json.Unmarshal(body, &doc) != nil || doc["v"] != float64(2) {
+		t.Fatalf("expected read after write to see the write, got %s", body)
+	}
+	if json.Unmarshal([]byte(<-stale), &doc) != nil || doc["v"] != float64(1) {
+		t.Fatalf("expected the in-flight read to finish with the old value, got %v", doc)
+	}
+}
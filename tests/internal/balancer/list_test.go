@@ -0,0 +1,199 @@
+package balancer_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/global"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+)
+
+func listIDs(t *testing.T, body []byte) []string {
+	var items []map[string]any
+	if err := json.Unmarshal(body, &items); err != nil {
+		t.Fatalf("invalid list body %s: %v", body, err)
+	}
+	ids := []string{}
+	for _, it := range items {
+		ids = append(ids, it["id"].(string))
+	}
+	return ids
+}
+
+func TestSendListRequest_MergeSortPaginate(t *testing.T) {
+	var upstreamQuery string
+	var queryMu sync.Mutex
+	g1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queryMu.Lock()
+		upstreamQuery = r.URL.RawQuery
+		queryMu.Unlock()
+		w.Write([]byte(`[{"id":"a","value":3},{"id":"b","value":1}]`))
+	}))
+	defer g1.Close()
+	g2 := mockServer(200, `[{"id":"c","value":2},{"id":"d","value":4}]`, false)
+	defer g2.Close()
+	initShardedCluster(g1, g2)
+	defer resetSharding()
+
+	status, body, err := balancer.SendListRequest("/api/events", "sort[value]=desc&offset=1&limit=2")
+	if status != 200 || err != nil {
+		t.Fatalf("unexpected result %d %v", status, err)
+	}
+	ids := listIDs(t, body)
+	if len(ids) != 2 || ids[0] != "a" || ids[1] != "c" {
+		t.Fatalf("unexpected page %v", ids)
+	}
+	queryMu.Lock()
+	if upstreamQuery != "sort[value]=desc&limit=3" {
+		t.Fatalf("unexpected upstream query %q", upstreamQuery)
+	}
+	queryMu.Unlock()
+
+	status, body, _ = balancer.SendListRequest("/api/events", "sort[value]=asc")
+	ids = listIDs(t, body)
+	if status != 200 || len(ids) != 4 || ids[0] != "b" || ids[3] != "d" {
+		t.Fatalf("unexpected ascending order %v", ids)
+	}
+}
+
+func TestSendListRequest_FanoutDeduplicates(t *testing.T) {
+	full := mockServer(200, `[{"id":"a","date":"2023-01-02T00:00:00Z"},{"id":"b","date":"2023-01-01T00:00:00Z"}]`, false)
+	defer full.Close()
+	partial := mockServer(200, `[{"id":"a","date":"2023-01-02T00:00:00Z"}]`, false)
+	defer partial.Close()
+
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.List.Fanout = 2
+	defer resetSharding()
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{
+		groupNode("m", "master", "", full),
+		groupNode("s", "slave", "", partial),
+	})
+
+	status, body, err := balancer.SendListRequest("/api/articles", "sort[date]=asc")
+	if status != 200 || err != nil {
+		t.Fatalf("unexpected result %d %v", status, err)
+	}
+	ids := listIDs(t, body)
+	if len(ids) != 2 || ids[0] != "b" || ids[1] != "a" {
+		t.Fatalf("expected merged and deduplicated list, got %v", ids)
+	}
+}
+
+func TestSendListRequest_MemoryBudget(t *testing.T) {
+	g1 := mockServer(200, `[{"id":"a"},{"id":"b"},{"id":"c"}]`, false)
+	defer g1.Close()
+	g2 := mockServer(200, `[]`, false)
+	defer g2.Close()
+	initShardedCluster(g1, g2)
+	configuration.Config.Gateway.List.MaxItems = 2
+	defer resetSharding()
+
+	status, _, err := balancer.SendListRequest("/api/events", "")
+	if status != 507 || err == nil {
+		t.Fatalf("expected budget error, got %d %v", status, err)
+	}
+}
+
+func TestSendListRequest_BodyOverBudget(t *testing.T) {
+	g1 := mockServer(200, `[{"id":"a","text":"`+strings.Repeat("x", 4096)+`"}]`, false)
+	defer g1.Close()
+	g2 := mockServer(200, `[]`, false)
+	defer g2.Close()
+	initShardedCluster(g1, g2)
+	configuration.Config.Gateway.List.MaxBytes = 1024
+	defer resetSharding()
+
+	status, _, err := balancer.SendListRequest("/api/events", "")
+	if status != 507 || err == nil {
+		t.Fatalf("expected budget error while reading, got %d %v", status, err)
+	}
+}
+
+func TestSendListRequest_FanoutDeduplicatesWithoutID(t *testing.T) {
+	a := mockServer(200, `[{"v":1},{"v":1},{"v":2}]`, false)
+	defer a.Close()
+	b := mockServer(200, `[{"v":2},{"v":1}]`, false)
+	defer b.Close()
+
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.List.Fanout = 2
+	defer resetSharding()
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{
+		groupNode("m", "master", "", a),
+		groupNode("s", "slave", "", b),
+	})
+
+	status, body, err := balancer.SendListRequest("/api/articles", "")
+	if status != 200 || err != nil {
+		t.Fatalf("unexpected result %d %v", status, err)
+	}
+	var items []map[string]any
+	json.Unmarshal(body, &items)
+	if len(items) != 3 {
+		t.Fatalf("expected replica copies collapsed and real duplicates kept, got %s", body)
+	}
+}
+
+func TestSendListRequest_PassesNodeErrorThrough(t *testing.T) {
+	g1 := mockServer(404, `{"error":"unknown entity"}`, false)
+	defer g1.Close()
+	g2 := mockServer(200, `[]`, false)
+	defer g2.Close()
+	initShardedCluster(g1, g2)
+	defer resetSharding()
+
+	status, body, err := balancer.SendListRequest("/api/events", "")
+	if status != 404 || err != nil || string(body) != `{"error":"unknown entity"}` {
+		t.Fatalf("expected node answer passed through, got %d %s %v", status, body, err)
+	}
+}
+
+func TestSendListRequest_SingleReplicaPassthrough(t *testing.T) {
+	var upstreamQuery string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamQuery = r.URL.RawQuery
+		w.Write([]byte(`[{"id":"b"},{"id":"a"},{"id":"c"}]`))
+	}))
+	defer s.Close()
+
+	configuration.Config = configuration.ElysianGateConfig{}
+	defer resetSharding()
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{groupNode("m", "master", "", s)})
+
+	status, body, err := balancer.SendListRequest("/api/articles", "sort[id]=asc&offset=1&limit=1")
+	if status != 200 || err != nil {
+		t.Fatalf("unexpected result %d %v", status, err)
+	}
+	if upstreamQuery != "sort[id]=asc&offset=1&limit=1" {
+		t.Fatalf("expected the query to reach the node unchanged, got %q", upstreamQuery)
+	}
+	if ids := listIDs(t, body); len(ids) != 3 || ids[0] != "b" {
+		t.Fatalf("expected the node answer unchanged, got %v", ids)
+	}
+}
+
+func TestSendListRequest_NoDefaultBudget(t *testing.T) {
+	docs := make([]string, 12000)
+	for i := range docs {
+		docs[i] = fmt.Sprintf(`{"id":"%d"}`, i)
+	}
+	g1 := mockServer(200, "["+strings.Join(docs, ",")+"]", false)
+	defer g1.Close()
+	g2 := mockServer(200, `[]`, false)
+	defer g2.Close()
+	initShardedCluster(g1, g2)
+	defer resetSharding()
+
+	status, body, err := balancer.SendListRequest("/api/events", "")
+	if status != 200 || err != nil || len(listIDs(t, body)) != 12000 {
+		t.Fatalf("expected an unbudgeted list to be merged, got %d %v", status, err)
+	}
+}
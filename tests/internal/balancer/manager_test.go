@@ -1,14 +1,20 @@
 package balancer_test
 
 import (
+	"encoding/json"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
 	"github.com/elysiandb/elysian-gate/internal/global"
 	"github.com/elysiandb/elysian-gate/internal/nodes"
+	"github.com/elysiandb/elysian-gate/internal/sharding"
 )
 
 func mockServer(status int, body string, fail bool) *httptest.Server {
@@ -35,11 +41,9 @@ func TestSendReadRequest_AllFail(t *testing.T) {
 	s := mockServer(500, "err", true)
 	defer s.Close()
 	addr := s.Listener.Addr().(*net.TCPAddr)
-	nodes.ElysianCluster = &nodes.Cluster{
-		Nodes: []global.Node{
-			{Name: "m1", Role: "master", Ready: true, HTTP: global.Transport{Host: addr.IP.String(), Port: addr.Port}},
-		},
-	}
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{
+		{Name: "m1", Role: "master", Ready: true, HTTP: global.Transport{Host: addr.IP.String(), Port: addr.Port}},
+	})
 	status, _, err := balancer.SendReadRequest("/api", "")
 	if status != 502 || err == nil {
 		t.Fail()
@@ -50,11 +54,9 @@ func TestSendReadRequest_Success(t *testing.T) {
 	s := mockServer(200, `{"ok":true}`, false)
 	defer s.Close()
 	addr := s.Listener.Addr().(*net.TCPAddr)
-	nodes.ElysianCluster = &nodes.Cluster{
-		Nodes: []global.Node{
-			{Name: "m1", Role: "master", Ready: true, HTTP: global.Transport{Host: addr.IP.String(), Port: addr.Port}},
-		},
-	}
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{
+		{Name: "m1", Role: "master", Ready: true, HTTP: global.Transport{Host: addr.IP.String(), Port: addr.Port}},
+	})
 	status, body, err := balancer.SendReadRequest("/api", "")
 	if status != 200 || err != nil || len(body) == 0 {
 		t.Fail()
@@ -73,11 +75,9 @@ func TestSendWriteRequestToMaster_Success(t *testing.T) {
 	s := mockServer(200, `{"id":"1"}`, false)
 	defer s.Close()
 	addr := s.Listener.Addr().(*net.TCPAddr)
-	nodes.ElysianCluster = &nodes.Cluster{
-		Nodes: []global.Node{
-			{Name: "master", Role: "master", Ready: true, HTTP: global.Transport{Host: addr.IP.String(), Port: addr.Port}},
-		},
-	}
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{
+		{Name: "master", Role: "master", Ready: true, HTTP: global.Transport{Host: addr.IP.String(), Port: addr.Port}},
+	})
 	status, body, err := balancer.SendWriteRequestToMaster("POST", "/api", "{}")
 	if status != 200 || err != nil || body == "" {
 		t.Fail()
@@ -85,11 +85,9 @@ func TestSendWriteRequestToMaster_Success(t *testing.T) {
 }
 
 func TestGetReadRequestNodes_NoPending(t *testing.T) {
-	nodes.ElysianCluster = &nodes.Cluster{
-		Nodes: []global.Node{
-			{Name: "m", Role: "master", Ready: true},
-		},
-	}
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{
+		{Name: "m", Role: "master", Ready: true},
+	})
 	res := balancer.GetReadRequestNodes()
 	if len(res) == 0 {
 		t.Fail()
@@ -102,7 +100,7 @@ func TestGetReadRequestNodes_WithSlavesAndMaster(t *testing.T) {
 	addr := s.Listener.Addr().(*net.TCPAddr)
 	master := global.Node{Name: "master", Role: "master", Ready: true, HTTP: global.Transport{Host: addr.IP.String(), Port: addr.Port}}
 	slave := global.Node{Name: "slave", Role: "slave", Ready: true, HTTP: global.Transport{Host: addr.IP.String(), Port: addr.Port}}
-	nodes.ElysianCluster = &nodes.Cluster{Nodes: []global.Node{master, slave}}
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{master, slave})
 	res := balancer.GetReadRequestNodes()
 	if len(res) < 1 {
 		t.Fail()
@@ -120,7 +118,188 @@ func TestSyncSlaves_WithOps(t *testing.T) {
 	addr := s.Listener.Addr().(*net.TCPAddr)
 	master := global.Node{Name: "master", Role: "master", Ready: true}
 	slave := global.Node{Name: "slave", Role: "slave", Ready: true, HTTP: global.Transport{Host: addr.IP.String(), Port: addr.Port}}
-	nodes.ElysianCluster = &nodes.Cluster{Nodes: []global.Node{master, slave}}
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{master, slave})
 	balancer.SendWriteRequestToMaster("POST", "/api", "{}")
 	balancer.SyncSlaves()
 }
+
+func groupNode(name, role, group string, s *httptest.Server) global.Node {
+	addr := s.Listener.Addr().(*net.TCPAddr)
+	return global.Node{Name: name, Role: role, Group: group, Ready: true, HTTP: global.Transport{Host: addr.IP.String(), Port: addr.Port}}
+}
+
+func initShardedCluster(g1, g2 *httptest.Server) {
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.Sharding = configuration.Sharding{
+		DefaultGroup:   "g1",
+		Entities:       map[string]string{"billing": "g2"},
+		HashedEntities: []string{"events"},
+	}
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{
+		groupNode("m1", "master", "g1", g1),
+		groupNode("m2", "master", "g2", g2),
+	})
+	sharding.Init(nodes.ElysianCluster.Groups())
+}
+
+func resetSharding() {
+	configuration.Config = configuration.ElysianGateConfig{}
+	sharding.Init(nil)
+	balancer.DiscardPending(global.DefaultGroup)
+}
+
+func TestSendReadRequest_ScatterGatherHashedList(t *testing.T) {
+	g1 := mockServer(200, `[{"id":"a"}]`, false)
+	defer g1.Close()
+	g2 := mockServer(200, `[{"id":"b"},{"id":"c"}]`, false)
+	defer g2.Close()
+	initShardedCluster(g1, g2)
+	defer resetSharding()
+
+	status, body, err := balancer.SendReadRequest("/api/events", "")
+	if status != 200 || err != nil {
+		t.Fatalf("unexpected result %d %v", status, err)
+	}
+	var items []map[string]any
+	if err := json.Unmarshal(body, &items); err != nil || len(items) != 3 {
+		t.Fatalf("expected 3 merged items, got %s", body)
+	}
+}
+
+func TestSendWriteRequestToMaster_RoutesMappedEntity(t *testing.T) {
+	var hits1, hits2 int32
+	g1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&hits1, 1)
+		w.Write([]byte(`{}`))
+	}))
+	defer g1.Close()
+	g2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&hits2, 1)
+		w.Write([]byte(`{}`))
+	}))
+	defer g2.Close()
+	initShardedCluster(g1, g2)
+	defer resetSharding()
+
+	if status, _, err := balancer.SendWriteRequestToMaster("PUT", "/api/billing/1", `{"a":1}`); status != 200 || err != nil {
+		t.Fatalf("unexpected result %d %v", status, err)
+	}
+	if atomic.LoadInt32(&hits1) != 0 || atomic.LoadInt32(&hits2) != 1 {
+		t.Fatalf("expected write on g2 only, got g1=%d g2=%d", hits1, hits2)
+	}
+
+	if status, _, err := balancer.SendWriteRequestToMaster("DELETE", "/api/events", ""); status != 200 || err != nil {
+		t.Fatalf("unexpected result %d %v", status, err)
+	}
+	if atomic.LoadInt32(&hits1) != 1 || atomic.LoadInt32(&hits2) != 2 {
+		t.Fatalf("expected destroy broadcast to every group, got g1=%d g2=%d", hits1, hits2)
+	}
+}
+
+func TestSendWriteRequestToMaster_HashedCreateAssignsID(t *testing.T) {
+	var received string
+	var recvMu sync.Mutex
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		recvMu.Lock()
+		received = string(data)
+		recvMu.Unlock()
+		w.Write(data)
+	})
+	g1 := httptest.NewServer(handler)
+	defer g1.Close()
+	g2 := httptest.NewServer(handler)
+	defer g2.Close()
+	initShardedCluster(g1, g2)
+	defer resetSharding()
+
+	status, _, err := balancer.SendWriteRequestToMaster("POST", "/api/events", `{"title":"x"}`)
+	if status != 200 || err != nil {
+		t.Fatalf("unexpected result %d %v", status, err)
+	}
+	recvMu.Lock()
+	defer recvMu.Unlock()
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(received), &doc); err != nil || doc["id"] == "" || doc["id"] == nil {
+		t.Fatalf("expected gateway-assigned id, got %s", received)
+	}
+}
+
+func TestSendWriteRequestToMaster_HashedCreateKeepsNonStringID(t *testing.T) {
+	var received string
+	var recvMu sync.Mutex
+	handler := func(group string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			data, _ := io.ReadAll(r.Body)
+			recvMu.Lock()
+			received = group + " " + string(data)
+			recvMu.Unlock()
+			w.Write(data)
+		}
+	}
+	g1 := httptest.NewServer(handler("g1"))
+	defer g1.Close()
+	g2 := httptest.NewServer(handler("g2"))
+	defer g2.Close()
+	initShardedCluster(g1, g2)
+	defer resetSharding()
+
+	status, _, err := balancer.SendWriteRequestToMaster("POST", "/api/events", `{"id":42,"title":"x"}`)
+	if status != 200 || err != nil {
+		t.Fatalf("unexpected result %d %v", status, err)
+	}
+	recvMu.Lock()
+	defer recvMu.Unlock()
+	if want := sharding.GroupFor("events", "42") + ` {"id":42,"title":"x"}`; received != want {
+		t.Fatalf("expected numeric id to be kept and routed by its value, got %q want %q", received, want)
+	}
+}
+
+func TestSendWriteRequestToMaster_HashedCreateNullID(t *testing.T) {
+	var received string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		received = string(data)
+		w.Write(data)
+	})
+	g1 := httptest.NewServer(handler)
+	defer g1.Close()
+	g2 := httptest.NewServer(handler)
+	defer g2.Close()
+	initShardedCluster(g1, g2)
+	defer resetSharding()
+
+	if status, _, err := balancer.SendWriteRequestToMaster("POST", "/api/events", `{"id":null}`); status != 200 || err != nil {
+		t.Fatalf("unexpected result %d %v", status, err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(received), &doc); err != nil {
+		t.Fatalf("unexpected body %s", received)
+	}
+	if id, ok := doc["id"].(string); !ok || id == "" {
+		t.Fatalf("expected gateway-assigned id for null id, got %s", received)
+	}
+}
+
+func TestSendWriteRequestToMaster_HashedCreateRejectsNonObject(t *testing.T) {
+	hits := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	})
+	g1 := httptest.NewServer(handler)
+	defer g1.Close()
+	g2 := httptest.NewServer(handler)
+	defer g2.Close()
+	initShardedCluster(g1, g2)
+	defer resetSharding()
+
+	for _, payload := range []string{`[{"id":"a"}]`, `"x"`, `null`} {
+		status, body, err := balancer.SendWriteRequestToMaster("POST", "/api/events", payload)
+		if status != 400 || err != nil || body != `{"error":"payload must be a JSON object"}` {
+			t.Fatalf("%s: expected 400, got %d %s %v", payload, status, body, err)
+		}
+	}
+	if hits != 0 {
+		t.Fatalf("expected rejected payloads not to be forwarded, got %d", hits)
+	}
+}
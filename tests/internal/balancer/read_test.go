@@ -0,0 +1,115 @@
+package balancer_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/global"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+)
+
+func delayedServer(delay time.Duration, status int, hits *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(`{"id":"1"}`))
+	}))
+}
+
+func readCluster(group string, slave, master *httptest.Server, reads configuration.Reads) {
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.Reads = reads
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{
+		groupNode("s-"+group, "slave", group, slave),
+		groupNode("m-"+group, "master", group, master),
+	})
+}
+
+func TestSendGroupReadRequest_NotFoundIsNotRetried(t *testing.T) {
+	var slaveHits, masterHits int32
+	slave := delayedServer(0, 404, &slaveHits)
+	defer slave.Close()
+	master := delayedServer(0, 200, &masterHits)
+	defer master.Close()
+	readCluster("r404", slave, master, configuration.Reads{})
+	defer resetSharding()
+
+	status, _, err := balancer.SendGroupReadRequest("r404", "/api/articles/1", "")
+	if status != 404 || err != nil {
+		t.Fatalf("expected 404 to be returned as is, got %d %v", status, err)
+	}
+	if atomic.LoadInt32(&masterHits) != 0 {
+		t.Fatalf("expected 404 not to be retried on master")
+	}
+}
+
+func TestSendGroupReadRequest_RetriesServerErrors(t *testing.T) {
+	var slaveHits, masterHits int32
+	slave := delayedServer(0, 503, &slaveHits)
+	defer slave.Close()
+	master := delayedServer(0, 200, &masterHits)
+	defer master.Close()
+	readCluster("r503", slave, master, configuration.Reads{})
+	defer resetSharding()
+
+	status, _, err := balancer.SendGroupReadRequest("r503", "/api/articles/1", "")
+	if status != 200 || err != nil {
+		t.Fatalf("expected retry to succeed, got %d %v", status, err)
+	}
+	if atomic.LoadInt32(&slaveHits) != 1 || atomic.LoadInt32(&masterHits) != 1 {
+		t.Fatalf("expected one attempt per node")
+	}
+}
+
+func TestSendGroupReadRequest_Hedged(t *testing.T) {
+	var slaveHits, masterHits int32
+	slave := delayedServer(time.Second, 200, &slaveHits)
+	defer slave.Close()
+	master := delayedServer(0, 200, &masterHits)
+	defer master.Close()
+	readCluster("rhedge", slave, master, configuration.Reads{
+		Hedging: configuration.Hedging{Enabled: true, MinDelayMs: 10, MaxDelayMs: 20},
+	})
+	defer resetSharding()
+
+	start := time.Now()
+	status, _, err := balancer.SendGroupReadRequest("rhedge", "/api/articles/1", "")
+	if status != 200 || err != nil {
+		t.Fatalf("unexpected result %d %v", status, err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected hedged read to finish early, took %v", elapsed)
+	}
+	if atomic.LoadInt32(&masterHits) != 1 {
+		t.Fatalf("expected hedged request on master")
+	}
+}
+
+func TestSendGroupReadRequest_LatencyBudget(t *testing.T) {
+	var slaveHits, masterHits int32
+	slave := delayedServer(time.Second, 200, &slaveHits)
+	defer slave.Close()
+	master := delayedServer(time.Second, 200, &masterHits)
+	defer master.Close()
+	readCluster("rbudget", slave, master, configuration.Reads{BudgetMs: 50})
+	defer resetSharding()
+
+	start := time.Now()
+	status, _, err := balancer.SendGroupReadRequest("rbudget", "/api/articles/1", "")
+	if status != 504 || err == nil {
+		t.Fatalf("expected budget error, got %d %v", status, err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected budget to cut the read short, took %v", elapsed)
+	}
+}
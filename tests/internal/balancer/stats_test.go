@@ -0,0 +1,142 @@
+package balancer_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/global"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+	"github.com/elysiandb/elysian-gate/internal/sharding"
+)
+
+func opsCluster(group string, master, slave *httptest.Server) {
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.Sharding.DefaultGroup = group
+	m := groupNode("ops-master-"+group, "master", group, master)
+	m.HTTP.Up = true
+	s := groupNode("ops-slave-"+group, "slave", group, slave)
+	s.HTTP.Up = true
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{m, s})
+	sharding.Init(nodes.ElysianCluster.Groups())
+}
+
+func TestDrainedSlaveGetsNoReads(t *testing.T) {
+	s := mockServer(200, `{}`, false)
+	defer s.Close()
+	opsCluster("drain", s, s)
+	defer resetSharding()
+
+	if err := nodes.ElysianCluster.Drain("ops-slave-drain", true); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	list := balancer.GetGroupReadRequestNodes("drain")
+	if len(list) != 1 || list[0].Name != "ops-master-drain" {
+		t.Fatalf("expected only the master to serve reads, got %d nodes", len(list))
+	}
+
+	nodes.ElysianCluster.Drain("ops-master-drain", true)
+	if list := balancer.GetGroupReadRequestNodes("drain"); len(list) != 1 {
+		t.Fatalf("expected a drained master to still serve reads as last resort")
+	}
+	if err := nodes.ElysianCluster.Drain("missing", true); err == nil {
+		t.Fatalf("expected unknown node to be rejected")
+	}
+}
+
+func TestReplicationLagAndRate(t *testing.T) {
+	master := mockServer(200, `{}`, false)
+	defer master.Close()
+	slave := mockServer(500, "", true)
+	defer slave.Close()
+	opsCluster("lag", master, slave)
+	defer resetSharding()
+
+	balancer.SendWriteRequestToMaster("PUT", "/api/lag/1", `{}`)
+	balancer.SendWriteRequestToMaster("PUT", "/api/lag/2", `{}`)
+	balancer.SyncSlaves()
+
+	if got := balancer.PendingOps("lag"); got != 2 {
+		t.Fatalf("expected 2 pending ops, got %d", got)
+	}
+	if got := balancer.ReplicationLag("ops-slave-lag", "lag"); got != 2 {
+		t.Fatalf("expected slave to lag 2 ops, got %d", got)
+	}
+	if balancer.RequestRate("ops-master-lag") <= 0 {
+		t.Fatalf("expected master request rate to be recorded")
+	}
+}
+
+func TestPromoteNode(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	slave := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if !healthy.Load() {
+			http.Error(w, "fail", 500)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer slave.Close()
+	master := mockServer(200, `{}`, false)
+	defer master.Close()
+	opsCluster("promote", master, slave)
+	defer resetSharding()
+
+	healthy.Store(false)
+	balancer.SendWriteRequestToMaster("PUT", "/api/promote/1", `{}`)
+	if err := balancer.PromoteNode("ops-slave-promote"); err == nil {
+		t.Fatalf("expected promotion to be refused while ops are unsynced")
+	}
+
+	healthy.Store(true)
+	if err := balancer.PromoteNode("ops-slave-promote"); err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+	m := nodes.GetGroupMasterNode("promote")
+	if m == nil || m.Name != "ops-slave-promote" {
+		t.Fatalf("expected slave to become master, got %+v", m)
+	}
+	for _, n := range nodes.ElysianCluster.Snapshot().Nodes {
+		if n.Name == "ops-master-promote" && (n.Role != "slave" || n.Ready) {
+			t.Fatalf("expected old master to become a slave awaiting resync, got %+v", n)
+		}
+	}
+}
+
+func TestPromoteNodeHoldsWritesUntilNewMasterIsLive(t *testing.T) {
+	var oldMasterWrites atomic.Int64
+	slave := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(150 * time.Millisecond)
+		w.Write([]byte(`{}`))
+	}))
+	defer slave.Close()
+	master := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/race/2" {
+			oldMasterWrites.Add(1)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer master.Close()
+	opsCluster("race", master, slave)
+	defer resetSharding()
+
+	balancer.SendWriteRequestToMaster("PUT", "/api/race/1", `{}`)
+	done := make(chan error)
+	go func() { done <- balancer.PromoteNode("ops-slave-race") }()
+	time.Sleep(50 * time.Millisecond)
+	if status, _, err := balancer.SendWriteRequestToMaster("PUT", "/api/race/2", `{}`); err != nil || status != 200 {
+		t.Fatalf("write during promotion: %d %v", status, err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+	if oldMasterWrites.Load() != 0 {
+		t.Fatalf("expected the write issued during promotion to reach the new master")
+	}
+	balancer.DiscardPending("race")
+}
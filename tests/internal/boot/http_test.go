@@ -0,0 +1,34 @@
+package boot_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/elysiandb/elysian-gate/internal/boot"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+)
+
+func TestInitHTTPFailsOnBadTLS(t *testing.T) {
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.HTTP.Host = "127.0.0.1"
+	configuration.Config.Gateway.HTTP.TLS = configuration.ServerTLS{Enabled: true, CertFile: "missing.pem", KeyFile: "missing.key"}
+	if err := boot.InitHTTP(); err == nil || !strings.Contains(err.Error(), "invalid TLS settings") {
+		t.Fatalf("expected bad TLS settings to fail startup, got %v", err)
+	}
+}
+
+func TestInitHTTPFailsWhenPortIsTaken(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.HTTP.Host = "127.0.0.1"
+	configuration.Config.Gateway.HTTP.Port = ln.Addr().(*net.TCPAddr).Port
+	if err := boot.InitHTTP(); err == nil {
+		t.Fatalf("expected a taken port to fail startup")
+	}
+}
@@ -0,0 +1,144 @@
+package breaker_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/breaker"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/global"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+	"github.com/elysiandb/elysian-gate/internal/transport/http/api"
+	"github.com/valyala/fasthttp"
+)
+
+func setup(cfg configuration.CircuitBreaker) {
+	configuration.Config = configuration.ElysianGateConfig{}
+	cfg.Enabled = true
+	configuration.Config.Gateway.CircuitBreaker = cfg
+	breaker.Reset()
+}
+
+func TestDisabledAlwaysAllows(t *testing.T) {
+	configuration.Config = configuration.ElysianGateConfig{}
+	breaker.Reset()
+	for i := 0; i < 10; i++ {
+		breaker.Record("n", breaker.Failure)
+	}
+	if !breaker.Allow("n") || !breaker.Available("n") {
+		t.Fatalf("expected disabled breaker to allow requests")
+	}
+}
+
+func TestOpensOnConsecutiveFailures(t *testing.T) {
+	setup(configuration.CircuitBreaker{ConsecutiveFailures: 3})
+	breaker.Record("n", breaker.Failure)
+	breaker.Record("n", breaker.Failure)
+	if breaker.GetState("n") != breaker.Closed {
+		t.Fatalf("expected breaker to stay closed")
+	}
+	breaker.Record("n", breaker.Failure)
+	if breaker.GetState("n") != breaker.Open || breaker.Allow("n") || breaker.Available("n") {
+		t.Fatalf("expected breaker to open after 3 consecutive failures")
+	}
+}
+
+func TestOpensOnErrorRate(t *testing.T) {
+	setup(configuration.CircuitBreaker{ConsecutiveFailures: 100, ErrorRate: 0.5, MinRequests: 4})
+	breaker.Record("n", breaker.Success)
+	breaker.Record("n", breaker.Failure)
+	breaker.Record("n", breaker.Success)
+	if breaker.GetState("n") != breaker.Closed {
+		t.Fatalf("expected breaker to stay closed below min requests")
+	}
+	breaker.Record("n", breaker.Failure)
+	if breaker.GetState("n") != breaker.Open {
+		t.Fatalf("expected breaker to open at 50%% error rate")
+	}
+}
+
+func TestHalfOpenProbes(t *testing.T) {
+	setup(configuration.CircuitBreaker{ConsecutiveFailures: 1, OpenSeconds: 1})
+	breaker.Record("n", breaker.Failure)
+	if breaker.Allow("n") {
+		t.Fatalf("expected open breaker to reject")
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	if !breaker.Allow("n") {
+		t.Fatalf("expected a probe to be allowed after the open period")
+	}
+	if breaker.GetState("n") != breaker.HalfOpen || breaker.Allow("n") {
+		t.Fatalf("expected a single half-open probe")
+	}
+	breaker.Record("n", breaker.Failure)
+	if breaker.GetState("n") != breaker.Open {
+		t.Fatalf("expected failed probe to reopen the breaker")
+	}
+
+	breaker.Probe("n")
+	if !breaker.Allow("n") {
+		t.Fatalf("expected health probe to allow traffic probing")
+	}
+	breaker.Record("n", breaker.Success)
+	if breaker.GetState("n") != breaker.Closed {
+		t.Fatalf("expected successful probe to close the breaker")
+	}
+}
+
+func TestTrip(t *testing.T) {
+	setup(configuration.CircuitBreaker{})
+	breaker.Trip("n")
+	if breaker.GetState("n") != breaker.Open {
+		t.Fatalf("expected trip to open the breaker")
+	}
+}
+
+func TestOpenNodesAreNotSelectedForReads(t *testing.T) {
+	setup(configuration.CircuitBreaker{})
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{
+		{Name: "m", Role: "master", Ready: true},
+		{Name: "s1", Role: "slave", Ready: true},
+		{Name: "s2", Role: "slave", Ready: true},
+	})
+	breaker.Trip("s1")
+
+	for _, n := range balancer.GetReadRequestNodes() {
+		if n.Name == "s1" {
+			t.Fatalf("expected node with open breaker to be skipped")
+		}
+	}
+	if len(balancer.GetReadRequestNodes()) != 2 {
+		t.Fatalf("expected s2 and master to be selected")
+	}
+}
+
+func TestWriteControllersReturnOpenBreakerStatus(t *testing.T) {
+	setup(configuration.CircuitBreaker{})
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{
+		{Name: "m", Role: "master", Ready: true},
+	})
+	breaker.Trip("m")
+
+	controllers := map[string]fasthttp.RequestHandler{
+		"POST":    api.CreateController,
+		"PUT":     api.UpdateByIdController,
+		"DELETE":  api.DeleteByIdController,
+		"DESTROY": api.DestroyController,
+	}
+	for name, controller := range controllers {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI("/api/articles/1")
+		ctx.Request.SetBodyString(`{"title":"x"}`)
+		ctx.SetUserValue("entity", "articles")
+		controller(ctx)
+
+		if ctx.Response.StatusCode() != fasthttp.StatusServiceUnavailable {
+			t.Fatalf("%s: expected 503, got %d", name, ctx.Response.StatusCode())
+		}
+		if string(ctx.Response.Body()) != `{"error":"circuit breaker open"}` {
+			t.Fatalf("%s: unexpected body %s", name, ctx.Response.Body())
+		}
+	}
+}
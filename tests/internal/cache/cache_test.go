@@ -0,0 +1,131 @@
+package cache_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/cache"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/global"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+)
+
+func setup(cfg configuration.Cache) {
+	configuration.Config = configuration.ElysianGateConfig{}
+	cfg.Enabled = true
+	configuration.Config.Gateway.Cache = cfg
+	cache.Reset()
+}
+
+func put(key, entity, id string) {
+	cache.Put(key, entity, id, cache.Generation(entity), cache.Entry{Status: 200, Body: []byte("x")})
+}
+
+func TestGetPutAndStats(t *testing.T) {
+	setup(configuration.Cache{})
+	if _, ok := cache.Get("/api/a/1"); ok {
+		t.Fatalf("expected miss on empty cache")
+	}
+	put("/api/a/1", "a", "1")
+	entry, ok := cache.Get("/api/a/1")
+	if !ok || entry.Status != 200 || string(entry.Body) != "x" {
+		t.Fatalf("expected hit, got %v %v", entry, ok)
+	}
+	st := cache.GetStats()
+	if st.Hits != 1 || st.Misses != 1 || st.Entries != 1 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+}
+
+func TestEvictsLeastRecentlyUsed(t *testing.T) {
+	setup(configuration.Cache{MaxEntries: 2})
+	put("/api/a/1", "a", "1")
+	put("/api/a/2", "a", "2")
+	cache.Get("/api/a/1")
+	put("/api/a/3", "a", "3")
+
+	if _, ok := cache.Get("/api/a/2"); ok {
+		t.Fatalf("expected /api/a/2 to be evicted")
+	}
+	if _, ok := cache.Get("/api/a/1"); !ok {
+		t.Fatalf("expected /api/a/1 to survive")
+	}
+	if cache.GetStats().Evictions != 1 {
+		t.Fatalf("expected one eviction")
+	}
+}
+
+func TestPerEntityTTLAndToggle(t *testing.T) {
+	off := false
+	setup(configuration.Cache{Entities: map[string]configuration.EntityCache{
+		"hot":     {TTLSeconds: 1},
+		"billing": {Enabled: &off},
+	}})
+	if cache.Enabled("billing") || !cache.Enabled("hot") {
+		t.Fatalf("unexpected per-entity enablement")
+	}
+	put("/api/hot/1", "hot", "1")
+	time.Sleep(1100 * time.Millisecond)
+	if _, ok := cache.Get("/api/hot/1"); ok {
+		t.Fatalf("expected entry to expire")
+	}
+}
+
+func TestInvalidation(t *testing.T) {
+	setup(configuration.Cache{})
+	put("/api/a/1", "a", "1")
+	put("/api/a/2", "a", "2")
+	put("/api/a", "a", "")
+	put("/api/b", "b", "")
+
+	cache.InvalidateID("a", "1")
+	if _, ok := cache.Get("/api/a/1"); ok {
+		t.Fatalf("expected /api/a/1 to be invalidated")
+	}
+	if _, ok := cache.Get("/api/a"); ok {
+		t.Fatalf("expected list of a to be invalidated")
+	}
+	if _, ok := cache.Get("/api/a/2"); !ok {
+		t.Fatalf("expected /api/a/2 to survive")
+	}
+
+	cache.InvalidateEntity("a")
+	if _, ok := cache.Get("/api/a/2"); ok {
+		t.Fatalf("expected entity a to be fully invalidated")
+	}
+	if _, ok := cache.Get("/api/b"); !ok {
+		t.Fatalf("expected entity b to survive")
+	}
+}
+
+func TestStalePutIsDropped(t *testing.T) {
+	setup(configuration.Cache{})
+	gen := cache.Generation("a")
+	cache.InvalidateID("a", "1")
+	cache.Put("/api/a/1", "a", "1", gen, cache.Entry{Status: 200, Body: []byte("old")})
+	if _, ok := cache.Get("/api/a/1"); ok {
+		t.Fatalf("expected read started before a write not to be cached")
+	}
+}
+
+func TestWriteThroughBalancerInvalidates(t *testing.T) {
+	setup(configuration.Cache{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer s.Close()
+	addr := s.Listener.Addr().(*net.TCPAddr)
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{
+		{Name: "m", Role: "master", Ready: true, HTTP: global.Transport{Host: addr.IP.String(), Port: addr.Port}},
+	})
+
+	put("/api/a/1", "a", "1")
+	balancer.SendWriteRequestToMaster("PUT", "/api/a/1", `{}`)
+	if _, ok := cache.Get("/api/a/1"); ok {
+		t.Fatalf("expected write to invalidate cached entry")
+	}
+}
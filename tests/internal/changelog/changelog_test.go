@@ -0,0 +1,339 @@
+package changelog_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/changelog"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/global"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+	"github.com/elysiandb/elysian-gate/internal/transport/http/api"
+	"github.com/valyala/fasthttp"
+)
+
+func open(t *testing.T, dir string, cfg configuration.ChangeFeed) {
+	t.Helper()
+	configuration.Config = configuration.ElysianGateConfig{}
+	cfg.Enabled = true
+	cfg.Dir = dir
+	configuration.Config.Gateway.ChangeFeed = cfg
+	if err := changelog.Init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	t.Cleanup(changelog.Close)
+}
+
+func appendN(t *testing.T, from int64, n int, data string) {
+	t.Helper()
+	for i := int64(0); i < int64(n); i++ {
+		entity := "articles"
+		if (from+i)%2 == 0 {
+			entity = "users"
+		}
+		c := changelog.Change{Seq: from + i, Op: changelog.OpUpdate, Entity: entity, ID: fmt.Sprint(from + i), Group: "default", Data: json.RawMessage(data)}
+		if err := changelog.Append(c); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+}
+
+func TestReadFiltersAndCursor(t *testing.T) {
+	open(t, t.TempDir(), configuration.ChangeFeed{})
+	appendN(t, 1, 1500, `{}`)
+
+	onlyUsers := func(c changelog.Change) bool { return c.Entity == "users" }
+	changes, cursor, err := changelog.Read(0, 3, onlyUsers)
+	if err != nil || len(changes) != 3 || changes[0].Seq != 2 || changes[2].Seq != 6 || cursor != 6 {
+		t.Fatalf("unexpected read from disk: %+v cursor %d err %v", changes, cursor, err)
+	}
+
+	changes, cursor, err = changelog.Read(1490, 100, onlyUsers)
+	if err != nil || len(changes) != 5 || changes[0].Seq != 1492 || cursor != 1500 {
+		t.Fatalf("unexpected read from tail: %+v cursor %d err %v", changes, cursor, err)
+	}
+
+	changes, cursor, _ = changelog.Read(1500, 100, nil)
+	if len(changes) != 0 || cursor != 1500 {
+		t.Fatalf("expected nothing after the last change")
+	}
+}
+
+func TestRecoversAfterRestartAndTornWrite(t *testing.T) {
+	dir := t.TempDir()
+	open(t, dir, configuration.ChangeFeed{Fsync: true})
+	appendN(t, 1, 3, `{"title":"x"}`)
+	changelog.Close()
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "changes-*.jsonl"))
+	if len(segments) != 1 {
+		t.Fatalf("expected one segment, got %v", segments)
+	}
+	f, _ := os.OpenFile(segments[0], os.O_WRONLY|os.O_APPEND, 0o644)
+	f.WriteString(`{"seq":4,"op":"upd`)
+	f.Close()
+
+	open(t, dir, configuration.ChangeFeed{})
+	if changelog.LastSeq() != 3 {
+		t.Fatalf("expected last seq 3 after recovery, got %d", changelog.LastSeq())
+	}
+	appendN(t, 4, 1, `{}`)
+	changes, _, err := changelog.Read(0, 10, nil)
+	if err != nil || len(changes) != 4 || changes[3].Seq != 4 || string(changes[0].Data) != `{"title":"x"}` {
+		t.Fatalf("unexpected changes after recovery: %+v %v", changes, err)
+	}
+}
+
+func TestRetentionDropsOldSegments(t *testing.T) {
+	dir := t.TempDir()
+	open(t, dir, configuration.ChangeFeed{SegmentMB: 1, MaxSegments: 2})
+	big := `{"blob":"` + strings.Repeat("x", 200<<10) + `"}`
+	appendN(t, 1, 16, big)
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "changes-*.jsonl"))
+	if len(segments) != 2 {
+		t.Fatalf("expected 2 retained segments, got %d", len(segments))
+	}
+	first := changelog.FirstSeq()
+	if first <= 1 {
+		t.Fatalf("expected oldest changes to be dropped, first seq %d", first)
+	}
+	if _, _, err := changelog.Read(0, 10, nil); !errors.Is(err, changelog.ErrTruncated) {
+		t.Fatalf("expected truncated error, got %v", err)
+	}
+	if changes, _, err := changelog.Read(first-1, 1, nil); err != nil || changes[0].Seq != first {
+		t.Fatalf("expected to read from the first retained change: %v", err)
+	}
+}
+
+func TestReadFailsWhenSegmentIsRemovedMidRead(t *testing.T) {
+	dir := t.TempDir()
+	open(t, dir, configuration.ChangeFeed{SegmentMB: 1, MaxSegments: 10})
+	appendN(t, 1, 1200, `{"blob":"`+strings.Repeat("x", 2<<10)+`"}`)
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "changes-*.jsonl"))
+	if len(segments) < 2 {
+		t.Fatalf("expected several segments, got %v", segments)
+	}
+	removeNext := func(c changelog.Change) bool {
+		os.Remove(segments[1])
+		return true
+	}
+	changes, cursor, err := changelog.Read(0, 2000, removeNext)
+	if !errors.Is(err, changelog.ErrTruncated) || changes != nil || cursor != 0 {
+		t.Fatalf("expected truncated error, got %d changes cursor %d err %v", len(changes), cursor, err)
+	}
+}
+
+func cluster(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method == "POST" {
+			w.Write([]byte(`{"id":"a1","title":"hello"}`))
+			return
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(s.Close)
+	addr := s.Listener.Addr().(*net.TCPAddr)
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{
+		{Name: "m", Role: "master", Ready: true, HTTP: global.Transport{Host: addr.IP.String(), Port: addr.Port}},
+	})
+}
+
+func serve(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := &fasthttp.Server{Handler: api.ChangesController, WriteTimeout: 300 * time.Millisecond}
+	go server.Serve(ln)
+	t.Cleanup(func() { server.Shutdown() })
+	return "http://" + ln.Addr().String() + "/changes"
+}
+
+type page struct {
+	Changes []changelog.Change `json:"changes"`
+	LastSeq int64              `json:"last_seq"`
+}
+
+func get(t *testing.T, url string) (int, page) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+	var p page
+	json.NewDecoder(resp.Body).Decode(&p)
+	return resp.StatusCode, p
+}
+
+func TestWritesFeedTheChangeEndpoint(t *testing.T) {
+	open(t, t.TempDir(), configuration.ChangeFeed{})
+	cluster(t)
+	url := serve(t)
+
+	balancer.SendWriteRequestToMaster("POST", "/api/articles", `{"title":"hello"}`)
+	balancer.SendWriteRequestToMaster("PUT", "/api/articles/a1", `{"title":"bye"}`)
+	balancer.SendWriteRequestToMaster("PUT", "/api/users/u1", `{"name":"x"}`)
+	balancer.SendWriteRequestToMaster("DELETE", "/api/articles/a1", ``)
+
+	status, p := get(t, url+"?entity=articles")
+	if status != 200 || len(p.Changes) != 3 {
+		t.Fatalf("expected 3 article changes, got %d %+v", status, p)
+	}
+	ops := []string{p.Changes[0].Op, p.Changes[1].Op, p.Changes[2].Op}
+	if strings.Join(ops, ",") != "create,update,delete" || p.Changes[0].ID != "a1" || p.Changes[2].Data != nil {
+		t.Fatalf("unexpected changes %+v", p.Changes)
+	}
+	if p.Changes[0].Seq >= p.Changes[1].Seq || p.LastSeq != changelog.LastSeq() {
+		t.Fatalf("expected ordered changes and cursor at the end, got %+v", p)
+	}
+
+	_, p = get(t, fmt.Sprintf("%s?from=%d&limit=1", url, p.Changes[1].Seq))
+	if len(p.Changes) != 1 || p.Changes[0].Op != "update" || p.Changes[0].Entity != "articles" {
+		t.Fatalf("expected from to be inclusive, got %+v", p.Changes)
+	}
+
+	since := changelog.LastSeq()
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		balancer.SendWriteRequestToMaster("PUT", "/api/articles/a2", `{"title":"late"}`)
+	}()
+	start := time.Now()
+	_, p = get(t, fmt.Sprintf("%s?since=%d&wait=5", url, since))
+	if len(p.Changes) != 1 || p.Changes[0].ID != "a2" || time.Since(start) > 3*time.Second {
+		t.Fatalf("expected long poll to return the next write, got %+v", p)
+	}
+
+	if status, _ := get(t, url+"?since=abc"); status != 400 {
+		t.Fatalf("expected bad cursor to be rejected, got %d", status)
+	}
+}
+
+func TestChangeStreamOverSSE(t *testing.T) {
+	open(t, t.TempDir(), configuration.ChangeFeed{})
+	cluster(t)
+	api.ChangesHeartbeat = 100 * time.Millisecond
+	url := serve(t)
+
+	balancer.SendWriteRequestToMaster("PUT", "/api/articles/a1", `{"title":"one"}`)
+	since := changelog.LastSeq()
+
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Last-Event-ID", fmt.Sprint(since))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer resp.Body.Close()
+
+	lines := make(chan string, 16)
+	go func() {
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+	}()
+
+	time.Sleep(500 * time.Millisecond)
+	balancer.SendWriteRequestToMaster("DELETE", "/api/articles/a1", ``)
+
+	want := fmt.Sprintf("id: %d", since+1)
+	deadline := time.After(3 * time.Second)
+	for {
+		select {
+		case line := <-lines:
+			if strings.HasPrefix(line, "id: ") {
+				if line != want {
+					t.Fatalf("expected %q, got %q", want, line)
+				}
+				if next := <-lines; next != "event: delete" {
+					t.Fatalf("expected delete event, got %q", next)
+				}
+				return
+			}
+		case <-deadline:
+			t.Fatalf("no change streamed")
+		}
+	}
+}
+
+func TestResumeSeq(t *testing.T) {
+	dir := t.TempDir()
+	open(t, dir, configuration.ChangeFeed{})
+	appendN(t, 1_000_000, 1, `{}`)
+	changelog.Close()
+
+	open(t, dir, configuration.ChangeFeed{})
+	cluster(t)
+	balancer.ResumeSeq(changelog.LastSeq())
+	balancer.SendWriteRequestToMaster("PUT", "/api/articles/a1", `{}`)
+	if changelog.LastSeq() != 1_000_001 {
+		t.Fatalf("expected sequence to continue after restart, got %d", changelog.LastSeq())
+	}
+}
+
+func TestConcurrentWritesAreLoggedInOrder(t *testing.T) {
+	open(t, t.TempDir(), configuration.ChangeFeed{})
+	cluster(t)
+	balancer.ResumeSeq(changelog.LastSeq())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			balancer.SendWriteRequestToMaster("PUT", fmt.Sprintf("/api/articles/a%d", i), `{}`)
+		}(i)
+	}
+	wg.Wait()
+
+	changes, _, err := changelog.Read(changelog.FirstSeq()-1, 100, nil)
+	if err != nil || len(changes) != 50 {
+		t.Fatalf("expected every write in the log, got %d %v", len(changes), err)
+	}
+	for i := 1; i < len(changes); i++ {
+		if changes[i].Seq != changes[i-1].Seq+1 {
+			t.Fatalf("expected seqs in order, got %d after %d", changes[i].Seq, changes[i-1].Seq)
+		}
+	}
+}
+
+func TestFailedAppendMovesFirstSeqPastTheGap(t *testing.T) {
+	dir := t.TempDir()
+	open(t, dir, configuration.ChangeFeed{SegmentMB: 1, MaxSegments: 10})
+	big := `{"blob":"` + strings.Repeat("x", 200<<10) + `"}`
+	appendN(t, 1, 6, big)
+
+	os.RemoveAll(dir)
+	changelog.Enqueue(changelog.Change{Seq: 7, Op: changelog.OpUpdate, Entity: "articles", ID: "7", Group: "default"})
+	changelog.Flush()
+	os.MkdirAll(dir, 0o755)
+	appendN(t, 8, 1, `{}`)
+
+	if first := changelog.FirstSeq(); first != 8 {
+		t.Fatalf("expected first seq after the lost change, got %d", first)
+	}
+	if _, _, err := changelog.Read(3, 10, nil); !errors.Is(err, changelog.ErrTruncated) {
+		t.Fatalf("expected readers from before the gap to be told to resync, got %v", err)
+	}
+	if changes, _, err := changelog.Read(7, 10, nil); err != nil || len(changes) != 1 || changes[0].Seq != 8 {
+		t.Fatalf("expected to resume after the gap, got %+v %v", changes, err)
+	}
+}
@@ -0,0 +1,299 @@
+package cli_test
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/elysiandb/elysian-gate/internal/cli"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/global"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+	"github.com/elysiandb/elysian-gate/internal/sharding"
+	"github.com/elysiandb/elysian-gate/internal/transport/http/admin"
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+)
+
+func gateway(t *testing.T) string {
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.Authorization.Roles = map[string]configuration.Grants{"anonymous": {"*": {"admin"}}}
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{
+		{Name: "m", Role: "master", Group: "default", Ready: true, HTTP: global.Transport{Up: true}, TCP: global.Transport{Up: true}},
+		{Name: "s", Role: "slave", Group: "default", Ready: true, HTTP: global.Transport{Up: true}, TCP: global.Transport{Up: true}},
+	})
+	sharding.Init(nodes.ElysianCluster.Groups())
+
+	r := router.New()
+	r.GET("/admin/cluster", admin.ClusterController)
+	r.POST("/admin/nodes/{name}/{action}", admin.NodeActionController)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := &fasthttp.Server{Handler: r.Handler}
+	go server.Serve(ln)
+	t.Cleanup(func() { server.Shutdown() })
+	return "http://" + ln.Addr().String()
+}
+
+func run(args ...string) (int, string, string) {
+	var out, errOut bytes.Buffer
+	code := cli.Run(args, &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestStatusPrintsCluster(t *testing.T) {
+	gw := gateway(t)
+
+	code, out, errOut := run("status", "--gateway", gw)
+	if code != 0 {
+		t.Fatalf("status exited %d: %s", code, errOut)
+	}
+	if !strings.Contains(out, "NODE") || !strings.Contains(out, "master") || !strings.Contains(out, "PENDING OPS") {
+		t.Fatalf("unexpected status output %q", out)
+	}
+
+	code, out, _ = run("status", "--gateway", gw, "--json")
+	if code != 0 || !strings.Contains(out, `"name": "s"`) {
+		t.Fatalf("expected raw JSON, got %d %q", code, out)
+	}
+}
+
+func TestNodeActions(t *testing.T) {
+	gw := gateway(t)
+
+	if code, _, errOut := run("drain", "s", "--gateway", gw); code != 0 {
+		t.Fatalf("drain exited %d: %s", code, errOut)
+	}
+	if n, _ := nodes.ElysianCluster.Snapshot().Node("s"); !n.Drained {
+		t.Fatalf("expected s to be drained")
+	}
+	if code, _, errOut := run("drain", "--gateway", gw, "--undo", "s"); code != 0 {
+		t.Fatalf("undrain exited %d: %s", code, errOut)
+	}
+	if n, _ := nodes.ElysianCluster.Snapshot().Node("s"); n.Drained {
+		t.Fatalf("expected s to be undrained")
+	}
+
+	code, out, errOut := run("promote", "s", "--gateway", gw)
+	if code != 0 || !strings.Contains(out, "s: promote ok") {
+		t.Fatalf("promote exited %d: %s %s", code, out, errOut)
+	}
+	if m, ok := nodes.ElysianCluster.Snapshot().Master("default"); !ok || m.Name != "s" {
+		t.Fatalf("expected s to be master, got %+v", m)
+	}
+
+	code, _, errOut = run("resync", "ghost", "--gateway", gw)
+	if code != 1 || !strings.Contains(errOut, "404") {
+		t.Fatalf("expected unknown node to fail with 404, got %d %q", code, errOut)
+	}
+	if code, _, _ := run("resync", "--gateway", gw); code != 2 {
+		t.Fatalf("expected missing node name to be a usage error, got %d", code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.yaml")
+	os.WriteFile(good, []byte(`
+nodes:
+  one:
+    role: master
+    http: {host: 127.0.0.1, port: 8089}
+    tcp: {host: 127.0.0.1, port: 8088}
+gateway:
+  http: {host: 0.0.0.0, port: 8899}
+`), 0o644)
+	code, out, errOut := run("validate", "--config", good)
+	if code != 0 || !strings.Contains(out, "OK (1 nodes)") {
+		t.Fatalf("expected valid config, got %d %q %q", code, out, errOut)
+	}
+
+	bad := filepath.Join(dir, "bad.yaml")
+	os.WriteFile(bad, []byte(`
+nodes:
+  one:
+    role: leader
+    http: {host: 127.0.0.1, port: 8089}
+    tcp: {host: 127.0.0.1, port: 0}
+  two:
+    role: slave
+    http: {host: 127.0.0.1, port: 8089}
+    tcp: {host: 127.0.0.1, port: 8090}
+gateway:
+  log: {level: loud}
+  http:
+    tls: {enabled: true, requireClientCert: true}
+  authorization:
+    principals:
+      billing-service: { billing: [read] }
+      jwt:billing-service: { billing: [read] }
+`), 0o644)
+	code, _, errOut = run("validate", "--config", bad)
+	if code != 1 {
+		t.Fatalf("expected invalid config to fail, got %d", code)
+	}
+	for _, want := range []string{"expected master or slave", "invalid tcp port", "127.0.0.1:8089", "log:", "requireClientCert needs clientCAFile", `principal "billing-service"`} {
+		if !strings.Contains(errOut, want) {
+			t.Fatalf("expected %q in %q", want, errOut)
+		}
+	}
+}
+
+func TestValidateReportsEveryGroupProblem(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "groups.yaml")
+	os.WriteFile(file, []byte(`
+nodes:
+  a1:
+    role: master
+    group: a
+    http: {host: 127.0.0.1, port: 8001}
+    tcp: {host: 127.0.0.1, port: 9001}
+  a2:
+    role: master
+    group: a
+    http: {host: 127.0.0.1, port: 8002}
+    tcp: {host: 127.0.0.1, port: 9002}
+  b1:
+    role: slave
+    group: b
+    http: {host: 127.0.0.1, port: 8003}
+    tcp: {host: 127.0.0.1, port: 9003}
+gateway:
+  sharding:
+    defaultGroup: c
+    entities:
+      orders: x
+      articles: y
+`), 0o644)
+	code, _, errOut := run("validate", "--config", file)
+	if code != 1 {
+		t.Fatalf("expected invalid config to fail, got %d", code)
+	}
+	last := -1
+	for _, want := range []string{
+		"group a must have exactly one master, found 2",
+		"group b must have exactly one master, found 0",
+		"default group c has no nodes",
+		"entity articles is mapped to unknown group y",
+		"entity orders is mapped to unknown group x",
+	} {
+		i := strings.Index(errOut, want)
+		if i <= last {
+			t.Fatalf("expected %q after the previous problem in %q", want, errOut)
+		}
+		last = i
+	}
+}
+
+func TestBench(t *testing.T) {
+	var reads, writes atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			reads.Add(1)
+		case "PUT":
+			writes.Add(1)
+		}
+		w.Write([]byte(`{"id":"x"}`))
+	}))
+	defer srv.Close()
+
+	code, out, errOut := run("bench", "--gateway", srv.URL, "--duration", "200ms", "--keys", "5", "--concurrency", "4", "--write-ratio", "0.5")
+	if code != 0 {
+		t.Fatalf("bench exited %d: %s", code, errOut)
+	}
+	if reads.Load() == 0 || writes.Load() == 0 {
+		t.Fatalf("expected both reads and writes, got %d/%d", reads.Load(), writes.Load())
+	}
+	if !strings.Contains(out, "req/s") || !strings.Contains(out, "p99") {
+		t.Fatalf("unexpected bench output %q", out)
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	code, _, errOut := run("frobnicate")
+	if code != 2 || !strings.Contains(errOut, "Commands:") {
+		t.Fatalf("expected usage error, got %d %q", code, errOut)
+	}
+	if code, out, _ := run("help"); code != 0 || !strings.Contains(out, "validate") {
+		t.Fatalf("expected help to list commands")
+	}
+}
+
+func TestClearAsksForConfirmation(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "gate.yaml")
+	os.WriteFile(config, []byte(`
+nodes:
+  one: {role: master, store: {folder: `+filepath.Join(dir, "db-1")+`}}
+  two: {role: slave, store: {folder: `+filepath.Join(dir, "db-2")+`}}
+`), 0o644)
+	for _, d := range []string{"db-1", "db-2"} {
+		os.MkdirAll(filepath.Join(dir, d), 0o755)
+		os.WriteFile(filepath.Join(dir, d, "data"), []byte("x"), 0o644)
+	}
+	defer func() { cli.Stdin = os.Stdin }()
+
+	cli.Stdin = strings.NewReader("n\n")
+	if code, _, errOut := run("clear", "--config", config); code != 1 || !strings.Contains(errOut, "aborted") {
+		t.Fatalf("expected refusal to abort, got %d %q", code, errOut)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "db-1")); err != nil {
+		t.Fatalf("expected data to survive an aborted clear")
+	}
+
+	cli.Stdin = strings.NewReader("y\n")
+	code, out, errOut := run("clear", "two", "--config", config)
+	if code != 0 || !strings.Contains(out, "db-2: removed 1 file(s)") {
+		t.Fatalf("expected node two to be cleared, got %d %q %q", code, out, errOut)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "db-1")); err != nil {
+		t.Fatalf("expected node one to be untouched")
+	}
+
+	code, out, _ = run("clear", "--config", config, "--yes")
+	if code != 0 || !strings.Contains(out, "db-2: nothing to remove") || !strings.Contains(out, "db-1: removed") {
+		t.Fatalf("unexpected clear output %d %q", code, out)
+	}
+}
+
+func TestGenConfigs(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "gate.yaml")
+	os.WriteFile(config, []byte(`
+nodes:
+  one:
+    role: master
+    http: {host: 127.0.0.1, port: 8090}
+    tcp: {host: 127.0.0.1, port: 8890}
+gateway:
+  nodeConfigDir: `+filepath.Join(dir, "nodes")+`
+  nodeDefaults:
+    store:
+      folder: `+filepath.Join(dir, "data-{node}")+`
+`), 0o644)
+
+	if code, out, _ := run("gen-configs", "--config", config, "--check"); code != 1 || !strings.Contains(out, "out of date") {
+		t.Fatalf("expected --check to fail before generation, got %d %q", code, out)
+	}
+	code, out, errOut := run("gen-configs", "--config", config)
+	if code != 0 || !strings.Contains(out, "one.yaml: written") {
+		t.Fatalf("gen-configs exited %d: %q %q", code, out, errOut)
+	}
+	if code, out, _ := run("gen-configs", "--config", config, "--check"); code != 0 || !strings.Contains(out, "up to date") {
+		t.Fatalf("expected --check to pass after generation, got %d %q", code, out)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "nodes", "one.yaml"))
+	if !strings.Contains(string(data), filepath.Join(dir, "data-one")) {
+		t.Fatalf("expected generated store folder, got %s", data)
+	}
+}
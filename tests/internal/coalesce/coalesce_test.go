@@ -0,0 +1,126 @@
+package coalesce_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/coalesce"
+)
+
+func TestDo_SharesInFlightCall(t *testing.T) {
+	var g coalesce.Group
+	var calls int32
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	results := make([]coalesce.Result, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = g.Do("k", func() (int, []byte, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return 200, []byte("ok"), nil
+			})
+		}(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("expected a single upstream call, got %d", calls)
+	}
+	for _, r := range results {
+		if r.Status != 200 || string(r.Body) != "ok" || !r.Shared {
+			t.Fatalf("unexpected result %+v", r)
+		}
+	}
+}
+
+func TestDo_SequentialCallsAreNotShared(t *testing.T) {
+	var g coalesce.Group
+	var calls int
+	for i := 0; i < 3; i++ {
+		r := g.Do("k", func() (int, []byte, error) {
+			calls++
+			return 500, nil, errors.New("boom")
+		})
+		if r.Shared || r.Err == nil {
+			t.Fatalf("unexpected result %+v", r)
+		}
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestDo_PanicBecomesError(t *testing.T) {
+	var g coalesce.Group
+	started := make(chan struct{})
+	waiter := make(chan coalesce.Result)
+	go func() {
+		<-started
+		waiter <- g.Do("k", func() (int, []byte, error) { return 200, nil, nil })
+	}()
+
+	r := g.Do("k", func() (int, []byte, error) {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		panic("boom")
+	})
+	if r.Err == nil {
+		t.Fatalf("expected the panic as an error, got %+v", r)
+	}
+	if w := <-waiter; w.Err == nil || !w.Shared {
+		t.Fatalf("expected the waiter to get the error, got %+v", w)
+	}
+	if r := g.Do("k", func() (int, []byte, error) { return 200, nil, nil }); r.Err != nil || r.Status != 200 {
+		t.Fatalf("expected the key to be usable after a panic, got %+v", r)
+	}
+}
+
+func TestForget_StartsAFreshCall(t *testing.T) {
+	var g coalesce.Group
+	release := make(chan struct{})
+	first := make(chan coalesce.Result)
+	go func() {
+		first <- g.Do("k", func() (int, []byte, error) {
+			<-release
+			return 200, []byte("old"), nil
+		})
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	g.Forget(func(key string) bool { return key == "k" })
+	second := make(chan coalesce.Result)
+	release2 := make(chan struct{})
+	go func() {
+		second <- g.Do("k", func() (int, []byte, error) {
+			<-release2
+			return 200, []byte("new"), nil
+		})
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	if r := <-first; string(r.Body) != "old" {
+		t.Fatalf("unexpected first result %+v", r)
+	}
+
+	joined := make(chan coalesce.Result)
+	go func() {
+		joined <- g.Do("k", func() (int, []byte, error) { return 200, []byte("third"), nil })
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release2)
+	if r := <-second; string(r.Body) != "new" || r.Shared != true {
+		t.Fatalf("unexpected second result %+v", r)
+	}
+	if r := <-joined; string(r.Body) != "new" {
+		t.Fatalf("expected a later caller to join the fresh call, got %+v", r)
+	}
+}
@@ -0,0 +1,112 @@
+package dashboard_test
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/dashboard"
+	"github.com/elysiandb/elysian-gate/internal/global"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+)
+
+func cluster(t *testing.T) {
+	configuration.Config = configuration.ElysianGateConfig{}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(s.Close)
+	addr := s.Listener.Addr().(*net.TCPAddr)
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{
+		{Name: "s2", Role: "slave", Ready: true},
+		{Name: "m", Role: "master", Ready: true, HTTP: global.Transport{Host: addr.IP.String(), Port: addr.Port, Up: true}, TCP: global.Transport{Up: true}},
+		{Name: "s1", Role: "slave", Ready: false},
+	})
+}
+
+func TestParseMode(t *testing.T) {
+	for _, m := range []string{"auto", "headless", "lines", "tui"} {
+		if _, err := dashboard.ParseMode(m); err != nil {
+			t.Fatalf("expected %s to be valid: %v", m, err)
+		}
+	}
+	if _, err := dashboard.ParseMode("fancy"); err == nil {
+		t.Fatalf("expected unknown mode to be rejected")
+	}
+}
+
+func TestStartStopsWithContext(t *testing.T) {
+	cluster(t)
+	for _, m := range []dashboard.Mode{dashboard.ModeHeadless, dashboard.ModeLines} {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := dashboard.Start(ctx, m)
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expected %s dashboard to stop with its context", m)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	cluster(t)
+	balancer.SendWriteRequestToMaster("PUT", "/api/a/1", `{}`)
+
+	v := dashboard.Collect()
+	if len(v.Nodes) != 3 || v.Nodes[0].Name != "m" || v.Nodes[1].Name != "s1" || v.Nodes[2].Name != "s2" {
+		t.Fatalf("expected master first then slaves by name, got %+v", v.Nodes)
+	}
+	if v.Nodes[0].QPS <= 0 {
+		t.Fatalf("expected master QPS to be reported")
+	}
+	if v.Nodes[1].LagLabel() != "resync" || v.Nodes[2].LagLabel() != "1" || v.Nodes[0].LagLabel() != "-" {
+		t.Fatalf("unexpected lag labels: %s %s %s", v.Nodes[0].LagLabel(), v.Nodes[1].LagLabel(), v.Nodes[2].LagLabel())
+	}
+	if len(v.Groups) != 1 || v.Groups[0].PendingOps != 1 {
+		t.Fatalf("unexpected pending ops: %+v", v.Groups)
+	}
+}
+
+func TestLineWriterOnlyPrintsChanges(t *testing.T) {
+	cluster(t)
+	var out bytes.Buffer
+	lw := dashboard.NewLineWriter(&out)
+
+	lw.Update(dashboard.Collect())
+	if n := strings.Count(out.String(), "\n"); n != 3 {
+		t.Fatalf("expected one line per node, got %d", n)
+	}
+	if strings.Contains(out.String(), "\033") {
+		t.Fatalf("expected no escape sequences in line mode")
+	}
+
+	out.Reset()
+	lw.Update(dashboard.Collect())
+	if out.Len() != 0 {
+		t.Fatalf("expected no output without changes, got %q", out.String())
+	}
+
+	nodes.ElysianCluster.Drain("s2", true)
+	lw.Update(dashboard.Collect())
+	if !strings.Contains(out.String(), "node=s2") || !strings.Contains(out.String(), `state="drained"`) || strings.Count(out.String(), "\n") != 1 {
+		t.Fatalf("expected a single line for the drained node, got %q", out.String())
+	}
+}
+
+func TestRender(t *testing.T) {
+	cluster(t)
+	out := dashboard.Render(dashboard.Collect(), 1, "s1 drained")
+	for _, want := range []string{"NODE", "> s1", "resync", "PENDING OPS", "[p] promote", "s1 drained"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in rendered dashboard:\n%s", want, out)
+		}
+	}
+}
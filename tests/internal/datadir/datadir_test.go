@@ -0,0 +1,93 @@
+package datadir_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/datadir"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+)
+
+func folder(path string) configuration.ElysianDB {
+	return configuration.ElysianDB{Store: configuration.Store{Folder: path}}
+}
+
+func config(t *testing.T) (configuration.ElysianGateConfig, string) {
+	dir := t.TempDir()
+	cfg := configuration.ElysianGateConfig{Nodes: map[string]configuration.Node{
+		"one": {Role: "master", ElysianDB: folder(filepath.Join(dir, "db-1"))},
+		"two": {Role: "slave"},
+	}}
+	cfg.Gateway.NodeDefaults = folder(filepath.Join(dir, "db-{node}"))
+	return cfg, dir
+}
+
+func TestResolveUsesStoreFolders(t *testing.T) {
+	cfg, dir := config(t)
+
+	dirs, err := datadir.Resolve(cfg)
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if len(dirs) != 2 || dirs[0].Path != filepath.Join(dir, "db-1") || dirs[1].Path != filepath.Join(dir, "db-two") {
+		t.Fatalf("unexpected dirs %+v", dirs)
+	}
+
+	dirs, err = datadir.Resolve(cfg, "two")
+	if err != nil || len(dirs) != 1 || dirs[0].Node != "two" {
+		t.Fatalf("expected only node two, got %+v %v", dirs, err)
+	}
+	if _, err := datadir.Resolve(cfg, "ghost"); !errors.Is(err, nodes.ErrUnknownNode) {
+		t.Fatalf("expected unknown node error, got %v", err)
+	}
+}
+
+func TestResolveRefusesUnsafeFolders(t *testing.T) {
+	wd, _ := os.Getwd()
+	for _, path := range []string{"/", "/tmp", ".", filepath.Dir(wd)} {
+		cfg := configuration.ElysianGateConfig{Nodes: map[string]configuration.Node{
+			"one": {ElysianDB: folder(path)},
+		}}
+		if _, err := datadir.Resolve(cfg); err == nil {
+			t.Fatalf("expected folder %q to be refused", path)
+		}
+	}
+
+	shared := filepath.Join(t.TempDir(), "db")
+	cfg := configuration.ElysianGateConfig{Nodes: map[string]configuration.Node{
+		"one": {ElysianDB: folder(shared)},
+		"two": {ElysianDB: folder(shared)},
+	}}
+	if _, err := datadir.Resolve(cfg); err == nil {
+		t.Fatalf("expected a shared folder to be refused")
+	}
+}
+
+func TestRemoveReportsWhatWasDeleted(t *testing.T) {
+	cfg, dir := config(t)
+	os.MkdirAll(filepath.Join(dir, "db-1", "shards"), 0o755)
+	os.WriteFile(filepath.Join(dir, "db-1", "shards", "0.json"), []byte("12345"), 0o644)
+	os.WriteFile(filepath.Join(dir, "db-1", "wal.log"), []byte("abc"), 0o644)
+	os.WriteFile(filepath.Join(dir, "unrelated"), []byte("keep"), 0o644)
+
+	dirs, _ := datadir.Resolve(cfg)
+	results, err := datadir.Remove(dirs)
+	if err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if results[0].Files != 2 || results[0].Bytes != 8 || results[0].Missing {
+		t.Fatalf("unexpected result for one: %+v", results[0])
+	}
+	if !results[1].Missing {
+		t.Fatalf("expected missing folder of two to be reported, got %+v", results[1])
+	}
+	if _, err := os.Stat(filepath.Join(dir, "db-1")); !os.IsNotExist(err) {
+		t.Fatalf("expected db-1 to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "unrelated")); err != nil {
+		t.Fatalf("expected unrelated file to be kept")
+	}
+}
@@ -0,0 +1,186 @@
+package e2e_test
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/fakedb"
+	"github.com/elysiandb/elysian-gate/internal/global"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+	"github.com/elysiandb/elysian-gate/internal/routing"
+	"github.com/elysiandb/elysian-gate/internal/sharding"
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+)
+
+type env struct {
+	master  *fakedb.Server
+	slave   *fakedb.Server
+	gateway string
+	names   [2]string
+}
+
+func start(t *testing.T) *env {
+	e := &env{master: fakedb.New(), slave: fakedb.New()}
+	t.Cleanup(e.master.Close)
+	t.Cleanup(e.slave.Close)
+	e.names = [2]string{t.Name() + "-m", t.Name() + "-s"}
+	e.master.Put("articles",
+		map[string]any{"id": "a1", "title": "first", "views": 10},
+		map[string]any{"id": "a2", "title": "second", "views": 30},
+	)
+
+	configuration.Config = configuration.ElysianGateConfig{Nodes: map[string]configuration.Node{
+		e.names[0]: e.master.NodeConfig("master", ""),
+		e.names[1]: e.slave.NodeConfig("slave", ""),
+	}}
+	configuration.Config.Gateway.HealthCheck = configuration.HealthCheck{TimeoutMs: 200, Rise: 1, Fall: 1}
+	if err := nodes.Init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	sharding.Init(nodes.ElysianCluster.Groups())
+	balancer.DiscardPending(global.DefaultGroup)
+	if err := nodes.ElysianCluster.ResyncSlave(e.names[1]); err != nil {
+		t.Fatalf("initial resync: %v", err)
+	}
+	nodes.ElysianCluster.CheckHealth()
+
+	r := router.New()
+	routing.RegisterRoutes(r)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := &fasthttp.Server{Handler: r.Handler}
+	go server.Serve(ln)
+	t.Cleanup(func() { server.Shutdown() })
+	e.gateway = "http://" + ln.Addr().String()
+	return e
+}
+
+func (e *env) call(t *testing.T, method string, path string, body string) (int, string) {
+	req, _ := http.NewRequest(method, e.gateway+path, strings.NewReader(body))
+	resp, err := (&http.Client{Timeout: 5 * time.Second}).Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+func (e *env) node(name string) global.Node {
+	n, _ := nodes.ElysianCluster.Snapshot().Node(name)
+	return n
+}
+
+func ids(docs []map[string]any) string {
+	out := []string{}
+	for _, d := range docs {
+		out = append(out, d["id"].(string))
+	}
+	return strings.Join(out, ",")
+}
+
+func TestWritesReachSlavesOnSync(t *testing.T) {
+	e := start(t)
+	if got := ids(e.slave.Entities("articles")); got != "a1,a2" {
+		t.Fatalf("expected initial resync to copy the master, got %s", got)
+	}
+
+	status, body := e.call(t, "POST", "/api/articles", `{"title":"third","views":20}`)
+	var created map[string]any
+	json.Unmarshal([]byte(body), &created)
+	id, _ := created["id"].(string)
+	if status != 200 || id == "" {
+		t.Fatalf("unexpected create %d %s", status, body)
+	}
+	e.call(t, "PUT", "/api/articles/a1", `{"views":11}`)
+	e.call(t, "DELETE", "/api/articles/a2", "")
+	if len(e.slave.Entities("articles")) != 2 || balancer.PendingOps(global.DefaultGroup) != 3 {
+		t.Fatalf("expected writes to stay pending until the next sync")
+	}
+
+	balancer.SyncSlaves()
+	if got, want := ids(e.slave.Entities("articles")), "a1,"+id; got != want {
+		t.Fatalf("expected slave %s after sync, got %s", want, got)
+	}
+	if doc := e.slave.Entities("articles")[0]; doc["views"] != float64(11) {
+		t.Fatalf("expected update to be replayed, got %v", doc)
+	}
+	if balancer.PendingOps(global.DefaultGroup) != 0 {
+		t.Fatalf("expected no pending ops after sync")
+	}
+}
+
+func TestReadsAndListsThroughGateway(t *testing.T) {
+	e := start(t)
+
+	if status, body := e.call(t, "GET", "/api/articles/a2", ""); status != 200 || !strings.Contains(body, `"second"`) {
+		t.Fatalf("unexpected get %d %s", status, body)
+	}
+	if status, _ := e.call(t, "GET", "/api/articles/missing", ""); status != 404 {
+		t.Fatalf("expected 404 for a missing article, got %d", status)
+	}
+
+	status, body := e.call(t, "GET", "/api/articles?filter[views][gte]=10&sort[views]=desc&limit=1", "")
+	var docs []map[string]any
+	json.Unmarshal([]byte(body), &docs)
+	if status != 200 || ids(docs) != "a2" {
+		t.Fatalf("unexpected list %d %s", status, body)
+	}
+}
+
+func TestFailingSlaveReadsFallBackToMaster(t *testing.T) {
+	e := start(t)
+	e.slave.FailRequests(500, 1)
+
+	for i := 0; i < 10; i++ {
+		if status, body := e.call(t, "GET", "/api/articles/a1", ""); status != 200 {
+			t.Fatalf("expected read to be retried on the master, got %d %s", status, body)
+		}
+	}
+}
+
+func TestPartitionedSlaveLeavesRotationAndCatchesUp(t *testing.T) {
+	e := start(t)
+
+	e.slave.Partition()
+	nodes.ElysianCluster.CheckHealth()
+	if n := e.node(e.names[1]); n.HTTP.Up || n.Ready {
+		t.Fatalf("expected partitioned slave to be down and not ready, got %+v", n)
+	}
+
+	before := len(e.slave.Requests())
+	for i := 0; i < 5; i++ {
+		if status, _ := e.call(t, "GET", "/api/articles/a1", ""); status != 200 {
+			t.Fatalf("expected reads to be served by the master, got %d", status)
+		}
+	}
+	if status, _ := e.call(t, "POST", "/api/articles", `{"id":"a3","title":"during partition"}`); status != 200 {
+		t.Fatalf("expected writes to keep working, got %d", status)
+	}
+	if after := len(e.slave.Requests()); after != before {
+		t.Fatalf("expected no traffic to the partitioned slave, got %d new requests", after-before)
+	}
+
+	e.slave.Heal()
+	nodes.ElysianCluster.CheckHealth()
+	deadline := time.Now().Add(3 * time.Second)
+	for !e.node(e.names[1]).Ready {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected healed slave to be resynced")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if got := ids(e.slave.Entities("articles")); got != "a1,a2,a3" {
+		t.Fatalf("expected resync to bring the slave up to date, got %s", got)
+	}
+}
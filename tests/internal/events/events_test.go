@@ -0,0 +1,238 @@
+package events_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/accesslog"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/events"
+	"github.com/elysiandb/elysian-gate/internal/global"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+	"github.com/elysiandb/elysian-gate/internal/transport/http/admin"
+	"github.com/valyala/fasthttp"
+)
+
+func next(t *testing.T, sub *events.Subscription) events.Event {
+	t.Helper()
+	select {
+	case e := <-sub.C:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for an event")
+	}
+	return events.Event{}
+}
+
+func TestPublishSubscribeAndFilters(t *testing.T) {
+	events.Reset()
+	all := events.Subscribe()
+	defer all.Close()
+	nodeOnly := events.Subscribe("node.*")
+	defer nodeOnly.Close()
+
+	events.Publish(events.SyncFailed, "s1", "default", map[string]any{"ops": 3})
+	events.Publish(events.NodeDown, "s1", "default", nil)
+
+	if e := next(t, all); e.Type != events.SyncFailed || e.ID != 1 || e.Data["ops"] != 3 {
+		t.Fatalf("unexpected first event %+v", e)
+	}
+	if e := next(t, all); e.Type != events.NodeDown || e.ID != 2 {
+		t.Fatalf("unexpected second event %+v", e)
+	}
+	if e := next(t, nodeOnly); e.Type != events.NodeDown {
+		t.Fatalf("expected node.* filter to skip sync events, got %+v", e)
+	}
+
+	replay := events.SubscribeFrom(1, events.NodeDown)
+	defer replay.Close()
+	if len(replay.Backlog) != 1 || replay.Backlog[0].ID != 2 {
+		t.Fatalf("expected backlog after id 1, got %+v", replay.Backlog)
+	}
+
+	all.Close()
+	all.Close()
+	if _, ok := <-all.C; ok {
+		t.Fatalf("expected closed subscription channel")
+	}
+}
+
+func TestWebhookRetriesAndSigns(t *testing.T) {
+	events.Reset()
+	var calls atomic.Int32
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			http.Error(w, "busy", 503)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer s.Close()
+
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.Events.Webhooks = []configuration.Webhook{
+		{URL: s.URL, Secret: "s3cret", Events: []string{"resync.*"}, MaxRetries: 3, BackoffMs: 10},
+	}
+	events.StartWebhooks()
+	defer events.StopWebhooks()
+
+	events.Publish(events.NodeDown, "s1", "default", nil)
+	events.Publish(events.ResyncFinished, "s1", "default", nil)
+
+	var r *http.Request
+	var body []byte
+	select {
+	case r = <-received:
+		body = <-bodies
+	case <-time.After(3 * time.Second):
+		t.Fatalf("webhook was never delivered")
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("expected two failed attempts before delivery, got %d calls", calls.Load())
+	}
+	if r.Header.Get(events.EventHeader) != events.ResyncFinished || r.Header.Get(events.EventIDHeader) != "2" {
+		t.Fatalf("unexpected event headers %v", r.Header)
+	}
+	want := events.Sign("s3cret", r.Header.Get(events.TimestampHeader), body)
+	if r.Header.Get(events.SignatureHeader) != want {
+		t.Fatalf("bad signature %q, want %q", r.Header.Get(events.SignatureHeader), want)
+	}
+	var e events.Event
+	if err := json.Unmarshal(body, &e); err != nil || e.Type != events.ResyncFinished || e.Node != "s1" {
+		t.Fatalf("unexpected payload %s", body)
+	}
+}
+
+func serveEvents(t *testing.T) string {
+	configuration.Config.Gateway.Authorization.Roles = map[string]configuration.Grants{"anonymous": {"*": {"admin"}}}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := &fasthttp.Server{
+		Handler:      accesslog.Middleware(admin.EventsController),
+		WriteTimeout: 300 * time.Millisecond,
+	}
+	go server.Serve(ln)
+	t.Cleanup(func() { server.Shutdown() })
+	return "http://" + ln.Addr().String() + "/admin/events"
+}
+
+type stream struct {
+	mu     sync.Mutex
+	events []events.Event
+}
+
+func (s *stream) read(body io.Reader) {
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var e events.Event
+		json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e)
+		s.mu.Lock()
+		s.events = append(s.events, e)
+		s.mu.Unlock()
+	}
+}
+
+func (s *stream) wait(t *testing.T, n int) []events.Event {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		got := append([]events.Event(nil), s.events...)
+		s.mu.Unlock()
+		if len(got) >= n {
+			return got
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected %d streamed events", n)
+	return nil
+}
+
+func TestServerSentEvents(t *testing.T) {
+	configuration.Config = configuration.ElysianGateConfig{}
+	events.Reset()
+	admin.EventsHeartbeat = 100 * time.Millisecond
+	url := serveEvents(t)
+
+	events.Publish(events.NodeUp, "m", "default", nil)
+
+	req, _ := http.NewRequest("GET", url+"?types=node.*", nil)
+	req.Header.Set("Last-Event-ID", "0")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	s := &stream{}
+	go s.read(resp.Body)
+
+	s.wait(t, 1)
+	time.Sleep(500 * time.Millisecond)
+	events.Publish(events.SyncFailed, "s", "default", nil)
+	events.Publish(events.NodeDown, "s", "default", nil)
+
+	got := s.wait(t, 2)
+	if got[0].Type != events.NodeUp || got[1].Type != events.NodeDown || got[1].ID != 3 {
+		t.Fatalf("unexpected stream %+v", got)
+	}
+
+	bad, err := http.Get(url + "?since=nope")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	bad.Body.Close()
+	if bad.StatusCode != 400 {
+		t.Fatalf("expected invalid event id to be rejected, got %d", bad.StatusCode)
+	}
+}
+
+func TestClusterTransitionsArePublished(t *testing.T) {
+	configuration.Config = configuration.ElysianGateConfig{}
+	events.Reset()
+	sub := events.Subscribe()
+	defer sub.Close()
+
+	c := nodes.NewCluster([]global.Node{
+		{Name: "m", Role: "master", Ready: true},
+		{Name: "s", Role: "slave", Ready: true, HTTP: global.Transport{Up: true}},
+	})
+	c.Drain("s", true)
+	c.Drain("s", false)
+	c.Promote("s")
+
+	want := []string{events.NodeDrained, events.NodeUndrained, events.NodePromoted}
+	for i, typ := range want {
+		e := next(t, sub)
+		if e.Type != typ || e.Node != "s" || e.ID != uint64(i+1) {
+			t.Fatalf("expected %s for s, got %+v", typ, e)
+		}
+		if typ == events.NodePromoted && e.Data["previous_master"] != "m" {
+			t.Fatalf("expected previous master in promotion event, got %+v", e.Data)
+		}
+	}
+	if got := len(events.History()); got != 3 {
+		t.Fatalf("expected 3 events in history, got %d", got)
+	}
+}
@@ -0,0 +1,179 @@
+package fakedb_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/fakedb"
+)
+
+func call(t *testing.T, s *fakedb.Server, method string, path string, body string) (int, string) {
+	req, _ := http.NewRequest(method, s.URL()+path, strings.NewReader(body))
+	resp, err := (&http.Client{Timeout: 2 * time.Second}).Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+func TestCRUD(t *testing.T) {
+	s := fakedb.New()
+	defer s.Close()
+
+	status, body := call(t, s, "POST", "/api/articles", `{"title":"one"}`)
+	var created map[string]any
+	json.Unmarshal([]byte(body), &created)
+	id, _ := created["id"].(string)
+	if status != 200 || id == "" {
+		t.Fatalf("expected created doc with generated id, got %d %s", status, body)
+	}
+
+	if status, body := call(t, s, "POST", "/api/articles", `{"id":7,"title":"seven"}`); status != 400 || !strings.Contains(body, "id must be a string") {
+		t.Fatalf("expected non-string id to be rejected, got %d %s", status, body)
+	}
+	if status, body := call(t, s, "POST", "/api/articles", `{"id":"a7","title":"seven"}`); status != 200 || !strings.Contains(body, `"id":"a7"`) {
+		t.Fatalf("expected supplied id to be kept, got %d %s", status, body)
+	}
+	call(t, s, "DELETE", "/api/articles/a7", "")
+
+	if status, body := call(t, s, "GET", "/api/articles/"+id, ""); status != 200 || !strings.Contains(body, `"title":"one"`) {
+		t.Fatalf("unexpected get %d %s", status, body)
+	}
+	if status, body := call(t, s, "PUT", "/api/articles/"+id, `{"views":3}`); status != 200 || !strings.Contains(body, `"title":"one"`) || !strings.Contains(body, `"views":3`) {
+		t.Fatalf("expected update to merge fields, got %d %s", status, body)
+	}
+	if status, _ := call(t, s, "PUT", "/api/articles/ghost", `{}`); status != 404 {
+		t.Fatalf("expected update of missing doc to 404, got %d", status)
+	}
+	if status, _ := call(t, s, "DELETE", "/api/articles/"+id, ""); status != 204 {
+		t.Fatalf("expected delete to succeed, got %d", status)
+	}
+	if status, _ := call(t, s, "GET", "/api/articles/"+id, ""); status != 404 {
+		t.Fatalf("expected deleted doc to 404, got %d", status)
+	}
+
+	s.Put("users", map[string]any{"id": "u1"})
+	if status, body := call(t, s, "GET", "/kv/api:entity:types:list", ""); status != 200 || !strings.Contains(body, `"value":"articles,users"`) {
+		t.Fatalf("unexpected types list %d %s", status, body)
+	}
+	call(t, s, "DELETE", "/api/users", "")
+	if types := s.Types(); len(types) != 1 || types[0] != "articles" {
+		t.Fatalf("expected destroy to drop the type, got %v", types)
+	}
+}
+
+func TestListFilters(t *testing.T) {
+	s := fakedb.New()
+	defer s.Close()
+	s.Put("books",
+		map[string]any{"id": "a", "title": "Go", "pages": 300, "meta": map[string]any{"lang": "en"}},
+		map[string]any{"id": "b", "title": "Rust", "pages": 550, "meta": map[string]any{"lang": "fr"}},
+		map[string]any{"id": "c", "title": "Gopher", "pages": 120, "meta": map[string]any{"lang": "en"}},
+	)
+
+	for query, want := range map[string]string{
+		"":                                     "a,b,c",
+		"filter[pages][gt]=200":                "a,b",
+		"filter[meta.lang]=en&sort[pages]=asc": "c,a",
+		"filter[title][contains]=Go":           "a,c",
+		"filter[pages][neq]=300&sort[id]=desc": "c,b",
+		"sort[pages]=desc&offset=1&limit=1":    "a",
+	} {
+		status, body := call(t, s, "GET", "/api/books?"+query, "")
+		var docs []map[string]any
+		json.Unmarshal([]byte(body), &docs)
+		ids := []string{}
+		for _, d := range docs {
+			ids = append(ids, d["id"].(string))
+		}
+		if status != 200 || strings.Join(ids, ",") != want {
+			t.Fatalf("query %q: expected %s, got %d %v", query, want, status, ids)
+		}
+	}
+
+	if status, _ := call(t, s, "GET", "/api/books?filter[pages][like]=1", ""); status != 400 {
+		t.Fatalf("expected unknown operator to be rejected, got %d", status)
+	}
+}
+
+func ping(t *testing.T, addr string, timeout time.Duration) string {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	conn.Write([]byte("PING\n"))
+	line, _ := bufio.NewReader(conn).ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+func TestTCPPingAndFaults(t *testing.T) {
+	s := fakedb.New()
+	defer s.Close()
+
+	if got := ping(t, s.TCPAddr(), time.Second); got != "PONG" {
+		t.Fatalf("expected PONG, got %q", got)
+	}
+
+	s.FailRequests(503, 1)
+	if status, _ := call(t, s, "GET", "/health", ""); status != 503 {
+		t.Fatalf("expected injected error, got %d", status)
+	}
+	s.FailRequests(0, 0)
+
+	s.SetLatency(100 * time.Millisecond)
+	start := time.Now()
+	call(t, s, "GET", "/health", "")
+	if time.Since(start) < 100*time.Millisecond {
+		t.Fatalf("expected injected latency")
+	}
+
+	s.SetLatency(time.Hour)
+	conn, err := net.Dial("tcp", s.TCPAddr())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	conn.Write([]byte("PING\n"))
+	time.Sleep(50 * time.Millisecond)
+	closed := make(chan struct{})
+	go func() {
+		s.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected Close not to wait for injected TCP latency")
+	}
+	conn.Close()
+}
+
+func TestTCPPartition(t *testing.T) {
+	s := fakedb.New()
+	defer s.Close()
+
+	s.Partition()
+	if got := ping(t, s.TCPAddr(), 200*time.Millisecond); got != "" {
+		t.Fatalf("expected partitioned node not to answer, got %q", got)
+	}
+	client := &http.Client{Timeout: 200 * time.Millisecond}
+	if _, err := client.Get(s.URL() + "/health"); err == nil {
+		t.Fatalf("expected partitioned node to time out")
+	}
+	s.Heal()
+	if status, _ := call(t, s, "GET", "/health", ""); status != 200 {
+		t.Fatalf("expected healed node to answer, got %d", status)
+	}
+	if reqs := s.Requests(); len(reqs) == 0 || reqs[0] != "GET /health" {
+		t.Fatalf("unexpected request log %v", reqs)
+	}
+}
@@ -0,0 +1,135 @@
+package logger_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/elysiandb/elysian-gate/internal/logger"
+)
+
+func configure(t *testing.T, opts logger.Options) string {
+	path := filepath.Join(t.TempDir(), "gate.log")
+	opts.Output = path
+	logger.Configure(opts)
+	t.Cleanup(func() { logger.Configure(logger.Options{Output: filepath.Join(os.TempDir(), "elysianGate-test.log")}) })
+	return path
+}
+
+func readLines(t *testing.T, path string) []string {
+	logger.Sync()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open log: %v", err)
+	}
+	defer f.Close()
+	var lines []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	return lines
+}
+
+func TestJSONFormatWithFields(t *testing.T) {
+	path := configure(t, logger.Options{Format: "json"})
+	logger.Info("write applied", logger.F("node", "node1"), logger.F("seq", 7), logger.F("error", errors.New("boom")))
+
+	lines := readLines(t, path)
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 line, got %d", len(lines))
+	}
+	var m map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &m); err != nil {
+		t.Fatalf("invalid json line %q: %v", lines[0], err)
+	}
+	if m["level"] != "info" || m["msg"] != "write applied" || m["node"] != "node1" || m["seq"] != float64(7) || m["error"] != "boom" {
+		t.Fatalf("unexpected entry: %v", m)
+	}
+	if m["time"] == nil {
+		t.Fatalf("expected time field")
+	}
+}
+
+func TestLogfmtFormat(t *testing.T) {
+	path := configure(t, logger.Options{})
+	logger.Warn("read failed", logger.F("node", "node 2"), logger.F("status", 503))
+
+	lines := readLines(t, path)
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 line, got %d", len(lines))
+	}
+	for _, want := range []string{"level=warn", `msg="read failed"`, `node="node 2"`, "status=503"} {
+		if !strings.Contains(lines[0], want) {
+			t.Fatalf("expected %q in %q", want, lines[0])
+		}
+	}
+}
+
+func TestLevelFiltering(t *testing.T) {
+	path := configure(t, logger.Options{Level: logger.WarnLevel})
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error")
+
+	lines := readLines(t, path)
+	if len(lines) != 2 || !strings.Contains(lines[0], "level=warn") || !strings.Contains(lines[1], "level=error") {
+		t.Fatalf("unexpected lines: %v", lines)
+	}
+}
+
+func TestPreservesOrder(t *testing.T) {
+	path := configure(t, logger.Options{Format: "json"})
+	for i := 0; i < 2000; i++ {
+		logger.Info("msg", logger.F("i", i))
+	}
+
+	lines := readLines(t, path)
+	if len(lines) != 2000 {
+		t.Fatalf("expected 2000 lines, got %d", len(lines))
+	}
+	for i, line := range lines {
+		var m map[string]any
+		json.Unmarshal([]byte(line), &m)
+		if m["i"] != float64(i) {
+			t.Fatalf("line %d out of order: %s", i, line)
+		}
+	}
+}
+
+func TestRotatesBySize(t *testing.T) {
+	path := configure(t, logger.Options{MaxSizeBytes: 512, MaxBackups: 2})
+	for i := 0; i < 100; i++ {
+		logger.Info("filling the log file", logger.F("i", i))
+	}
+	logger.Sync()
+
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups to be kept, got %d", len(backups))
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat log: %v", err)
+	}
+	if info.Size() > 512 {
+		t.Fatalf("expected active log under size limit, got %d bytes", info.Size())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if l, err := logger.ParseLevel("debug"); err != nil || l != logger.DebugLevel {
+		t.Fatalf("expected debug level")
+	}
+	if l, err := logger.ParseLevel(""); err != nil || l != logger.InfoLevel {
+		t.Fatalf("expected info as default level")
+	}
+	if _, err := logger.ParseLevel("loud"); err == nil {
+		t.Fatalf("expected error for unknown level")
+	}
+}
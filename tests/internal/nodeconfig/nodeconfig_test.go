@@ -0,0 +1,137 @@
+package nodeconfig_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/nodeconfig"
+	"gopkg.in/yaml.v3"
+)
+
+func config(dir string) configuration.ElysianGateConfig {
+	off := false
+	cfg := configuration.ElysianGateConfig{Nodes: map[string]configuration.Node{
+		"one": {
+			Role: "master",
+			HTTP: configuration.Transport{Host: "127.0.0.1", Port: 8090},
+			TCP:  configuration.Transport{Host: "127.0.0.1", Port: 8890},
+			ElysianDB: configuration.ElysianDB{
+				Store: configuration.Store{Shards: 64},
+				Cache: configuration.NodeCache{Enabled: &off},
+			},
+		},
+		"two": {
+			Role:            "slave",
+			HTTP:            configuration.Transport{Host: "127.0.0.1", Port: 8091},
+			TCP:             configuration.Transport{Host: "127.0.0.1", Port: 8891},
+			ElysianDBConfig: filepath.Join(dir, "custom", "two.yml"),
+		},
+	}}
+	cfg.Gateway.NodeConfigDir = dir
+	cfg.Gateway.NodeDefaults.Store.Folder = "/var/lib/elysiandb/{node}"
+	cfg.Gateway.NodeDefaults.Store.FlushIntervalSeconds = 2
+	return cfg
+}
+
+func TestForMergesNodeDefaultsAndBuiltins(t *testing.T) {
+	cfg := config(t.TempDir())
+
+	f, err := nodeconfig.For(cfg, "one")
+	if err != nil {
+		t.Fatalf("for: %v", err)
+	}
+	if f.Store.Folder != "/var/lib/elysiandb/one" || f.Store.Shards != 64 || f.Store.FlushIntervalSeconds != 2 {
+		t.Fatalf("unexpected store %+v", f.Store)
+	}
+	if !f.Store.CrashRecovery.Enabled || f.Store.CrashRecovery.MaxLogMB != 100 || f.API.Index.Workers != 4 {
+		t.Fatalf("expected built-in defaults, got %+v", f)
+	}
+	if f.API.Cache.Enabled || f.API.Cache.CleanupIntervalSeconds != 10 {
+		t.Fatalf("expected node cache override, got %+v", f.API.Cache)
+	}
+	if f.Server.HTTP.Port != 8090 || f.Server.TCP.Port != 8890 || !f.Server.TCP.Enabled {
+		t.Fatalf("expected listeners from the node definition, got %+v", f.Server)
+	}
+
+	f, _ = nodeconfig.For(cfg, "two")
+	if f.Store.Shards != 512 || f.Store.Folder != "/var/lib/elysiandb/two" {
+		t.Fatalf("unexpected store for two %+v", f.Store)
+	}
+	if _, err := nodeconfig.For(cfg, "ghost"); err == nil {
+		t.Fatalf("expected unknown node to fail")
+	}
+}
+
+func TestGenerateWritesAndDetectsDrift(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config(dir)
+	delete(cfg.Nodes, "two")
+	cfg.Nodes["three"] = configuration.Node{Role: "slave", HTTP: configuration.Transport{Port: 8092}, TCP: configuration.Transport{Port: 8892}}
+
+	generated, err := nodeconfig.Generate(cfg, false)
+	if err != nil || len(generated) != 2 || !generated[0].Changed {
+		t.Fatalf("expected missing files to be reported, got %+v %v", generated, err)
+	}
+	if _, err := os.Stat(generated[0].Path); !os.IsNotExist(err) {
+		t.Fatalf("expected a check not to write anything")
+	}
+
+	generated, err = nodeconfig.Generate(cfg, true)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if generated[0].Path != filepath.Join(dir, "one.yaml") || generated[1].Path != filepath.Join(dir, "three.yaml") {
+		t.Fatalf("unexpected paths %+v", generated)
+	}
+	data, _ := os.ReadFile(generated[1].Path)
+	if !strings.HasPrefix(string(data), "# Generated by elysianGate") {
+		t.Fatalf("expected a generated header, got %q", data)
+	}
+	var f nodeconfig.File
+	if err := yaml.Unmarshal(data, &f); err != nil || f.Server.HTTP.Port != 8092 || f.Store.FlushIntervalSeconds != 2 {
+		t.Fatalf("unexpected generated file %+v %v", f, err)
+	}
+
+	generated, _ = nodeconfig.Generate(cfg, false)
+	if generated[0].Changed || generated[1].Changed {
+		t.Fatalf("expected generated files to be up to date")
+	}
+	cfg.Gateway.NodeDefaults.Store.Shards = 128
+	generated, _ = nodeconfig.Generate(cfg, false)
+	if !generated[1].Changed {
+		t.Fatalf("expected a changed default to be detected as drift")
+	}
+}
+
+func TestGenerateNeverWritesHandWrittenConfigs(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config(dir)
+	custom := cfg.Nodes["two"].ElysianDBConfig
+	os.MkdirAll(filepath.Dir(custom), 0o755)
+	os.WriteFile(custom, []byte("store:\n  folder: /var/lib/hand/two\n"), 0o644)
+
+	generated, err := nodeconfig.Generate(cfg, true)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if !generated[1].External || generated[1].Changed || generated[1].Path != custom {
+		t.Fatalf("expected two to be reported as hand-written, got %+v", generated[1])
+	}
+	if data, _ := os.ReadFile(custom); string(data) != "store:\n  folder: /var/lib/hand/two\n" {
+		t.Fatalf("expected the hand-written file to be left alone, got %q", data)
+	}
+
+	if folder, err := nodeconfig.StoreFolder(cfg, "two"); err != nil || folder != "/var/lib/hand/two" {
+		t.Fatalf("expected the folder from the hand-written file, got %q %v", folder, err)
+	}
+	if folder, _ := nodeconfig.StoreFolder(cfg, "one"); folder != "/var/lib/elysiandb/one" {
+		t.Fatalf("expected the generated folder for one, got %q", folder)
+	}
+	os.Remove(custom)
+	if _, err := nodeconfig.StoreFolder(cfg, "two"); err == nil {
+		t.Fatalf("expected a missing hand-written file to fail")
+	}
+}
@@ -0,0 +1,129 @@
+package nodes_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/breaker"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/global"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+)
+
+func TestSnapshotsAreImmutable(t *testing.T) {
+	list := []global.Node{{Name: "m", Role: "master", Ready: true}}
+	c := nodes.NewCluster(list)
+	list[0].Ready = false
+
+	before := c.Snapshot()
+	if !before.Nodes[0].Ready {
+		t.Fatalf("expected cluster to copy its initial node list")
+	}
+	if err := c.Drain("m", true); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	after := c.Snapshot()
+	if before.Nodes[0].Drained || !after.Nodes[0].Drained || after.Version != before.Version+1 {
+		t.Fatalf("expected drain to publish a new snapshot, before %+v after %+v", before, after)
+	}
+	if err := c.Drain("missing", true); err == nil || c.Snapshot() != after {
+		t.Fatalf("expected failed transition to leave the snapshot untouched")
+	}
+}
+
+func TestSyncAndFreshTransitions(t *testing.T) {
+	c := nodes.NewCluster([]global.Node{
+		{Name: "m", Role: "master", Ready: true},
+		{Name: "s", Role: "slave", Ready: true},
+		{Name: "r", Role: "slave"},
+	})
+	if c.BeginSync("m") || c.BeginSync("r") {
+		t.Fatalf("expected only ready slaves to be syncable")
+	}
+	if !c.BeginSync("s") || c.BeginSync("s") {
+		t.Fatalf("expected a single sync per slave")
+	}
+	if n, _ := c.Snapshot().Node("s"); !n.Syncing {
+		t.Fatalf("expected slave to be syncing")
+	}
+
+	c.EndSync("s", true)
+	if n, _ := c.Snapshot().Node("s"); n.Syncing || !n.Fresh {
+		t.Fatalf("expected slave to be fresh after a successful sync, got %+v", n)
+	}
+
+	c.MarkAllDirty()
+	if n, _ := c.Snapshot().Node("s"); n.Fresh {
+		t.Fatalf("expected slave to be dirty after a write")
+	}
+	version := c.Snapshot().Version
+	c.MarkAllDirty()
+	if c.Snapshot().Version != version {
+		t.Fatalf("expected no new snapshot when nothing is fresh")
+	}
+}
+
+func TestSnapshotHelpers(t *testing.T) {
+	c := nodes.NewCluster([]global.Node{
+		{Name: "m1", Role: "master", Group: "a"},
+		{Name: "s1", Role: "slave", Group: "a"},
+		{Name: "m2", Role: "master", Group: "b"},
+	})
+	snap := c.Snapshot()
+	if m, ok := snap.Master("b"); !ok || m.Name != "m2" {
+		t.Fatalf("expected m2 to be the master of b, got %+v", m)
+	}
+	if _, ok := snap.Node("nope"); ok {
+		t.Fatalf("expected unknown node lookup to fail")
+	}
+	if groups := snap.Groups(); len(groups) != 2 || groups[0] != "a" || groups[1] != "b" {
+		t.Fatalf("unexpected groups %v", groups)
+	}
+	if (&nodes.Cluster{}).Snapshot().Nodes != nil {
+		t.Fatalf("expected zero cluster to expose an empty snapshot")
+	}
+}
+
+func TestConcurrentReadsWritesAndMonitorTicks(t *testing.T) {
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.HealthCheck = configuration.HealthCheck{Rise: 1, Fall: 1}
+	breaker.Reset()
+	master, slave := newFakeNode(t), newFakeNode(t)
+	m := master.node("race-master", "master")
+	s := slave.node("race-slave", "slave")
+	m.Ready, s.Ready = true, true
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{m, s})
+
+	var wg sync.WaitGroup
+	run := func(fn func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 30; i++ {
+				fn(i)
+			}
+		}()
+	}
+	run(func(int) {
+		for _, n := range balancer.GetReadRequestNodes() {
+			if n.Name == "" {
+				t.Errorf("unexpected empty node in read set")
+			}
+		}
+	})
+	run(func(int) { balancer.SendWriteRequestToMaster("PUT", "/api/race/1", `{"id":"1"}`) })
+	run(func(int) { balancer.SyncSlaves() })
+	run(func(int) { nodes.ElysianCluster.CheckHealth() })
+	run(func(i int) { nodes.ElysianCluster.Drain("race-slave", i%2 == 0) })
+	wg.Wait()
+
+	balancer.SyncSlaves()
+	if pending := balancer.PendingOps(global.DefaultGroup); pending != 0 {
+		t.Fatalf("expected all writes to reach the slave, %d pending", pending)
+	}
+	n, _ := nodes.ElysianCluster.Snapshot().Node("race-slave")
+	if n.Syncing || !n.Ready {
+		t.Fatalf("expected slave to settle ready and idle, got %+v", n)
+	}
+}
@@ -0,0 +1,225 @@
+package nodes_test
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/events"
+	"github.com/elysiandb/elysian-gate/internal/global"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+)
+
+type fakeNode struct {
+	healthy atomic.Bool
+	canary  atomic.Value
+	http    *httptest.Server
+	tcp     net.Listener
+}
+
+func newFakeNode(t *testing.T) *fakeNode {
+	f := &fakeNode{}
+	f.healthy.Store(true)
+	f.canary.Store(`{"key":"canary","value":"ok"}`)
+	f.http = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/kv/") {
+			w.Write([]byte(f.canary.Load().(string)))
+			return
+		}
+		if !f.healthy.Load() {
+			http.Error(w, "down", 503)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	f.tcp = ln
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(c net.Conn) {
+				defer c.Close()
+				bufio.NewReader(c).ReadString('\n')
+				c.Write([]byte("PONG\n"))
+			}(conn)
+		}
+	}()
+	t.Cleanup(func() {
+		f.http.Close()
+		ln.Close()
+	})
+	return f
+}
+
+func (f *fakeNode) node(name, role string) global.Node {
+	h := f.http.Listener.Addr().(*net.TCPAddr)
+	p := f.tcp.Addr().(*net.TCPAddr)
+	return global.Node{
+		Name:  name,
+		Role:  role,
+		Ready: true,
+		HTTP:  global.Transport{Host: h.IP.String(), Port: h.Port},
+		TCP:   global.Transport{Host: p.IP.String(), Port: p.Port},
+	}
+}
+
+func TestHealthCheckRiseAndFall(t *testing.T) {
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.HealthCheck = configuration.HealthCheck{Rise: 2, Fall: 2, TimeoutMs: 500}
+	f := newFakeNode(t)
+	c := nodes.NewCluster([]global.Node{f.node("rise-fall", "master")})
+	sub := events.Subscribe(events.NodeUp, events.NodeDown)
+	defer sub.Close()
+
+	c.CheckHealth()
+	if !c.Snapshot().Nodes[0].HTTP.Up || !c.Snapshot().Nodes[0].TCP.Up {
+		t.Fatalf("expected first probe to mark node up")
+	}
+
+	f.healthy.Store(false)
+	c.CheckHealth()
+	if !c.Snapshot().Nodes[0].HTTP.Up {
+		t.Fatalf("expected a single failure to be tolerated")
+	}
+	c.CheckHealth()
+	if c.Snapshot().Nodes[0].HTTP.Up {
+		t.Fatalf("expected node down after 2 failures")
+	}
+
+	f.healthy.Store(true)
+	c.CheckHealth()
+	if c.Snapshot().Nodes[0].HTTP.Up {
+		t.Fatalf("expected a single success not to bring node back")
+	}
+	c.CheckHealth()
+	if !c.Snapshot().Nodes[0].HTTP.Up {
+		t.Fatalf("expected node up after 2 successes")
+	}
+
+	for _, want := range []string{events.NodeUp, events.NodeDown, events.NodeUp} {
+		select {
+		case e := <-sub.C:
+			if e.Type != want || e.Node != "rise-fall" {
+				t.Fatalf("expected %s event, got %+v", want, e)
+			}
+		default:
+			t.Fatalf("expected a %s event", want)
+		}
+	}
+}
+
+func TestDeepCheckAffectsReadinessOnly(t *testing.T) {
+	configuration.Config = configuration.ElysianGateConfig{
+		Nodes: map[string]configuration.Node{
+			"deep": {HealthCheck: configuration.HealthCheck{
+				Rise: 1, Fall: 1,
+				DeepCheck: configuration.DeepCheck{Enabled: true, Key: "canary", ExpectValue: "ok"},
+			}},
+		},
+	}
+	f := newFakeNode(t)
+	c := nodes.NewCluster([]global.Node{f.node("deep", "slave")})
+
+	c.CheckHealth()
+	if c.Snapshot().Nodes[0].Unhealthy || !c.Snapshot().Nodes[0].Ready {
+		t.Fatalf("expected healthy ready node, got %+v", c.Snapshot().Nodes[0])
+	}
+
+	f.canary.Store(`{"key":"canary","value":"stale"}`)
+	c.CheckHealth()
+	c.CheckHealth()
+	n := c.Snapshot().Nodes[0]
+	if !n.Unhealthy || !n.Ready || !n.HTTP.Up {
+		t.Fatalf("expected failed deep check to mark node unhealthy but keep it live and ready, got %+v", n)
+	}
+}
+
+func TestHealthCheckFor(t *testing.T) {
+	configuration.Config = configuration.ElysianGateConfig{
+		Nodes: map[string]configuration.Node{
+			"custom": {HealthCheck: configuration.HealthCheck{IntervalMs: 500, Fall: 5}},
+		},
+	}
+	configuration.Config.Gateway.HealthCheck = configuration.HealthCheck{IntervalMs: 1000, TimeoutMs: 300, Rise: 3}
+
+	hc := nodes.HealthCheckFor("custom")
+	if hc.Interval != 500*time.Millisecond || hc.Timeout != 300*time.Millisecond || hc.Rise != 3 || hc.Fall != 5 || hc.DeepCheck {
+		t.Fatalf("unexpected merged health check: %+v", hc)
+	}
+	hc = nodes.HealthCheckFor("other")
+	if hc.Interval != time.Second || hc.Fall != 3 {
+		t.Fatalf("expected gateway values and defaults, got %+v", hc)
+	}
+}
+
+func blackhole(t *testing.T) net.Listener {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	var conns []net.Conn
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return ln
+}
+
+func TestProbesRunConcurrentlyOutsideLock(t *testing.T) {
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.HealthCheck = configuration.HealthCheck{TimeoutMs: 200, MaxParallel: 10}
+	addr := blackhole(t).Addr().(*net.TCPAddr)
+
+	list := []global.Node{}
+	for i := 0; i < 20; i++ {
+		list = append(list, global.Node{
+			Name: "hole-" + string(rune('a'+i)),
+			Role: "master",
+			HTTP: global.Transport{Host: addr.IP.String(), Port: addr.Port},
+			TCP:  global.Transport{Host: addr.IP.String(), Port: addr.Port},
+		})
+	}
+	c := nodes.NewCluster(list)
+
+	done := make(chan time.Duration)
+	go func() {
+		start := time.Now()
+		c.CheckHealth()
+		done <- time.Since(start)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	start := time.Now()
+	c.Snapshot()
+	c.Drain("hole-a", true)
+	if blocked := time.Since(start); blocked > 100*time.Millisecond {
+		t.Fatalf("expected snapshot not to wait for probes, blocked %v", blocked)
+	}
+
+	if elapsed := <-done; elapsed > 2*time.Second {
+		t.Fatalf("expected 20 slow nodes to be probed concurrently, took %v", elapsed)
+	}
+	for _, n := range c.Snapshot().Nodes {
+		if n.HTTP.Up || n.TCP.Up {
+			t.Fatalf("expected %s to be down", n.Name)
+		}
+	}
+}
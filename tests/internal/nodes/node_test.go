@@ -1,6 +1,9 @@
 package nodes_test
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -26,14 +29,16 @@ func TestInit(t *testing.T) {
 	}
 	configuration.Config.Gateway.StartsNodes = false
 
-	nodes.Init()
+	if err := nodes.Init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
 
-	if nodes.ElysianCluster == nil || len(nodes.ElysianCluster.Nodes) != 2 {
+	if nodes.ElysianCluster == nil || len(nodes.ElysianCluster.Snapshot().Nodes) != 2 {
 		t.Fatalf("expected 2 nodes, got %v", nodes.ElysianCluster)
 	}
 
 	foundMaster := false
-	for _, n := range nodes.ElysianCluster.Nodes {
+	for _, n := range nodes.ElysianCluster.Snapshot().Nodes {
 		switch n.Role {
 		case "master":
 			foundMaster = true
@@ -51,13 +56,38 @@ func TestInit(t *testing.T) {
 	}
 }
 
-func TestGetMasterNode(t *testing.T) {
-	nodes.ElysianCluster = &nodes.Cluster{
-		Nodes: []global.Node{
-			{Name: "slave", Role: "slave"},
-			{Name: "master", Role: "master"},
-		},
+func TestInitFailsOnInvalidNodeTLS(t *testing.T) {
+	configuration.Config = configuration.ElysianGateConfig{Nodes: map[string]configuration.Node{
+		"master": {Role: "master", HTTP: configuration.Transport{Host: "127.0.0.1", Port: 8443, TLS: configuration.TLS{
+			Enabled: true,
+			CAFile:  filepath.Join(t.TempDir(), "missing.pem"),
+		}}},
+	}}
+
+	if err := nodes.Init(); err == nil || !strings.Contains(err.Error(), "node master") {
+		t.Fatalf("expected invalid TLS settings to stop Init, got %v", err)
+	}
+}
+
+func TestInitFailsWhenNodeConfigsCannotBeGenerated(t *testing.T) {
+	blocker := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(blocker, nil, 0o644)
+	configuration.Config = configuration.ElysianGateConfig{Nodes: map[string]configuration.Node{
+		"master": {Role: "master", HTTP: configuration.Transport{Host: "127.0.0.1", Port: 8080}},
+	}}
+	configuration.Config.Gateway.StartsNodes = true
+	configuration.Config.Gateway.NodeConfigDir = filepath.Join(blocker, "configs")
+
+	if err := nodes.Init(); err == nil {
+		t.Fatalf("expected a generation failure to stop Init")
 	}
+}
+
+func TestGetMasterNode(t *testing.T) {
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{
+		{Name: "slave", Role: "slave"},
+		{Name: "master", Role: "master"},
+	})
 	m := nodes.GetMasterNode()
 	if m == nil || m.Role != "master" {
 		t.Fatalf("expected master node, got %#v", m)
@@ -69,3 +99,35 @@ func TestStartMonitoring(t *testing.T) {
 	c.StartMonitoring()
 	time.Sleep(100 * time.Millisecond)
 }
+
+func TestPromoteAndResyncValidation(t *testing.T) {
+	c := nodes.NewCluster([]global.Node{
+		{Name: "m", Role: "master", Ready: true},
+		{Name: "s", Role: "slave", Ready: false},
+	})
+	if err := c.Promote("s"); err == nil {
+		t.Fatalf("expected not ready slave promotion to fail")
+	}
+	if err := c.Promote("m"); err == nil {
+		t.Fatalf("expected master promotion to fail")
+	}
+	if err := c.Resync("m"); err == nil {
+		t.Fatalf("expected master resync to fail")
+	}
+
+	c = nodes.NewCluster([]global.Node{
+		{Name: "m", Role: "master", Ready: true},
+		{Name: "s", Role: "slave", Ready: true, HTTP: global.Transport{Up: true}},
+	})
+	before := c.Snapshot()
+	if err := c.Promote("s"); err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+	after := c.Snapshot().Nodes
+	if after[0].Role != "slave" || after[0].Ready || after[1].Role != "master" {
+		t.Fatalf("unexpected roles after promotion: %+v", after)
+	}
+	if before.Nodes[1].Role != "slave" || c.Snapshot().Version != before.Version+1 {
+		t.Fatalf("expected promotion to publish a new snapshot without touching the old one")
+	}
+}
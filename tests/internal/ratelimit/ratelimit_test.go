@@ -0,0 +1,140 @@
+package ratelimit_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/ratelimit"
+	"github.com/elysiandb/elysian-gate/internal/transport/http/api"
+	"github.com/valyala/fasthttp"
+)
+
+func setup(rules ...configuration.RateLimitRule) {
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.RateLimit = configuration.RateLimit{Enabled: true, Rules: rules}
+	ratelimit.Reset()
+}
+
+func TestTokenBucketPerClient(t *testing.T) {
+	setup(configuration.RateLimitRule{Client: "*", Class: ratelimit.ClassWrite, Rate: 1, Burst: 2})
+
+	for i := 0; i < 2; i++ {
+		if !ratelimit.Check("a", "articles", ratelimit.ClassWrite).Allowed {
+			t.Fatalf("expected burst to be allowed")
+		}
+	}
+	d := ratelimit.Check("a", "articles", ratelimit.ClassWrite)
+	if d.Allowed || d.RetryAfter <= 0 || d.RetryAfter > time.Second {
+		t.Fatalf("expected third write to be limited, got %+v", d)
+	}
+	if !ratelimit.Check("b", "articles", ratelimit.ClassWrite).Allowed {
+		t.Fatalf("expected other clients to have their own bucket")
+	}
+	if !ratelimit.Check("a", "articles", ratelimit.ClassRead).Allowed {
+		t.Fatalf("expected reads not to be limited by a write rule")
+	}
+}
+
+func TestSharedEntityBucket(t *testing.T) {
+	setup(configuration.RateLimitRule{Entity: "billing", Rate: 1, Burst: 1})
+
+	if !ratelimit.Check("a", "billing", ratelimit.ClassRead).Allowed {
+		t.Fatalf("expected first call to be allowed")
+	}
+	if ratelimit.Check("b", "billing", ratelimit.ClassWrite).Allowed {
+		t.Fatalf("expected billing bucket to be shared across clients")
+	}
+	if !ratelimit.Check("b", "articles", ratelimit.ClassWrite).Allowed {
+		t.Fatalf("expected other entities not to be limited")
+	}
+}
+
+func TestDailyQuotaAndPersistence(t *testing.T) {
+	setup(configuration.RateLimitRule{Client: "svc", Daily: 2})
+
+	for i := 0; i < 2; i++ {
+		if !ratelimit.Check("svc", "articles", ratelimit.ClassRead).Allowed {
+			t.Fatalf("expected call %d to be within quota", i)
+		}
+	}
+	d := ratelimit.Check("svc", "articles", ratelimit.ClassRead)
+	if d.Allowed || d.RetryAfter <= 0 || d.RetryAfter > 24*time.Hour {
+		t.Fatalf("expected quota to be exhausted, got %+v", d)
+	}
+	if !ratelimit.Check("other", "articles", ratelimit.ClassRead).Allowed {
+		t.Fatalf("expected rule to apply to svc only")
+	}
+
+	file := filepath.Join(t.TempDir(), "quotas.json")
+	if err := ratelimit.SaveQuotas(file); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	ratelimit.Reset()
+	if err := ratelimit.LoadQuotas(file); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if ratelimit.Check("svc", "articles", ratelimit.ClassRead).Allowed {
+		t.Fatalf("expected persisted quota usage to survive a restart")
+	}
+}
+
+func TestIdleBucketsAreEvicted(t *testing.T) {
+	setup(configuration.RateLimitRule{Client: "*", Rate: 10, Burst: 10})
+	clock := time.Now()
+	ratelimit.SetClock(func() time.Time { return clock })
+	t.Cleanup(func() { ratelimit.SetClock(nil) })
+
+	for i := 0; i < 100; i++ {
+		ratelimit.Check(fmt.Sprintf("10.0.0.%d", i), "articles", ratelimit.ClassRead)
+	}
+	if ratelimit.Buckets() != 100 {
+		t.Fatalf("expected one bucket per client, got %d", ratelimit.Buckets())
+	}
+
+	clock = clock.Add(2 * time.Minute)
+	ratelimit.Check("10.0.0.1", "articles", ratelimit.ClassRead)
+	if ratelimit.Buckets() != 1 {
+		t.Fatalf("expected idle full buckets to be evicted, got %d", ratelimit.Buckets())
+	}
+}
+
+func TestQuotasSurviveRuleReordering(t *testing.T) {
+	daily := configuration.RateLimitRule{Name: "svc-daily", Client: "svc", Daily: 1}
+	setup(daily)
+	ratelimit.Check("svc", "articles", ratelimit.ClassRead)
+
+	configuration.Config.Gateway.RateLimit.Rules = []configuration.RateLimitRule{{Client: "*", Class: ratelimit.ClassWrite, Rate: 100}, daily}
+	if ratelimit.Check("svc", "articles", ratelimit.ClassRead).Allowed {
+		t.Fatalf("expected the quota to follow the rule, not its index")
+	}
+
+	cfg := configuration.ElysianGateConfig{Nodes: map[string]configuration.Node{
+		"m": {Role: "master", HTTP: configuration.Transport{Port: 1}, TCP: configuration.Transport{Port: 2}},
+	}}
+	cfg.Gateway.RateLimit.Rules = []configuration.RateLimitRule{{Client: "*", Rate: 1}, {Client: "*", Daily: 5}}
+	if err := configuration.Validate(cfg); err == nil || !strings.Contains(err.Error(), "distinct names") {
+		t.Fatalf("expected rules with the same key to be rejected, got %v", err)
+	}
+}
+
+func TestControllerReturns429(t *testing.T) {
+	setup(configuration.RateLimitRule{Client: "*", Class: ratelimit.ClassWrite, Rate: 0.001, Burst: 1})
+	ratelimit.Check("0.0.0.0", "articles", ratelimit.ClassWrite)
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod("DELETE")
+	ctx.Request.SetRequestURI("/api/articles")
+	ctx.SetUserValue("entity", "articles")
+	api.DestroyController(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", ctx.Response.StatusCode())
+	}
+	if len(ctx.Response.Header.Peek("Retry-After")) == 0 {
+		t.Fatalf("expected Retry-After header")
+	}
+}
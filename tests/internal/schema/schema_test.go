@@ -0,0 +1,143 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/schema"
+	"github.com/elysiandb/elysian-gate/internal/transport/http/api"
+	"github.com/valyala/fasthttp"
+)
+
+const articleSchema = `{
+  "type": "object",
+  "required": ["title", "value"],
+  "additionalProperties": false,
+  "properties": {
+    "id": { "type": "string" },
+    "title": { "type": "string", "minLength": 3, "pattern": "^title-" },
+    "value": { "type": "integer", "minimum": 0 },
+    "date": { "type": "string", "format": "date-time" },
+    "status": { "enum": ["draft", "published"] },
+    "tags": { "type": "array", "items": { "type": "string" }, "maxItems": 3, "uniqueItems": true },
+    "author": { "type": "object", "required": ["name"], "properties": { "name": { "type": "string" } } }
+  }
+}`
+
+func decode(s string) any {
+	var v any
+	json.Unmarshal([]byte(s), &v)
+	return v
+}
+
+func paths(violations []schema.Violation) string {
+	out := []string{}
+	for _, v := range violations {
+		out = append(out, v.Path)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestValidate(t *testing.T) {
+	s, err := schema.Parse([]byte(articleSchema))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	valid := `{"title":"title-1","value":3,"date":"2023-01-01T10:00:00Z","tags":["go","db"],"author":{"name":"Alice"}}`
+	if v := s.Validate(decode(valid), false); len(v) != 0 {
+		t.Fatalf("expected valid document, got %v", v)
+	}
+
+	invalid := `{"title":"x","value":-1.5,"date":"yesterday","status":"gone","tags":["go","go",1,"a"],"author":{},"extra":true}`
+	v := s.Validate(decode(invalid), false)
+	got := paths(v)
+	for _, want := range []string{"/title", "/value", "/date", "/status", "/tags", "/tags/2", "/author/name", "/extra"} {
+		if !strings.Contains(","+got+",", ","+want+",") {
+			t.Fatalf("expected violation at %s, got %v", want, v)
+		}
+	}
+
+	if v := s.Validate(decode(`{"value":1}`), true); len(v) != 0 {
+		t.Fatalf("expected partial update to skip required fields, got %v", v)
+	}
+	if v := s.Validate(decode(`{"value":1}`), false); paths(v) != "/title" {
+		t.Fatalf("expected missing title, got %v", v)
+	}
+}
+
+func TestParseRejectsUnsupportedKeywords(t *testing.T) {
+	for _, doc := range []string{
+		`{"$ref":"#/definitions/x"}`,
+		`{"type":"object","properties":{"a":{"anyOf":[{"type":"string"}]}}}`,
+		`{"items":{"not":{"type":"null"}}}`,
+		`{"patternProperties":{"^x":{}},"minProperties":1}`,
+		`{"additionalProperties":{"oneOf":[]}}`,
+		`{"dependentRequired":{"a":["b"]}}`,
+	} {
+		if _, err := schema.Parse([]byte(doc)); err == nil || !strings.Contains(err.Error(), "unsupported schema keyword") {
+			t.Fatalf("expected %s to be rejected, got %v", doc, err)
+		}
+	}
+	if _, err := schema.Parse([]byte(`{"$schema":"https://json-schema.org/draft/2020-12/schema","title":"t","description":"d","type":"string","default":"x"}`)); err != nil {
+		t.Fatalf("expected annotations to be accepted, got %v", err)
+	}
+}
+
+func setupController(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "articles.json")
+	os.WriteFile(file, []byte(articleSchema), 0644)
+
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.Validation = configuration.Validation{
+		MaxBodyBytes: 1024,
+		Entities: map[string]configuration.EntityValidation{
+			"articles": {SchemaFile: file, MaxBodyBytes: 128},
+		},
+	}
+	if err := schema.Init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+}
+
+func create(body string) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod("POST")
+	ctx.Request.SetRequestURI("/api/articles")
+	ctx.Request.SetBodyString(body)
+	ctx.SetUserValue("entity", "articles")
+	api.CreateController(ctx)
+	return ctx
+}
+
+func TestCreateControllerRejectsInvalidBodies(t *testing.T) {
+	setupController(t)
+
+	ctx := create(`{"title":"nope"}`)
+	if ctx.Response.StatusCode() != fasthttp.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", ctx.Response.StatusCode())
+	}
+	var resp struct {
+		Error      string             `json:"error"`
+		Violations []schema.Violation `json:"violations"`
+	}
+	json.Unmarshal(ctx.Response.Body(), &resp)
+	if resp.Error != "validation failed" || len(resp.Violations) != 2 {
+		t.Fatalf("unexpected response %s", ctx.Response.Body())
+	}
+
+	ctx = create(`{"title":`)
+	if ctx.Response.StatusCode() != fasthttp.StatusBadRequest || !strings.Contains(string(ctx.Response.Body()), "invalid JSON") {
+		t.Fatalf("expected malformed JSON to be rejected, got %d %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+
+	ctx = create(`{"title":"title-` + strings.Repeat("x", 200) + `","value":1}`)
+	if ctx.Response.StatusCode() != fasthttp.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d", ctx.Response.StatusCode())
+	}
+}
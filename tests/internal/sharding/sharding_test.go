@@ -0,0 +1,76 @@
+package sharding_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/sharding"
+)
+
+func initSharding() {
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.Sharding = configuration.Sharding{
+		DefaultGroup:   "g1",
+		Entities:       map[string]string{"billing": "g2"},
+		HashedEntities: []string{"events"},
+	}
+	sharding.Init([]string{"g2", "g1", "g1"})
+}
+
+func TestGroups(t *testing.T) {
+	initSharding()
+	groups := sharding.Groups()
+	if len(groups) != 2 || groups[0] != "g1" || groups[1] != "g2" {
+		t.Fatalf("unexpected groups %v", groups)
+	}
+	if sharding.DefaultGroup() != "g1" {
+		t.Fatalf("expected default group g1, got %s", sharding.DefaultGroup())
+	}
+}
+
+func TestGroupFor_EntityMapping(t *testing.T) {
+	initSharding()
+	if g := sharding.GroupFor("billing", "42"); g != "g2" {
+		t.Fatalf("expected billing on g2, got %s", g)
+	}
+	if g := sharding.GroupFor("articles", ""); g != "g1" {
+		t.Fatalf("expected unmapped entity on default group, got %s", g)
+	}
+}
+
+func TestGroupFor_Hashed(t *testing.T) {
+	initSharding()
+	if !sharding.IsHashed("events") || sharding.IsHashed("billing") {
+		t.Fatalf("unexpected hashed entities")
+	}
+	seen := map[string]int{}
+	for i := 0; i < 200; i++ {
+		id := fmt.Sprintf("id-%d", i)
+		g := sharding.GroupFor("events", id)
+		if g != sharding.GroupFor("events", id) {
+			t.Fatalf("hash routing is not stable for %s", id)
+		}
+		seen[g]++
+	}
+	if seen["g1"] == 0 || seen["g2"] == 0 {
+		t.Fatalf("expected ids spread over both groups, got %v", seen)
+	}
+}
+
+func TestParseAPIPath(t *testing.T) {
+	cases := map[string][2]string{
+		"/api/articles":         {"articles", ""},
+		"/api/articles/1":       {"articles", "1"},
+		"/api/articles/1?x=1":   {"articles", "1"},
+		"/kv/api:entity:types":  {"", ""},
+		"/api":                  {"", ""},
+		"/api/articles?sort=id": {"articles", ""},
+	}
+	for path, want := range cases {
+		entity, id := sharding.ParseAPIPath(path)
+		if entity != want[0] || id != want[1] {
+			t.Fatalf("%s: got (%s, %s), want %v", path, entity, id, want)
+		}
+	}
+}
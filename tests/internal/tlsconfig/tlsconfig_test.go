@@ -0,0 +1,160 @@
+package tlsconfig_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/forward"
+	"github.com/elysiandb/elysian-gate/internal/global"
+	"github.com/elysiandb/elysian-gate/internal/tlsconfig"
+)
+
+type pair struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func issue(t *testing.T, cn string, parent *pair, isCA bool) *pair {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IsCA:         isCA,
+
+		BasicConstraintsValid: true,
+	}
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &pair{cert: cert, key: key, der: der}
+}
+
+func write(t *testing.T, dir string, name string, p *pair) (string, string) {
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	keyDER, _ := x509.MarshalECPrivateKey(p.key)
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: p.der}), 0644)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	return certFile, keyFile
+}
+
+func TestMutualTLSUpstream(t *testing.T) {
+	dir := t.TempDir()
+	ca := issue(t, "ca", nil, true)
+	caFile, _ := write(t, dir, "ca", ca)
+	serverCert, serverKey := write(t, dir, "server", issue(t, "node", ca, false))
+	clientCert, clientKey := write(t, dir, "client", issue(t, "gateway", ca, false))
+
+	serverCfg, err := tlsconfig.ServerConfig(configuration.ServerTLS{
+		CertFile: serverCert, KeyFile: serverKey, ClientCAFile: caFile, RequireClientCert: true,
+	})
+	if err != nil {
+		t.Fatalf("server config: %v", err)
+	}
+	if serverCfg.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Fatalf("expected client certificates to be required")
+	}
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", serverCfg)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+		}),
+		ErrorLog: log.New(io.Discard, "", 0),
+	}
+	go s.Serve(ln)
+	defer s.Close()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	node := global.Node{HTTP: global.Transport{Host: "127.0.0.1", Port: addr.Port, TLS: true}}
+	if _, _, err := forward.ForwardRequest("GET", node.URL("/health"), ""); err == nil {
+		t.Fatalf("expected untrusted upstream to fail")
+	}
+
+	clientCfg, err := tlsconfig.ClientConfig(configuration.TLS{CAFile: caFile, CertFile: clientCert, KeyFile: clientKey})
+	if err != nil {
+		t.Fatalf("client config: %v", err)
+	}
+	forward.RegisterTLS(addr.String(), clientCfg)
+
+	status, body, err := forward.ForwardRequest("GET", node.URL("/health"), "")
+	if err != nil || status != 200 || body != "gateway" {
+		t.Fatalf("unexpected result %d %s %v", status, body, err)
+	}
+}
+
+func TestCertificateHotReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := issue(t, "ca", nil, true)
+	certFile, keyFile := write(t, dir, "server", issue(t, "first", ca, false))
+
+	r, err := tlsconfig.NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("reloader: %v", err)
+	}
+	cert, _ := r.GetCertificate(nil)
+	if leaf, _ := x509.ParseCertificate(cert.Certificate[0]); leaf.Subject.CommonName != "first" {
+		t.Fatalf("unexpected initial certificate %s", leaf.Subject.CommonName)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	write(t, dir, "server", issue(t, "second", ca, false))
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, future, future)
+
+	cert, _ = r.GetCertificate(nil)
+	if leaf, _ := x509.ParseCertificate(cert.Certificate[0]); leaf.Subject.CommonName != "second" {
+		t.Fatalf("expected reloaded certificate, got %s", leaf.Subject.CommonName)
+	}
+}
+
+func TestInvalidCABundle(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.pem")
+	os.WriteFile(bad, []byte("nope"), 0644)
+	if _, err := tlsconfig.ClientConfig(configuration.TLS{CAFile: bad}); err == nil {
+		t.Fatalf("expected invalid CA bundle to be rejected")
+	}
+}
+
+func TestRequireClientCertNeedsClientCA(t *testing.T) {
+	dir := t.TempDir()
+	ca := issue(t, "ca", nil, true)
+	certFile, keyFile := write(t, dir, "server", issue(t, "localhost", ca, false))
+	_, err := tlsconfig.ServerConfig(configuration.ServerTLS{Enabled: true, CertFile: certFile, KeyFile: keyFile, RequireClientCert: true})
+	if err == nil || !strings.Contains(err.Error(), "clientCAFile") {
+		t.Fatalf("expected requireClientCert without clientCAFile to be rejected, got %v", err)
+	}
+}
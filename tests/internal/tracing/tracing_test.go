@@ -0,0 +1,282 @@
+package tracing_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/elysiandb/elysian-gate/internal/accesslog"
+	"github.com/elysiandb/elysian-gate/internal/balancer"
+	"github.com/elysiandb/elysian-gate/internal/configuration"
+	"github.com/elysiandb/elysian-gate/internal/global"
+	"github.com/elysiandb/elysian-gate/internal/nodes"
+	"github.com/elysiandb/elysian-gate/internal/replication"
+	"github.com/elysiandb/elysian-gate/internal/tracing"
+	"github.com/elysiandb/elysian-gate/internal/transport/http/api"
+	"github.com/valyala/fasthttp"
+)
+
+type span struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Kind         int    `json:"kind"`
+	Links        []struct {
+		TraceID string `json:"traceId"`
+		SpanID  string `json:"spanId"`
+	} `json:"links"`
+}
+
+type export struct {
+	ResourceSpans []struct {
+		ScopeSpans []struct {
+			Spans []span `json:"spans"`
+		} `json:"scopeSpans"`
+	} `json:"resourceSpans"`
+}
+
+func (e export) spans() []span {
+	var out []span
+	for _, rs := range e.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			out = append(out, ss.Spans...)
+		}
+	}
+	return out
+}
+
+func enableFileTracing(t *testing.T) string {
+	file := filepath.Join(t.TempDir(), "spans.jsonl")
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.Tracing = configuration.Tracing{Enabled: true, File: file}
+	if err := tracing.Init(); err != nil {
+		t.Fatalf("init tracing: %v", err)
+	}
+	t.Cleanup(tracing.Shutdown)
+	return file
+}
+
+func readSpans(t *testing.T, file string) []span {
+	tracing.Flush()
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatalf("open spans: %v", err)
+	}
+	defer f.Close()
+	var spans []span
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		var e export
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("invalid export line: %v", err)
+		}
+		spans = append(spans, e.spans()...)
+	}
+	return spans
+}
+
+func byName(spans []span, name string) []span {
+	var out []span
+	for _, s := range spans {
+		if s.Name == name {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+type headerRecorder struct {
+	mu     sync.Mutex
+	values []string
+}
+
+func (h *headerRecorder) server(body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.mu.Lock()
+		h.values = append(h.values, r.Header.Get(tracing.TraceparentHeader))
+		h.mu.Unlock()
+		w.Write([]byte(body))
+	}))
+}
+
+func (h *headerRecorder) last() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.values) == 0 {
+		return ""
+	}
+	return h.values[len(h.values)-1]
+}
+
+func node(name, role string, s *httptest.Server) global.Node {
+	addr := s.Listener.Addr().(*net.TCPAddr)
+	return global.Node{Name: name, Role: role, Ready: true, HTTP: global.Transport{Host: addr.IP.String(), Port: addr.Port}}
+}
+
+func TestTraceparent(t *testing.T) {
+	in := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, ok := tracing.ParseTraceparent(in)
+	if !ok || !sc.Sampled || sc.Traceparent() != in {
+		t.Fatalf("expected round trip, got %q (%v)", sc.Traceparent(), ok)
+	}
+	for _, bad := range []string{"", "00-xyz-00f067aa0ba902b7-01", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "00-00000000000000000000000000000000-00f067aa0ba902b7-01"} {
+		if _, ok := tracing.ParseTraceparent(bad); ok {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestDisabledTracingIsNoop(t *testing.T) {
+	configuration.Config = configuration.ElysianGateConfig{}
+	tracing.Shutdown()
+	_, s := tracing.Start(t.Context(), "noop")
+	s.SetAttributes(tracing.Attr("k", "v"))
+	s.End()
+	if s != nil {
+		t.Fatalf("expected no span when tracing is disabled")
+	}
+}
+
+func TestWriteIsTracedAndPropagated(t *testing.T) {
+	file := enableFileTracing(t)
+	master, slave := &headerRecorder{}, &headerRecorder{}
+	ms, ss := master.server(`{"id":"1"}`), slave.server(`{}`)
+	defer ms.Close()
+	defer ss.Close()
+	nodes.ElysianCluster = nodes.NewCluster([]global.Node{node("m", "master", ms), node("s", "slave", ss)})
+
+	inbound := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod("POST")
+	ctx.Request.SetRequestURI("/api/articles")
+	ctx.Request.Header.Set(tracing.TraceparentHeader, inbound)
+	ctx.Request.SetBodyString(`{"title":"a"}`)
+	ctx.SetUserValue("entity", "articles")
+	accesslog.Middleware(tracing.Middleware(api.CreateController))(ctx)
+	balancer.SyncSlaves()
+
+	spans := readSpans(t, file)
+	server := byName(spans, "HTTP POST")
+	write := byName(spans, "balancer.SendWriteRequestToMaster")
+	appendOp := byName(spans, "balancer.appendOp")
+	sync := byName(spans, "balancer.SyncSlave")
+	if len(write) != 1 || len(appendOp) != 1 || len(sync) != 1 {
+		t.Fatalf("unexpected spans: %+v", spans)
+	}
+
+	var root, client span
+	for _, s := range server {
+		switch s.Kind {
+		case int(tracing.KindServer):
+			root = s
+		case int(tracing.KindClient):
+			if s.ParentSpanID == write[0].SpanID {
+				client = s
+			}
+		}
+	}
+	if root.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || root.ParentSpanID != "00f067aa0ba902b7" {
+		t.Fatalf("expected server span to continue inbound trace, got %+v", root)
+	}
+	if write[0].ParentSpanID != root.SpanID || appendOp[0].ParentSpanID != write[0].SpanID || client.SpanID == "" {
+		t.Fatalf("unexpected span tree: server=%+v write=%+v append=%+v", root, write[0], appendOp[0])
+	}
+	if want := "00-" + root.TraceID + "-" + client.SpanID + "-01"; master.last() != want {
+		t.Fatalf("expected master to receive %q, got %q", want, master.last())
+	}
+
+	if len(sync[0].Links) != 1 || sync[0].Links[0].SpanID != write[0].SpanID {
+		t.Fatalf("expected sync span to link the write, got %+v", sync[0])
+	}
+	if !strings.Contains(slave.last(), sync[0].TraceID) {
+		t.Fatalf("expected slave replay to carry the sync trace, got %q", slave.last())
+	}
+}
+
+func TestReplicationSpansPerEntityType(t *testing.T) {
+	file := enableFileTracing(t)
+	ms := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/kv/") {
+			w.Write([]byte(`{"key":"api:entity:types:list","value":"articles,users"}`))
+			return
+		}
+		w.Write([]byte(`[{"id":"1"}]`))
+	}))
+	defer ms.Close()
+	rec := &headerRecorder{}
+	ss := rec.server(`{}`)
+	defer ss.Close()
+	m, s := node("m", "master", ms), node("s", "slave", ss)
+
+	if err := replication.ReplicateMasterToNode(&m, &s); err != nil {
+		t.Fatalf("replicate: %v", err)
+	}
+
+	spans := readSpans(t, file)
+	root := byName(spans, "replication.ReplicateMasterToNode")
+	entities := byName(spans, "replication.ReplicateEntity")
+	if len(root) != 1 || len(entities) != 2 {
+		t.Fatalf("unexpected spans: %+v", spans)
+	}
+	for _, e := range entities {
+		if e.ParentSpanID != root[0].SpanID {
+			t.Fatalf("expected entity span under replication span, got %+v", e)
+		}
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	var bodies []string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		bodies = append(bodies, string(data))
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.Tracing = configuration.Tracing{Enabled: true, Exporter: "otlp", Endpoint: collector.URL, ServiceName: "gate-test"}
+	if err := tracing.Init(); err != nil {
+		t.Fatalf("init tracing: %v", err)
+	}
+	defer tracing.Shutdown()
+
+	_, s := tracing.Start(t.Context(), "op", tracing.Attr("n", 1))
+	s.End()
+	tracing.Flush()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(paths) != 1 || paths[0] != "/v1/traces" {
+		t.Fatalf("expected one export to /v1/traces, got %v", paths)
+	}
+	if !strings.Contains(bodies[0], `"gate-test"`) || !strings.Contains(bodies[0], `"name":"op"`) {
+		t.Fatalf("unexpected export body %s", bodies[0])
+	}
+}
+
+func TestInitRejectsUnknownExporter(t *testing.T) {
+	configuration.Config = configuration.ElysianGateConfig{}
+	configuration.Config.Gateway.Tracing = configuration.Tracing{Enabled: true, Exporter: "zipkin"}
+	if err := tracing.Init(); err == nil {
+		t.Fatalf("expected unknown exporter to be rejected")
+	}
+	configuration.Config.Gateway.Tracing = configuration.Tracing{Enabled: true, Exporter: "otlp"}
+	if err := tracing.Init(); err == nil {
+		t.Fatalf("expected otlp exporter without endpoint to be rejected")
+	}
+}